@@ -20,6 +20,7 @@ var shutdownRequested bool
 func New() (*Application, error) {
 	args := new(LaunchArgs)
 	flag.BoolVar(&args.runOnce, "once", false, "run all tests once and exit")
+	flag.BoolVar(&args.updateSnapshots, "update-snapshots", false, "write missing snapshots and rewrite existing ones by actual values")
 	flag.Parse()
 	args.configurationPath = flag.Arg(0)
 
@@ -36,6 +37,7 @@ func New() (*Application, error) {
 	application := Application{
 		args:         args,
 		tmpDirectory: tmpDirectory,
+		logs:         plugins.NewRunLogs(tmpDirectory),
 	}
 	application.setupGracefulExit()
 	return &application, nil
@@ -44,6 +46,7 @@ func New() (*Application, error) {
 type Application struct {
 	tmpDirectory      string
 	args              *LaunchArgs
+	logs              *plugins.RunLogs
 	config            *application_config.Config
 	parsedConfig      *testing.ParsedConfig
 	launcherType      string
@@ -55,6 +58,7 @@ type Application struct {
 type LaunchArgs struct {
 	configurationPath string
 	runOnce           bool
+	updateSnapshots   bool
 }
 
 func (a *Application) Start() error {
@@ -170,6 +174,11 @@ func (a *Application) runTests(cwd string) error {
 		return fmt.Errorf("unable to load config: %v", err)
 	}
 	a.config = config
+	testing.ConfigureSnapshots(config.SnapshotsDirectory, a.args.updateSnapshots)
+	err = helper.ConfigureTemplateFunctions(config.TemplateFunctions)
+	if err != nil {
+		return fmt.Errorf("unable to configure template functions: %v", err)
+	}
 
 	parsedConfig, err := testing.ParseConfig(config)
 	if err != nil {
@@ -185,7 +194,7 @@ func (a *Application) runTests(cwd string) error {
 		a.launcher = nil
 	}
 	if a.launcher == nil {
-		launcher, err := plugins.NewLauncher(config.Launcher, a.tmpDirectory)
+		launcher, err := plugins.NewLauncher(config.Launcher, a.tmpDirectory, a.logs)
 		if err != nil {
 			return fmt.Errorf("unable to create launcher: %v", err)
 		}
@@ -206,7 +215,7 @@ func (a *Application) runTests(cwd string) error {
 		}
 	}
 
-	exitCode := parsedConfig.RunTests()
+	exitCode := parsedConfig.RunTests(a.launcher.ApplicationsUrls(), a.logs)
 	if exitCode != 0 {
 		return fmt.Errorf("tests exited with code %d", exitCode)
 	}
@@ -232,6 +241,10 @@ func (a *Application) shutdown() {
 		}
 		a.launcher = nil
 	}
+	err := a.logs.Close()
+	if err != nil {
+		log.Printf("unable to close logs: %v", err)
+	}
 }
 
 func (a *LaunchArgs) Validate() error {
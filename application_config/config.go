@@ -3,15 +3,37 @@ package application_config
 import (
 	"fmt"
 	"integration_framework/helper"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// application name is used as host name of application in network
+var applicationNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_.\-]*$`)
+
+// DefaultApplicationName is name of application defined by `application` section
+const DefaultApplicationName = "application"
+
 type Config struct {
-	Launcher     string                   `yaml:"launcher"`
-	Application  *ApplicationConfig       `yaml:"application"`
-	Environment  map[string]string        `yaml:"environment"`
-	GeneralCases map[string]GeneralCase   `yaml:"general_cases"`
-	Services     map[string]ServiceConfig `yaml:"services"`
-	Cases        TestCases                `yaml:"cases"`
+	Launcher    string             `yaml:"launcher"`
+	Application *ApplicationConfig `yaml:"application"`
+	// applications under test by name, it is used instead of `application` to test several applications together.
+	// after loading it contains `application` too, so it describes all applications
+	Applications map[string]*ApplicationConfig `yaml:"applications"`
+	Environment  map[string]string             `yaml:"environment"`
+	GeneralCases map[string]GeneralCase        `yaml:"general_cases"`
+	Services     map[string]ServiceConfig      `yaml:"services"`
+	Cases        TestCases                     `yaml:"cases"`
+	// directory to store snapshots in. relative to config, `__snapshots__` by default
+	SnapshotsDirectory string `yaml:"snapshots_directory"`
+	// params of functions available in templates, like `hash_password: {cost: 10}`
+	TemplateFunctions map[string]map[string]interface{} `yaml:"template_functions"`
+	// endpoints of already running services for "external" launcher
+	External *ExternalConfig `yaml:"external"`
+	// how long and how often to wait for services and application to become ready
+	Readiness *ReadinessConfig `yaml:"readiness"`
 }
 
 type TestCases map[string]*TestCase
@@ -22,6 +44,8 @@ type GeneralCase struct {
 }
 
 type TestCase struct {
+	// name of application to send request to. it is inherited by sub-cases and can be omitted if there is only one application
+	Application     string                 `yaml:"application"`
 	PrepareServices map[string]interface{} `yaml:"prepare_services"`
 	Request         interface{}            `yaml:"request"`
 	// if ModifyRequest is empty then default headers will be added
@@ -37,12 +61,22 @@ type TestCase struct {
 	Skip             bool                     `yaml:"skip"`
 	Cases            TestCases                `yaml:"cases"`
 	GeneralCases     *GeneralCasesSelector    `yaml:"general_cases"`
+	Parameters       *TestCaseParameters      `yaml:"parameters"`
+}
+
+// TestCaseParameters can be defined as list of rows (each row is map of parameters)
+// or as matrix (map of axes, each axis is list of values of parameter)
+type TestCaseParameters struct {
+	Rows   []map[string]interface{}
+	Matrix map[string][]interface{}
 }
 
 type GeneralCasesSelector struct {
 	Request interface{} `yaml:"request"`
 	Include []string    `yaml:"include"`
 	Exclude []string    `yaml:"exclude"`
+	// parameters for included general cases
+	With map[string]interface{} `yaml:"with"`
 }
 
 type ApplicationConfig struct {
@@ -50,6 +84,63 @@ type ApplicationConfig struct {
 	RequestType     string          `yaml:"request_type"`
 	RequestDefaults RequestDefaults `yaml:"request_defaults"`
 	Dockerize       bool            `yaml:"dockerize"`
+	// command to run application. it overrides command of image for docker launchers,
+	// "local" launcher runs it on host, like `go run ./cmd/api`
+	Command string `yaml:"command"`
+	// path to check readiness of application. if it is defined then application is ready when path returns 2xx,
+	// else application is ready when it responds anything on `/`
+	HealthPath string `yaml:"health_path"`
+	// port of application on host. free port is used if it is not defined
+	HostPort int `yaml:"host_port"`
+	// prebuilt image of application. if it is defined then application is not built from path
+	Image string `yaml:"image"`
+	// dockerfile to build application, relative to path. `Dockerfile.prod` by default
+	Dockerfile string            `yaml:"dockerfile"`
+	BuildArgs  map[string]string `yaml:"build_args"`
+	// target stage of multi-stage dockerfile
+	Target     string `yaml:"target"`
+	Entrypoint string `yaml:"entrypoint"`
+	// port application listens to inside of container. 8080 by default
+	Port        int                `yaml:"port"`
+	Healthcheck *HealthcheckConfig `yaml:"healthcheck"`
+	// files with environment of application, relative to path. values from `environment` have priority
+	EnvFile []string `yaml:"env_file"`
+	// restart policy of container: no, always, on-failure or unless-stopped. `on-failure` by default
+	Restart string `yaml:"restart"`
+	// user to run container as. `${UID}:${GID}` by default so files created by application are accessible
+	User      string           `yaml:"user"`
+	Resources *ResourcesConfig `yaml:"resources"`
+	// environment of application. it is added to common `environment` and has priority over it
+	Environment map[string]string `yaml:"environment"`
+	// names of services by variables of environment containing their addresses, like `DB_PORT: postgres`.
+	// "local" launcher rewrites addresses in these variables to published ports of services on localhost
+	LocalAddresses map[string]string `yaml:"local_addresses"`
+}
+
+// HealthcheckConfig defines healthcheck of container. test is shell command, durations are like `5s`
+type HealthcheckConfig struct {
+	Test        string `yaml:"test"`
+	Interval    string `yaml:"interval"`
+	Timeout     string `yaml:"timeout"`
+	Retries     int    `yaml:"retries"`
+	StartPeriod string `yaml:"start_period"`
+}
+
+// ResourcesConfig limits resources of container. cpus is number of cpus like `0.5`, memory is like `512m` or `1g`
+type ResourcesConfig struct {
+	Cpus   float64 `yaml:"cpus"`
+	Memory string  `yaml:"memory"`
+}
+
+type ExternalConfig struct {
+	// allow preparers like `clear` which can destroy data of external services
+	AllowDestructive bool `yaml:"allow_destructive"`
+	// endpoints of services by service name: DSN for databases, url for http and smtp, root directory for filesystem
+	Services map[string]string `yaml:"services"`
+	// url of application like `http://host:port`
+	ApplicationUrl string `yaml:"application_url"`
+	// urls of applications by name if several applications defined
+	Applications map[string]string `yaml:"applications"`
 }
 
 type RequestDefaults struct {
@@ -59,9 +150,19 @@ type RequestDefaults struct {
 }
 
 type ServiceConfig struct {
-	Type   string                 `yaml:"type"`
-	Env    ServiceDefinitionEnv   `yaml:"env"`
-	Params map[string]interface{} `yaml:",inline"`
+	Type      string               `yaml:"type"`
+	Env       ServiceDefinitionEnv `yaml:"env"`
+	Readiness *ReadinessConfig     `yaml:"readiness"`
+	// names of applications which receive environment of service. all applications receive it by default
+	Applications []string               `yaml:"applications"`
+	Params       map[string]interface{} `yaml:",inline"`
+}
+
+// ReadinessConfig defines durations like `30s`. Interval doubles after every failed check until it reaches max_interval
+type ReadinessConfig struct {
+	Timeout     string `yaml:"timeout"`
+	Interval    string `yaml:"interval"`
+	MaxInterval string `yaml:"max_interval"`
 }
 
 type ServiceDefinitionEnv struct {
@@ -102,6 +203,48 @@ func (c *Config) Join(otherConfig *Config) error {
 		c.Application = otherConfig.Application
 	}
 
+	for otherApplicationName, otherApplication := range otherConfig.Applications {
+		for applicationName := range c.Applications {
+			if applicationName == otherApplicationName {
+				return fmt.Errorf("applications can not be re-defined")
+			}
+		}
+		if c.Applications == nil {
+			c.Applications = make(map[string]*ApplicationConfig)
+		}
+		c.Applications[otherApplicationName] = otherApplication
+	}
+
+	if otherConfig.SnapshotsDirectory != "" {
+		if c.SnapshotsDirectory != "" {
+			return fmt.Errorf("snapshots directory can not be re-defined")
+		}
+		c.SnapshotsDirectory = otherConfig.SnapshotsDirectory
+	}
+
+	if otherConfig.Readiness != nil {
+		if c.Readiness != nil {
+			return fmt.Errorf("readiness config can not be re-defined")
+		}
+		c.Readiness = otherConfig.Readiness
+	}
+
+	if otherConfig.External != nil {
+		if c.External != nil {
+			return fmt.Errorf("external config can not be re-defined")
+		}
+		c.External = otherConfig.External
+	}
+
+	for otherFunctionName, otherFunctionParams := range otherConfig.TemplateFunctions {
+		for functionName := range c.TemplateFunctions {
+			if functionName == otherFunctionName {
+				return fmt.Errorf("template function params can not be re-defined")
+			}
+		}
+		c.TemplateFunctions[otherFunctionName] = otherFunctionParams
+	}
+
 	for otherServiceName, otherService := range otherConfig.Services {
 		for serviceName := range c.Services {
 			if serviceName == otherServiceName {
@@ -123,17 +266,40 @@ func (c Config) Validate() error {
 	if c.Launcher == "" {
 		return fmt.Errorf("launcher not specified")
 	}
-	if c.Application == nil {
+	if c.Application == nil && len(c.Applications) == 0 {
 		return fmt.Errorf("application config not specified")
 	}
-	if c.Application.RequestType == "" {
-		return fmt.Errorf("application.request_type not specified")
+	if c.Application != nil {
+		if len(c.Applications) != 0 {
+			return fmt.Errorf("application and applications can not be defined together")
+		}
+		err := c.Application.Validate()
+		if err != nil {
+			return fmt.Errorf("application config invalid: %v", err)
+		}
+	}
+	for applicationName, application := range c.Applications {
+		if !applicationNameRegexp.MatchString(applicationName) {
+			return fmt.Errorf("application name %q should contain only lowercase letters, digits, `_`, `.` and `-`", applicationName)
+		}
+		if application == nil {
+			return fmt.Errorf("application %q not specified", applicationName)
+		}
+		err := application.Validate()
+		if err != nil {
+			return fmt.Errorf("application %q invalid: %v", applicationName, err)
+		}
 	}
-	if c.Application.RequestDefaults.Method == "" {
-		return fmt.Errorf("application.request_defaults.method not specified")
+	if c.Readiness != nil {
+		err := c.Readiness.Validate()
+		if err != nil {
+			return fmt.Errorf("readiness config invalid: %v", err)
+		}
 	}
-	if c.Application.RequestDefaults.Url == "" {
-		return fmt.Errorf("application.request_defaults.url not specified")
+	for functionName := range c.TemplateFunctions {
+		if !helper.IsTemplateFunctionDefined(functionName) {
+			return fmt.Errorf("template function %q not defined", functionName)
+		}
 	}
 	for generalCaseName, generalCase := range c.GeneralCases {
 		err := generalCase.Validate()
@@ -146,6 +312,11 @@ func (c Config) Validate() error {
 		if err != nil {
 			return fmt.Errorf("service %q invalid: %v", serviceName, err)
 		}
+		for _, applicationName := range service.Applications {
+			if !c.isApplicationDefined(applicationName) {
+				return fmt.Errorf("service %q invalid: application %q not defined", serviceName, applicationName)
+			}
+		}
 	}
 	for testCaseName, testCase := range c.Cases {
 		err := testCase.Validate()
@@ -156,6 +327,149 @@ func (c Config) Validate() error {
 	return nil
 }
 
+func (c Config) isApplicationDefined(applicationName string) bool {
+	if c.Application != nil && applicationName == DefaultApplicationName {
+		return true
+	}
+	_, ok := c.Applications[applicationName]
+	return ok
+}
+
+// ApplicationsNames returns sorted names of all applications
+func (c Config) ApplicationsNames() []string {
+	var res []string
+	for applicationName := range c.Applications {
+		res = append(res, applicationName)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// normalizeApplications adds application defined by `application` section to applications
+func (c *Config) normalizeApplications() {
+	if c.Application == nil {
+		return
+	}
+	c.Applications = map[string]*ApplicationConfig{
+		DefaultApplicationName: c.Application,
+	}
+}
+
+func (ac ApplicationConfig) Validate() error {
+	if ac.RequestType == "" {
+		return fmt.Errorf("request_type not specified")
+	}
+	if ac.RequestDefaults.Method == "" {
+		return fmt.Errorf("request_defaults.method not specified")
+	}
+	if ac.RequestDefaults.Url == "" {
+		return fmt.Errorf("request_defaults.url not specified")
+	}
+	if ac.HostPort < 0 || ac.HostPort > 65535 {
+		return fmt.Errorf("host_port should be valid port")
+	}
+	if ac.HealthPath != "" && !strings.HasPrefix(ac.HealthPath, "/") {
+		return fmt.Errorf("health_path should start with /")
+	}
+	if ac.Image != "" && (ac.Dockerfile != "" || len(ac.BuildArgs) != 0 || ac.Target != "") {
+		return fmt.Errorf("image can not be used with dockerfile, build_args or target")
+	}
+	if ac.Port < 0 || ac.Port > 65535 {
+		return fmt.Errorf("port should be valid port")
+	}
+	switch ac.Restart {
+	case "", "no", "always", "on-failure", "unless-stopped":
+	default:
+		return fmt.Errorf("restart should be one of no, always, on-failure, unless-stopped")
+	}
+	for i, envFile := range ac.EnvFile {
+		if envFile == "" {
+			return fmt.Errorf("env_file[%d] should not be empty", i)
+		}
+	}
+	if ac.Healthcheck != nil {
+		err := ac.Healthcheck.Validate()
+		if err != nil {
+			return fmt.Errorf("healthcheck invalid: %v", err)
+		}
+	}
+	if ac.Resources != nil {
+		err := ac.Resources.Validate()
+		if err != nil {
+			return fmt.Errorf("resources invalid: %v", err)
+		}
+	}
+	return nil
+}
+
+func (hc HealthcheckConfig) Validate() error {
+	if hc.Test == "" {
+		return fmt.Errorf("test not specified")
+	}
+	if hc.Retries < 0 {
+		return fmt.Errorf("retries should not be negative")
+	}
+	err := validatePositiveDuration("interval", hc.Interval)
+	if err != nil {
+		return err
+	}
+	err = validatePositiveDuration("timeout", hc.Timeout)
+	if err != nil {
+		return err
+	}
+	return validatePositiveDuration("start_period", hc.StartPeriod)
+}
+
+func validatePositiveDuration(name string, value string) error {
+	duration, err := parseOptionalDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", name, err)
+	}
+	if duration < 0 {
+		return fmt.Errorf("%s should not be negative", name)
+	}
+	return nil
+}
+
+func (rc ResourcesConfig) Validate() error {
+	if rc.Cpus < 0 {
+		return fmt.Errorf("cpus should not be negative")
+	}
+	if rc.Memory != "" {
+		_, err := ParseMemoryLimit(rc.Memory)
+		if err != nil {
+			return fmt.Errorf("invalid memory: %v", err)
+		}
+	}
+	return nil
+}
+
+// ParseMemoryLimit parses memory like `512m`, `1g` or `1048576` to bytes
+func ParseMemoryLimit(s string) (int64, error) {
+	multiplier := int64(1)
+	number := strings.ToLower(strings.TrimSpace(s))
+	number = strings.TrimSuffix(number, "b")
+	switch {
+	case strings.HasSuffix(number, "k"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(number, "m"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(number, "g"):
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		number = number[:len(number)-1]
+	}
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse memory %q", s)
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("memory %q should be positive", s)
+	}
+	return value * multiplier, nil
+}
+
 func (gc GeneralCase) Validate() error {
 	for testCaseName, testCase := range gc.Cases {
 		err := testCase.Validate()
@@ -170,9 +484,53 @@ func (sc ServiceConfig) Validate() error {
 	if sc.Type == "" {
 		return fmt.Errorf("service type not specified")
 	}
+	if sc.Readiness != nil {
+		err := sc.Readiness.Validate()
+		if err != nil {
+			return fmt.Errorf("readiness config invalid: %v", err)
+		}
+	}
 	return nil
 }
 
+func (rc ReadinessConfig) Validate() error {
+	timeout, interval, maxInterval, err := rc.Durations()
+	if err != nil {
+		return err
+	}
+	if timeout < 0 || interval < 0 || maxInterval < 0 {
+		return fmt.Errorf("durations should not be negative")
+	}
+	if interval != 0 && maxInterval != 0 && interval > maxInterval {
+		return fmt.Errorf("interval should not be greater than max_interval")
+	}
+	return nil
+}
+
+// Durations returns parsed durations. Not defined durations are zero
+func (rc ReadinessConfig) Durations() (timeout time.Duration, interval time.Duration, maxInterval time.Duration, err error) {
+	timeout, err = parseOptionalDuration(rc.Timeout)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid timeout: %v", err)
+	}
+	interval, err = parseOptionalDuration(rc.Interval)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid interval: %v", err)
+	}
+	maxInterval, err = parseOptionalDuration(rc.MaxInterval)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid max_interval: %v", err)
+	}
+	return timeout, interval, maxInterval, nil
+}
+
+func parseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
 func (tcs *TestCases) Join(otherTestCases TestCases, prefix string) error {
 	for otherTestCaseName, otherTestCase := range otherTestCases {
 		found := false
@@ -215,6 +573,13 @@ func (tcs *TestCases) UnmarshalYAML(unmarshal func(interface{}) error) error {
 }
 
 func (tc *TestCase) Join(otherTestCase *TestCase, prefix string) error {
+	if otherTestCase.Application != "" {
+		if tc.Application != "" {
+			return fmt.Errorf("application can not be re-defined")
+		}
+		tc.Application = otherTestCase.Application
+	}
+
 	if otherTestCase.PrepareServices != nil {
 		if tc.PrepareServices != nil {
 			return fmt.Errorf("prepare_services section can not be re-defined")
@@ -264,6 +629,13 @@ func (tc *TestCase) Join(otherTestCase *TestCase, prefix string) error {
 		tc.GeneralCases = otherTestCase.GeneralCases
 	}
 
+	if otherTestCase.Parameters != nil {
+		if tc.Parameters != nil {
+			return fmt.Errorf("parameters can not be re-defined")
+		}
+		tc.Parameters = otherTestCase.Parameters
+	}
+
 	if otherTestCase.Only {
 		tc.Only = true
 	}
@@ -277,6 +649,12 @@ func (tc *TestCase) Join(otherTestCase *TestCase, prefix string) error {
 }
 
 func (tc TestCase) Validate() error {
+	if tc.Parameters != nil {
+		err := tc.Parameters.Validate()
+		if err != nil {
+			return fmt.Errorf("parameters invalid: %v", err)
+		}
+	}
 	for testCaseName, testCase := range tc.Cases {
 		err := testCase.Validate()
 		if err != nil {
@@ -297,3 +675,93 @@ func (e *ServiceDefinitionEnv) UnmarshalYAML(unmarshal func(interface{}) error)
 	}
 	return fmt.Errorf("str unmarshal error: %v\nmap unmarshal error: %v", strErr, mapErr)
 }
+
+func (p *TestCaseParameters) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var rows []map[string]interface{}
+	rowsErr := unmarshal(&rows)
+	if rowsErr == nil {
+		for _, row := range rows {
+			for parameterName, parameterValue := range row {
+				row[parameterName] = helper.YamlValueToJsonValue(parameterValue)
+			}
+		}
+		p.Rows = rows
+		return nil
+	}
+	var matrix map[string][]interface{}
+	matrixErr := unmarshal(&matrix)
+	if matrixErr == nil {
+		for _, axis := range matrix {
+			for i, parameterValue := range axis {
+				axis[i] = helper.YamlValueToJsonValue(parameterValue)
+			}
+		}
+		p.Matrix = matrix
+		return nil
+	}
+	return fmt.Errorf("rows unmarshal error: %v\nmatrix unmarshal error: %v", rowsErr, matrixErr)
+}
+
+func (p TestCaseParameters) Validate() error {
+	if p.Rows != nil {
+		if len(p.Rows) == 0 {
+			return fmt.Errorf("at least one row should be defined")
+		}
+		for i, row := range p.Rows {
+			if len(row) == 0 {
+				return fmt.Errorf("row #%d is empty", i)
+			}
+		}
+		return nil
+	}
+	if len(p.Matrix) == 0 {
+		return fmt.Errorf("at least one axis should be defined")
+	}
+	for axisName, axis := range p.Matrix {
+		if len(axis) == 0 {
+			return fmt.Errorf("axis %q is empty", axisName)
+		}
+	}
+	return nil
+}
+
+// Combinations returns all sets of parameters values.
+// For rows it is rows itself, for matrix it is cartesian product of all axes
+func (p TestCaseParameters) Combinations() []map[string]interface{} {
+	if p.Rows != nil {
+		return p.Rows
+	}
+	var axesNames []string
+	for axisName := range p.Matrix {
+		axesNames = append(axesNames, axisName)
+	}
+	sort.Strings(axesNames)
+	combinations := []map[string]interface{}{{}}
+	for _, axisName := range axesNames {
+		var nextCombinations []map[string]interface{}
+		for _, combination := range combinations {
+			for _, parameterValue := range p.Matrix[axisName] {
+				nextCombination := make(map[string]interface{}, len(combination)+1)
+				for parameterName, value := range combination {
+					nextCombination[parameterName] = value
+				}
+				nextCombination[axisName] = parameterValue
+				nextCombinations = append(nextCombinations, nextCombination)
+			}
+		}
+		combinations = nextCombinations
+	}
+	return combinations
+}
+
+func (s *GeneralCasesSelector) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain GeneralCasesSelector
+	err := unmarshal((*plain)(s))
+	if err != nil {
+		return err
+	}
+	for paramName, paramValue := range s.With {
+		s.With[paramName] = helper.YamlValueToJsonValue(paramValue)
+	}
+	return nil
+}
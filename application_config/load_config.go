@@ -41,14 +41,41 @@ func LoadConfig(pathToConfig string, rootToResolveRelativePaths string) (*Config
 		return nil, fmt.Errorf("config invalid: %v", err)
 	}
 
-	config.Application.Path, err = absPath(config.Application.Path, filepath.Dir(absolutePathToConfig))
+	config.normalizeApplications()
+	for applicationName, application := range config.Applications {
+		err = resolveApplicationPaths(application, filepath.Dir(absolutePathToConfig))
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve paths of application %q: %v", applicationName, err)
+		}
+	}
+
+	if config.SnapshotsDirectory == "" {
+		config.SnapshotsDirectory = "__snapshots__"
+	}
+	configDirectory, err := configDirectory(absolutePathToConfig)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get absolute path to application: %v", err)
+		return nil, fmt.Errorf("unable to get config directory: %v", err)
+	}
+	config.SnapshotsDirectory, err = absPath(config.SnapshotsDirectory, configDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get absolute path to snapshots directory: %v", err)
 	}
 
 	return config, nil
 }
 
+// configDirectory returns path itself if config is directory and parent directory if config is single file
+func configDirectory(absolutePathToConfig string) (string, error) {
+	fileInfo, err := os.Stat(absolutePathToConfig)
+	if err != nil {
+		return "", fmt.Errorf("unable to stat file %s: %v", absolutePathToConfig, err)
+	}
+	if fileInfo.IsDir() {
+		return absolutePathToConfig, nil
+	}
+	return filepath.Dir(absolutePathToConfig), nil
+}
+
 func IterateOverConfigFiles(path string, callback func(filename string) error) error {
 	fileInfo, err := os.Stat(path)
 	if err != nil {
@@ -86,6 +113,20 @@ func IterateOverConfigFiles(path string, callback func(filename string) error) e
 	return nil
 }
 
+func resolveApplicationPaths(application *ApplicationConfig, rootToResolveRelativePaths string) (err error) {
+	application.Path, err = absPath(application.Path, rootToResolveRelativePaths)
+	if err != nil {
+		return fmt.Errorf("unable to get absolute path to application: %v", err)
+	}
+	for i, envFile := range application.EnvFile {
+		application.EnvFile[i], err = absPath(envFile, application.Path)
+		if err != nil {
+			return fmt.Errorf("unable to get absolute path to env file: %v", err)
+		}
+	}
+	return nil
+}
+
 func loadAllFilesInPath(path string) ([]*Config, error) {
 	var configs []*Config
 	err := IterateOverConfigFiles(path, func(filename string) error {
@@ -120,6 +161,8 @@ func joinConfigs(configs []*Config) (*Config, error) {
 		GeneralCases: make(map[string]GeneralCase),
 		Environment:  make(map[string]string),
 		Services:     make(map[string]ServiceConfig),
+
+		TemplateFunctions: make(map[string]map[string]interface{}),
 	}
 	for _, config := range configs {
 		err := res.Join(config)
@@ -0,0 +1,43 @@
+// Command http_mock runs mock of upstream http service in container. Config is read from `/config.json`, port is defined by env PORT.
+// If env TLS_PORT is defined then https is served on it with certificates from config
+package main
+
+import (
+	flag "github.com/spf13/pflag"
+	"integration_framework/http_mock"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	pathToConfig := flag.String("config", "/config.json", "path to config of mock")
+	flag.Parse()
+
+	config, err := http_mock.LoadConfig(*pathToConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	server := http_mock.NewServer(*config)
+	if tlsPort := os.Getenv("TLS_PORT"); tlsPort != "" {
+		go func() {
+			log.Printf("%s: listening tls on port %s", config.ServiceName, tlsPort)
+			tlsServer := &http.Server{
+				Addr:      ":" + tlsPort,
+				Handler:   server,
+				TLSConfig: server.TlsConfig(),
+			}
+			// certificates are taken from TLSConfig
+			log.Fatal(tlsServer.ListenAndServeTLS("", ""))
+		}()
+	}
+	log.Printf("%s: listening on port %s", config.ServiceName, port)
+	err = http.ListenAndServe(":"+port, server)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,35 @@
+// Command smtp_mock runs smtp server capturing mails in container. Ports are defined by env SMTP_PORT and HTTP_PORT, credentials by SMTP_USERNAME and SMTP_PASSWORD
+package main
+
+import (
+	"integration_framework/smtp_mock"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	server := smtp_mock.NewServer(os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+	smtpServer := server.SmtpServer(":" + envOrDefault("SMTP_PORT", "25"))
+	go func() {
+		log.Printf("smtp server listening on %s", smtpServer.Addr)
+		err := smtpServer.ListenAndServe()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}()
+	httpPort := envOrDefault("HTTP_PORT", "8080")
+	log.Printf("http api listening on port %s", httpPort)
+	err := http.ListenAndServe(":"+httpPort, server)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func envOrDefault(name string, defaultValue string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -2,7 +2,6 @@ package helper
 
 import (
 	"fmt"
-	"net/http"
 	"os"
 )
 
@@ -45,7 +44,7 @@ func FillEnvironment(dst *map[string]string, paramToEnvVarNameMap map[string]int
 
 type YamlMap map[interface{}]interface{}
 
-func yamlValueToJsonValue(v interface{}) interface{} {
+func YamlValueToJsonValue(v interface{}) interface{} {
 	yamlMap, ok := IsYamlMap(v)
 	if ok {
 		return yamlMap.ToMap()
@@ -54,7 +53,7 @@ func yamlValueToJsonValue(v interface{}) interface{} {
 	if ok {
 		r := make([]interface{}, len(slice))
 		for i, item := range slice {
-			r[i] = yamlValueToJsonValue(item)
+			r[i] = YamlValueToJsonValue(item)
 		}
 		return r
 	}
@@ -64,7 +63,7 @@ func yamlValueToJsonValue(v interface{}) interface{} {
 func (m YamlMap) ToMap() map[string]interface{} {
 	res := make(map[string]interface{})
 	for k, v := range m {
-		res[fmt.Sprintf("%v", k)] = yamlValueToJsonValue(v)
+		res[fmt.Sprintf("%v", k)] = YamlValueToJsonValue(v)
 	}
 	return res
 }
@@ -79,12 +78,3 @@ func IsYamlMap(v interface{}) (YamlMap, bool) {
 	}
 	return nil, false
 }
-
-func IsHttpPortAvailable(host string, port int) bool {
-	_, err := http.Get(fmt.Sprintf("http://%s:%d", host, port))
-	if err != nil {
-		// fmt.Printf("is port available connection err: %v\n", err)
-		return false
-	}
-	return true
-}
@@ -2,70 +2,167 @@ package helper
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
 	"strings"
 	"text/template"
+	"text/template/parse"
 )
 
+// captureFunction is added to templates to get result of action as is
+const captureFunction = "__capture"
+
+// ApplyInterpolationForObject applies interpolation to every string in object keeping its structure.
+// If string is exactly one action like `{{ .response.id }}` then result of action used as is, so it keeps its type (number, map, list).
+// Otherwise string is interpolated as template and result is string.
+// Missing key of map is an error with path of value in object, so misspelled name is not interpolated silently
 func ApplyInterpolationForObject(input interface{}, variables map[string]interface{}) (interface{}, error) {
-	encoded, err := json.Marshal(input)
-	if err != nil {
-		return nil, fmt.Errorf("unable to marshal input: %v", err)
+	return applyInterpolationForObject(input, variables, "")
+}
+
+func applyInterpolationForObject(input interface{}, variables map[string]interface{}, objectPath string) (interface{}, error) {
+	switch value := input.(type) {
+	case string:
+		res, err := applyInterpolationForValue(value, variables)
+		if err != nil {
+			return nil, interpolationError(objectPath, err)
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(value))
+		for i, item := range value {
+			interpolatedItem, err := applyInterpolationForObject(item, variables, fmt.Sprintf("%s[%d]", objectPath, i))
+			if err != nil {
+				return nil, err
+			}
+			res[i] = interpolatedItem
+		}
+		return res, nil
+	case []map[string]interface{}:
+		res := make([]map[string]interface{}, len(value))
+		for i, item := range value {
+			interpolatedItem, err := applyInterpolationForObject(item, variables, fmt.Sprintf("%s[%d]", objectPath, i))
+			if err != nil {
+				return nil, err
+			}
+			res[i] = interpolatedItem.(map[string]interface{})
+		}
+		return res, nil
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			interpolatedKey, err := ApplyInterpolation(k, variables)
+			if err != nil {
+				return nil, interpolationError(objectPath+"."+k, err)
+			}
+			interpolatedValue, err := applyInterpolationForObject(v, variables, objectPath+"."+k)
+			if err != nil {
+				return nil, err
+			}
+			res[interpolatedKey] = interpolatedValue
+		}
+		return res, nil
+	}
+	yamlMap, ok := IsYamlMap(input)
+	if !ok {
+		return input, nil
+	}
+	// yaml maps should remain yaml maps to be used in places where yaml-parsed values expected (like requesters)
+	res := make(map[interface{}]interface{}, len(yamlMap))
+	for k, v := range yamlMap {
+		keyPath := fmt.Sprintf("%s.%v", objectPath, k)
+		var interpolatedKey interface{} = k
+		if stringKey, ok := k.(string); ok {
+			var err error
+			interpolatedKey, err = ApplyInterpolation(stringKey, variables)
+			if err != nil {
+				return nil, interpolationError(keyPath, err)
+			}
+		}
+		interpolatedValue, err := applyInterpolationForObject(v, variables, keyPath)
+		if err != nil {
+			return nil, err
+		}
+		res[interpolatedKey] = interpolatedValue
+	}
+	if _, ok := input.(YamlMap); ok {
+		return YamlMap(res), nil
 	}
+	return res, nil
+}
 
-	encodedAndInterpolated, err := ApplyInterpolation(string(encoded), variables)
+func interpolationError(objectPath string, err error) error {
+	if objectPath == "" {
+		return err
+	}
+	return fmt.Errorf("unable to interpolate value of key %q: %v", objectPath, err)
+}
+
+// applyInterpolationForValue returns result of action as is if string contains only one action, otherwise returns interpolated string
+func applyInterpolationForValue(str string, variables map[string]interface{}) (interface{}, error) {
+	if !strings.Contains(str, "{{") {
+		return str, nil
+	}
+	tmpl, err := newTemplate(str)
 	if err != nil {
 		return nil, err
 	}
+	nodes := tmpl.Tree.Root.Nodes
+	if len(nodes) != 1 {
+		return executeTemplate(tmpl, variables)
+	}
+	action, ok := nodes[0].(*parse.ActionNode)
+	if !ok || len(action.Pipe.Decl) != 0 {
+		return executeTemplate(tmpl, variables)
+	}
 
-	var output interface{}
-	err = json.Unmarshal([]byte(encodedAndInterpolated), &output)
+	// result of action is passed to capturing function appended to pipeline of parsed action,
+	// so action is executed as it is written, like `{{ .response.id }}` -> `{{ .response.id | __capture }}`
+	var captured interface{}
+	action.Pipe.Cmds = append(action.Pipe.Cmds, &parse.CommandNode{
+		NodeType: parse.NodeCommand,
+		Pos:      action.Pipe.Pos,
+		Args:     []parse.Node{parse.NewIdentifier(captureFunction).SetTree(tmpl.Tree).SetPos(action.Pipe.Pos)},
+	})
+	tmpl.Funcs(template.FuncMap{
+		captureFunction: func(v interface{}) string {
+			captured = v
+			return ""
+		},
+	})
+	_, err = executeTemplate(tmpl, variables)
 	if err != nil {
-		return nil, fmt.Errorf("unable to unmarshal result: %v", err)
+		return nil, err
 	}
-	return output, nil
+	return captured, nil
 }
 
+// ApplyInterpolation interpolates string as template. Missing key of map is an error
 func ApplyInterpolation(str string, variables map[string]interface{}) (string, error) {
-	funcMap := template.FuncMap{
-		"hash_password": func(s string) (string, error) {
-			// TODO move to yaml configuration
-			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(s), bcrypt.MinCost)
-			if err != nil {
-				return "", err
-			}
-			return string(hashedPassword), nil
-		},
-		"quote": func(s string) string {
-			return fmt.Sprintf(`"%s"`, strings.ReplaceAll(s, `"`, `\"`))
-		},
-		"singlequote": func(s string) string {
-			return fmt.Sprintf(`'%s'`, strings.ReplaceAll(s, `'`, `\'`))
-		},
-		"string": func(val interface{}) string {
-			switch val.(type) {
-			case []byte, string:
-				return fmt.Sprintf("%s", val)
-			default:
-				return fmt.Sprintf("%v", val)
-			}
-		},
+	tmpl, err := newTemplate(str)
+	if err != nil {
+		return "", err
 	}
+	return executeTemplate(tmpl, variables)
+}
 
-	// fmt.Println(">> parse encoded interpolated", string(str))
-	tmpl, err := template.New("input").Funcs(funcMap).Parse(string(str))
+// newTemplate parses template with functions. Template fails on missing key of map
+func newTemplate(str string) (*template.Template, error) {
+	templateFunctions, err := getTemplateFunctions()
 	if err != nil {
-		return "", fmt.Errorf("unable to parse template: %v", err)
+		return nil, fmt.Errorf("unable to get template functions: %v", err)
 	}
+	tmpl, err := template.New("input").Funcs(templateFunctions).Option("missingkey=error").Parse(str)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse template: %v", err)
+	}
+	return tmpl, nil
+}
 
+func executeTemplate(tmpl *template.Template, variables map[string]interface{}) (string, error) {
 	var filledTemplateBuffer bytes.Buffer
-	err = tmpl.Execute(&filledTemplateBuffer, variables)
+	err := tmpl.Execute(&filledTemplateBuffer, variables)
 	if err != nil {
 		return "", fmt.Errorf("unable to execute template: %v", err)
 	}
-	// fmt.Println(">> interpolated template", filledTemplateBuffer.String())
-
 	return filledTemplateBuffer.String(), nil
 }
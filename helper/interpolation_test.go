@@ -0,0 +1,141 @@
+package helper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var interpolationVariables = map[string]interface{}{
+	"params": map[string]interface{}{
+		"id":    42,
+		"name":  "alice",
+		"tags":  []interface{}{"a", "b"},
+		"inner": map[string]interface{}{"enabled": true},
+	},
+}
+
+func TestApplyInterpolation(t *testing.T) {
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"no template", "no template"},
+		{"user {{ .params.name }} #{{ .params.id }}", "user alice #42"},
+		{`{{ printf "%s-%d" .params.name .params.id }}`, "alice-42"},
+	}
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			res, err := ApplyInterpolation(test.template, interpolationVariables)
+			if err != nil {
+				t.Fatalf("unable to interpolate: %v", err)
+			}
+			if res != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, res)
+			}
+		})
+	}
+}
+
+func TestApplyInterpolationMissingKey(t *testing.T) {
+	_, err := ApplyInterpolation("user {{ .params.missing }}", interpolationVariables)
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected error with missing key, got %v", err)
+	}
+}
+
+func TestApplyInterpolationForObject(t *testing.T) {
+	input := map[string]interface{}{
+		"id":      "{{ .params.id }}",
+		"title":   "user {{ .params.name }}",
+		"tags":    "{{ .params.tags }}",
+		"enabled": "{{ .params.inner.enabled }}",
+		"{{ .params.name }}": []interface{}{
+			"{{ .params.id }}",
+			map[interface{}]interface{}{"nested": "{{ .params.name }}", 1: "number key"},
+		},
+		"untouched": 3.5,
+	}
+	expected := map[string]interface{}{
+		// single action keeps type of value
+		"id":      42,
+		"title":   "user alice",
+		"tags":    []interface{}{"a", "b"},
+		"enabled": true,
+		"alice": []interface{}{
+			42,
+			map[interface{}]interface{}{"nested": "alice", 1: "number key"},
+		},
+		"untouched": 3.5,
+	}
+	res, err := ApplyInterpolationForObject(input, interpolationVariables)
+	if err != nil {
+		t.Fatalf("unable to interpolate: %v", err)
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("expected %#v, got %#v", expected, res)
+	}
+}
+
+func TestApplyInterpolationForObjectMissingKey(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        interface{}
+		expectedPath string
+	}{
+		{
+			name:         "item of list",
+			input:        map[string]interface{}{"list": []interface{}{"{{ .params.misspelled }}"}},
+			expectedPath: `".list[0]"`,
+		},
+		{
+			name:         "value of yaml map",
+			input:        map[interface{}]interface{}{"user": map[interface{}]interface{}{"name": "user {{ .params.misspelled }}"}},
+			expectedPath: `".user.name"`,
+		},
+		{
+			name:         "key of map",
+			input:        map[string]interface{}{"users": map[string]interface{}{"{{ .params.misspelled }}": 1}},
+			expectedPath: `".users.{{ .params.misspelled }}"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ApplyInterpolationForObject(test.input, interpolationVariables)
+			if err == nil || !strings.Contains(err.Error(), test.expectedPath) || !strings.Contains(err.Error(), "misspelled") {
+				t.Errorf("expected error with path %s of value and missing key, got %v", test.expectedPath, err)
+			}
+		})
+	}
+}
+
+func TestApplyInterpolationForObjectSingleAction(t *testing.T) {
+	tests := []struct {
+		template string
+		expected interface{}
+	}{
+		{"{{ .params.id }}", 42},
+		{"  {{ .params.id }}  ", "  42  "},
+		{"{{ .params.tags }}", []interface{}{"a", "b"}},
+		{"{{ index .params.tags 1 }}", "b"},
+		// pipelines and parenthesized arguments are executed as written
+		{`{{ .params.name | printf "%s!" }}`, "alice!"},
+		{`{{ printf "%v" (index .params.tags 0) | printf "[%s]" }}`, "[a]"},
+		{`{{ "}}" }}`, "}}"},
+		{"{{ .params.inner }}", map[string]interface{}{"enabled": true}},
+		{"{{ $name := .params.name }}{{ $name }}", "alice"},
+		{"{{ if .params.inner.enabled }}yes{{ end }}", "yes"},
+		{"{{ .params.id }}{{ .params.name }}", "42alice"},
+	}
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			res, err := ApplyInterpolationForObject(test.template, interpolationVariables)
+			if err != nil {
+				t.Fatalf("unable to interpolate: %v", err)
+			}
+			if !reflect.DeepEqual(res, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, res)
+			}
+		})
+	}
+}
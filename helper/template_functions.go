@@ -0,0 +1,106 @@
+package helper
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"text/template"
+)
+
+// TemplateFunctionConstructor creates function to be used in templates.
+// params are defined for function in `template_functions` section of config (can be nil if not defined)
+type TemplateFunctionConstructor func(params map[string]interface{}) (interface{}, error)
+
+var (
+	// initialized here (not in init) because built-in functions are defined in init of other files
+	templateFunctionConstructors = make(map[string]TemplateFunctionConstructor)
+	templateFunctions            template.FuncMap
+	templateFunctionsMutex       sync.Mutex
+)
+
+// DefineTemplateFunction makes function available in all templates. Plugins can define their own functions in init()
+func DefineTemplateFunction(name string, constructor TemplateFunctionConstructor) {
+	_, ok := templateFunctionConstructors[name]
+	if ok {
+		panic(fmt.Errorf("template function with name %q already defined", name))
+	}
+	templateFunctionConstructors[name] = constructor
+}
+
+func IsTemplateFunctionDefined(name string) bool {
+	_, ok := templateFunctionConstructors[name]
+	return ok
+}
+
+// ConfigureTemplateFunctions (re-)creates all defined template functions using params from config
+func ConfigureTemplateFunctions(params map[string]map[string]interface{}) error {
+	for functionName := range params {
+		if !IsTemplateFunctionDefined(functionName) {
+			return fmt.Errorf("template function %q not defined", functionName)
+		}
+	}
+	var functionNames []string
+	for functionName := range templateFunctionConstructors {
+		functionNames = append(functionNames, functionName)
+	}
+	sort.Strings(functionNames)
+	funcMap := make(template.FuncMap, len(functionNames))
+	for _, functionName := range functionNames {
+		var functionParams map[string]interface{}
+		if params[functionName] != nil {
+			functionParams = make(map[string]interface{}, len(params[functionName]))
+			for paramName, paramValue := range params[functionName] {
+				functionParams[paramName] = YamlValueToJsonValue(paramValue)
+			}
+		}
+		fn, err := templateFunctionConstructors[functionName](functionParams)
+		if err != nil {
+			return fmt.Errorf("unable to create template function %q: %v", functionName, err)
+		}
+		funcMap[functionName] = fn
+	}
+
+	templateFunctionsMutex.Lock()
+	defer templateFunctionsMutex.Unlock()
+	templateFunctions = funcMap
+	return nil
+}
+
+func getTemplateFunctions() (template.FuncMap, error) {
+	templateFunctionsMutex.Lock()
+	funcMap := templateFunctions
+	templateFunctionsMutex.Unlock()
+	if funcMap != nil {
+		return funcMap, nil
+	}
+	// functions not configured yet, so create them with default params
+	err := ConfigureTemplateFunctions(nil)
+	if err != nil {
+		return nil, err
+	}
+	return getTemplateFunctions()
+}
+
+func intParam(params map[string]interface{}, name string, defaultValue int) (int, error) {
+	value, ok := params[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	intValue, ok := value.(int)
+	if !ok {
+		return 0, fmt.Errorf("param %q should be int, but it is %T (%#v)", name, value, value)
+	}
+	return intValue, nil
+}
+
+func stringParam(params map[string]interface{}, name string, defaultValue string) (string, error) {
+	value, ok := params[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	stringValue, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("param %q should be string, but it is %T (%#v)", name, value, value)
+	}
+	return stringValue, nil
+}
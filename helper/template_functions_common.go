@@ -0,0 +1,79 @@
+package helper
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func init() {
+	DefineTemplateFunction("quote", func(params map[string]interface{}) (interface{}, error) {
+		return func(s string) string {
+			return fmt.Sprintf(`"%s"`, strings.ReplaceAll(s, `"`, `\"`))
+		}, nil
+	})
+	DefineTemplateFunction("singlequote", func(params map[string]interface{}) (interface{}, error) {
+		return func(s string) string {
+			return fmt.Sprintf(`'%s'`, strings.ReplaceAll(s, `'`, `\'`))
+		}, nil
+	})
+	DefineTemplateFunction("string", func(params map[string]interface{}) (interface{}, error) {
+		return func(val interface{}) string {
+			switch val.(type) {
+			case []byte, string:
+				return fmt.Sprintf("%s", val)
+			default:
+				return fmt.Sprintf("%v", val)
+			}
+		}, nil
+	})
+	DefineTemplateFunction("base64_encode", func(params map[string]interface{}) (interface{}, error) {
+		return func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s))
+		}, nil
+	})
+	DefineTemplateFunction("base64_decode", func(params map[string]interface{}) (interface{}, error) {
+		return func(s string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return "", err
+			}
+			return string(decoded), nil
+		}, nil
+	})
+	// dict and list allow to build objects in templates, for example claims for jwt
+	DefineTemplateFunction("dict", func(params map[string]interface{}) (interface{}, error) {
+		return func(keysAndValues ...interface{}) (map[string]interface{}, error) {
+			if len(keysAndValues)%2 != 0 {
+				return nil, fmt.Errorf("dict should be called with even number of arguments")
+			}
+			res := make(map[string]interface{}, len(keysAndValues)/2)
+			for i := 0; i < len(keysAndValues); i += 2 {
+				key, ok := keysAndValues[i].(string)
+				if !ok {
+					return nil, fmt.Errorf("dict keys should be strings, but key #%d is %T (%#v)", i/2, keysAndValues[i], keysAndValues[i])
+				}
+				res[key] = keysAndValues[i+1]
+			}
+			return res, nil
+		}, nil
+	})
+	DefineTemplateFunction("list", func(params map[string]interface{}) (interface{}, error) {
+		return func(items ...interface{}) []interface{} {
+			return items
+		}, nil
+	})
+	DefineTemplateFunction("env", func(params map[string]interface{}) (interface{}, error) {
+		return func(name string, defaultValue ...string) (string, error) {
+			value, ok := os.LookupEnv(name)
+			if ok {
+				return value, nil
+			}
+			if len(defaultValue) != 0 {
+				return defaultValue[0], nil
+			}
+			return "", fmt.Errorf("environment variable %q not set", name)
+		}, nil
+	})
+}
@@ -0,0 +1,114 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+	"hash"
+)
+
+func init() {
+	DefineTemplateFunction("hash_password", func(params map[string]interface{}) (interface{}, error) {
+		cost, err := intParam(params, "cost", bcrypt.MinCost)
+		if err != nil {
+			return nil, err
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("cost should be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return func(s string) (string, error) {
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(s), cost)
+			if err != nil {
+				return "", err
+			}
+			return string(hashedPassword), nil
+		}, nil
+	})
+	DefineTemplateFunction("sha256", func(params map[string]interface{}) (interface{}, error) {
+		encode, err := encodingParam(params)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string) string {
+			sum := sha256.Sum256([]byte(s))
+			return encode(sum[:])
+		}, nil
+	})
+	DefineTemplateFunction("hmac_sha256", func(params map[string]interface{}) (interface{}, error) {
+		encode, err := encodingParam(params)
+		if err != nil {
+			return nil, err
+		}
+		return func(key string, message string) string {
+			mac := hmac.New(sha256.New, []byte(key))
+			mac.Write([]byte(message))
+			return encode(mac.Sum(nil))
+		}, nil
+	})
+	DefineTemplateFunction("jwt", func(params map[string]interface{}) (interface{}, error) {
+		algorithm, err := stringParam(params, "algorithm", "HS256")
+		if err != nil {
+			return nil, err
+		}
+		var hashConstructor func() hash.Hash
+		switch algorithm {
+		case "HS256":
+			hashConstructor = sha256.New
+		case "HS384":
+			hashConstructor = sha512.New384
+		case "HS512":
+			hashConstructor = sha512.New
+		default:
+			return nil, fmt.Errorf("unsupported jwt algorithm %q", algorithm)
+		}
+		defaultSecret, err := stringParam(params, "secret", "")
+		if err != nil {
+			return nil, err
+		}
+		// secret can be passed as second argument, otherwise secret from config used
+		return func(claims map[string]interface{}, secret ...string) (string, error) {
+			key := defaultSecret
+			if len(secret) != 0 {
+				key = secret[0]
+			}
+			if key == "" {
+				return "", fmt.Errorf("secret to sign jwt not defined")
+			}
+			header, err := json.Marshal(map[string]string{
+				"alg": algorithm,
+				"typ": "JWT",
+			})
+			if err != nil {
+				return "", fmt.Errorf("unable to marshal header: %v", err)
+			}
+			payload, err := json.Marshal(claims)
+			if err != nil {
+				return "", fmt.Errorf("unable to marshal claims: %v", err)
+			}
+			unsignedToken := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+			mac := hmac.New(hashConstructor, []byte(key))
+			mac.Write([]byte(unsignedToken))
+			return unsignedToken + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+		}, nil
+	})
+}
+
+func encodingParam(params map[string]interface{}) (func([]byte) string, error) {
+	encoding, err := stringParam(params, "encoding", "hex")
+	if err != nil {
+		return nil, err
+	}
+	switch encoding {
+	case "hex":
+		return hex.EncodeToString, nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString, nil
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
@@ -0,0 +1,145 @@
+package helper
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	// extracts value from saved results, for example `{{ jsonpath "$.data.items[0].id" .response }}`
+	DefineTemplateFunction("jsonpath", func(params map[string]interface{}) (interface{}, error) {
+		return func(path string, value interface{}) (interface{}, error) {
+			segments, err := parseJsonPath(path)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse path %q: %v", path, err)
+			}
+			res, err := extractJsonPath(value, segments, "$")
+			if err != nil {
+				return nil, fmt.Errorf("unable to extract %q: %v", path, err)
+			}
+			return res, nil
+		}, nil
+	})
+}
+
+type jsonPathSegment struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJsonPath parses subset of jsonpath: `$`, `.key`, `['key']`, `[0]`, `[*]` and `.*`
+func parseJsonPath(path string) ([]jsonPathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path should start with $")
+	}
+	var segments []jsonPathSegment
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			if key == "" {
+				return nil, fmt.Errorf("empty key")
+			}
+			rest = rest[end:]
+			if key == "*" {
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			} else {
+				segments = append(segments, jsonPathSegment{key: key})
+			}
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket")
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case selector == "*":
+				segments = append(segments, jsonPathSegment{wildcard: true})
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				segments = append(segments, jsonPathSegment{key: selector[1 : len(selector)-1]})
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("invalid index %q", selector)
+				}
+				segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+	}
+	return segments, nil
+}
+
+func extractJsonPath(value interface{}, segments []jsonPathSegment, currentPath string) (interface{}, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+	segment := segments[0]
+	if yamlMap, ok := IsYamlMap(value); ok {
+		value = yamlMap.ToMap()
+	}
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		if segment.wildcard {
+			var keys []string
+			for key := range typedValue {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			res := make([]interface{}, 0, len(keys))
+			for _, key := range keys {
+				item, err := extractJsonPath(typedValue[key], segments[1:], currentPath+"."+key)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, item)
+			}
+			return res, nil
+		}
+		if segment.isIndex {
+			return nil, fmt.Errorf("%s is map, not list", currentPath)
+		}
+		item, ok := typedValue[segment.key]
+		if !ok {
+			return nil, fmt.Errorf("%s has no key %q", currentPath, segment.key)
+		}
+		return extractJsonPath(item, segments[1:], currentPath+"."+segment.key)
+	case []interface{}:
+		if segment.wildcard {
+			res := make([]interface{}, 0, len(typedValue))
+			for i, item := range typedValue {
+				extracted, err := extractJsonPath(item, segments[1:], fmt.Sprintf("%s[%d]", currentPath, i))
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, extracted)
+			}
+			return res, nil
+		}
+		if !segment.isIndex {
+			return nil, fmt.Errorf("%s is list, not map", currentPath)
+		}
+		index := segment.index
+		if index < 0 {
+			index += len(typedValue)
+		}
+		if index < 0 || index >= len(typedValue) {
+			return nil, fmt.Errorf("index %d out of range of %s (length %d)", segment.index, currentPath, len(typedValue))
+		}
+		return extractJsonPath(typedValue[index], segments[1:], fmt.Sprintf("%s[%d]", currentPath, index))
+	default:
+		return nil, fmt.Errorf("%s is %T, it can not contain other values", currentPath, value)
+	}
+}
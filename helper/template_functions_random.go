@@ -0,0 +1,94 @@
+package helper
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const defaultRandomAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// lockedRand is source of random values for template functions. seed can be set in config to get same values on every run
+type lockedRand struct {
+	mutex sync.Mutex
+	rand  *rand.Rand
+}
+
+func newLockedRand(params map[string]interface{}) (*lockedRand, error) {
+	seed, err := intParam(params, "seed", 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := params["seed"]; !ok {
+		seed = int(time.Now().UnixNano())
+	}
+	return &lockedRand{
+		rand: rand.New(rand.NewSource(int64(seed))),
+	}, nil
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.rand.Intn(n)
+}
+
+func (r *lockedRand) Read(p []byte) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rand.Read(p)
+}
+
+func init() {
+	DefineTemplateFunction("uuid", func(params map[string]interface{}) (interface{}, error) {
+		random, err := newLockedRand(params)
+		if err != nil {
+			return nil, err
+		}
+		return func() string {
+			b := make([]byte, 16)
+			random.Read(b)
+			// version 4, variant RFC 4122
+			b[6] = (b[6] & 0x0f) | 0x40
+			b[8] = (b[8] & 0x3f) | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+		}, nil
+	})
+	DefineTemplateFunction("random_string", func(params map[string]interface{}) (interface{}, error) {
+		random, err := newLockedRand(params)
+		if err != nil {
+			return nil, err
+		}
+		alphabet, err := stringParam(params, "alphabet", defaultRandomAlphabet)
+		if err != nil {
+			return nil, err
+		}
+		if alphabet == "" {
+			return nil, fmt.Errorf("alphabet should not be empty")
+		}
+		return func(length int) (string, error) {
+			if length < 0 {
+				return "", fmt.Errorf("length should be non-negative, but it is %d", length)
+			}
+			res := make([]byte, length)
+			for i := range res {
+				res[i] = alphabet[random.Intn(len(alphabet))]
+			}
+			return string(res), nil
+		}, nil
+	})
+	DefineTemplateFunction("random_int", func(params map[string]interface{}) (interface{}, error) {
+		random, err := newLockedRand(params)
+		if err != nil {
+			return nil, err
+		}
+		// returns value in range [min, max)
+		return func(min int, max int) (int, error) {
+			if max <= min {
+				return 0, fmt.Errorf("max should be greater than min")
+			}
+			return min + random.Intn(max-min), nil
+		}, nil
+	})
+}
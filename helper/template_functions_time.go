@@ -0,0 +1,98 @@
+package helper
+
+import (
+	"fmt"
+	"time"
+)
+
+func init() {
+	DefineTemplateFunction("now", func(params map[string]interface{}) (interface{}, error) {
+		// fixed time can be set in config to get same values on every run
+		value, err := stringParam(params, "value", "")
+		if err != nil {
+			return nil, err
+		}
+		if value == "" {
+			return func() time.Time {
+				return time.Now().UTC()
+			}, nil
+		}
+		fixedTime, err := parseTime(value)
+		if err != nil {
+			return nil, err
+		}
+		return func() time.Time {
+			return fixedTime
+		}, nil
+	})
+	DefineTemplateFunction("date_add", func(params map[string]interface{}) (interface{}, error) {
+		return func(t interface{}, duration string) (time.Time, error) {
+			parsedTime, err := toTime(t)
+			if err != nil {
+				return time.Time{}, err
+			}
+			parsedDuration, err := time.ParseDuration(duration)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("unable to parse duration: %v", err)
+			}
+			return parsedTime.Add(parsedDuration), nil
+		}, nil
+	})
+	DefineTemplateFunction("date_add_date", func(params map[string]interface{}) (interface{}, error) {
+		return func(t interface{}, years int, months int, days int) (time.Time, error) {
+			parsedTime, err := toTime(t)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return parsedTime.AddDate(years, months, days), nil
+		}, nil
+	})
+	DefineTemplateFunction("format_date", func(params map[string]interface{}) (interface{}, error) {
+		defaultLayout, err := stringParam(params, "layout", TimeLayout)
+		if err != nil {
+			return nil, err
+		}
+		return func(t interface{}, layout ...string) (string, error) {
+			parsedTime, err := toTime(t)
+			if err != nil {
+				return "", err
+			}
+			if len(layout) != 0 {
+				return parsedTime.Format(layout[0]), nil
+			}
+			return parsedTime.Format(defaultLayout), nil
+		}, nil
+	})
+	DefineTemplateFunction("unix", func(params map[string]interface{}) (interface{}, error) {
+		return func(t interface{}) (int64, error) {
+			parsedTime, err := toTime(t)
+			if err != nil {
+				return 0, err
+			}
+			return parsedTime.Unix(), nil
+		}, nil
+	})
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range []string{TimeLayout, time.RFC3339Nano} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse time %q", value)
+}
+
+func toTime(t interface{}) (time.Time, error) {
+	switch value := t.(type) {
+	case time.Time:
+		return value, nil
+	case *time.Time:
+		return *value, nil
+	case string:
+		return parseTime(value)
+	default:
+		return time.Time{}, fmt.Errorf("value of type %T can not be used as time", t)
+	}
+}
@@ -0,0 +1,181 @@
+package http_mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultResponseContentType = "application/json"
+
+const (
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+// Config is config of mock. It is read from file at start and can be replaced by `PUT /__config`
+type Config struct {
+	// routes like `/path` (any method), `POST /path` or `GET /users/{id}` (with path param)
+	Routes map[string]Response `json:"routes"`
+	// name of service, used in logs
+	ServiceName string `json:"service_name"`
+	// content type of responses, `application/json` by default. Bodies of responses with json content type are marshaled to json
+	ResponseContentType string `json:"response_content_type"`
+	// initial value of config available in templates of bodies like `{{ .config.key }}`. It can be replaced by `POST /__config`
+	Config map[string]interface{} `json:"config"`
+	// mode of mock. Requests not matched by routes respond with 404 by default.
+	// In `record` mode they are proxied to upstream and saved to fixtures, in `replay` mode they are responded by fixtures
+	Mode string `json:"mode"`
+	// url of upstream like `http://host:port/` used in record mode
+	Upstream string `json:"upstream"`
+	// directory of fixtures. It is cleared at start of record mode
+	Fixtures string `json:"fixtures"`
+	// keys of request used to choose fixture in replay mode: method, path, query, body, query.<name> or header.<Name>. Method, path, query and body are used by default.
+	// Fixtures with the same key are replayed in turn, last fixture is repeated
+	MatchBy []string `json:"match_by"`
+	// certificates served by tls listener
+	Certificates []Certificate `json:"certificates"`
+}
+
+type Response struct {
+	// status code, 200 by default
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    interface{}       `json:"body"`
+	// delay before response like `500ms`
+	Delay string `json:"delay"`
+	// fault instead of response: `drop` closes connection without response, `timeout` doesn't respond until client closes connection
+	Fault string `json:"fault"`
+	// responses returned in turn, last response is repeated
+	Sequence []Response `json:"sequence"`
+	// responses chosen by conditions of request, first matched response is used. Response itself is used if no one matched
+	Responses []Response `json:"responses"`
+	// conditions of response from `responses`
+	When *Condition `json:"when"`
+}
+
+// Condition is predicate of request. All defined fields should match
+type Condition struct {
+	Method string `json:"method"`
+	// params of path defined in route like `/users/{id}`
+	Params  map[string]string `json:"params"`
+	Query   map[string]string `json:"query"`
+	Headers map[string]string `json:"headers"`
+	// json body should contain fields of object, string body should contain string
+	Body interface{} `json:"body"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	configBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config: %v", err)
+	}
+	config, err := ParseConfig(configBytes)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func ParseConfig(configBytes []byte) (*Config, error) {
+	var config Config
+	err := json.Unmarshal(configBytes, &config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal config: %v", err)
+	}
+	err = validateRoutes(config.Routes)
+	if err != nil {
+		return nil, err
+	}
+	err = config.validateMode()
+	if err != nil {
+		return nil, err
+	}
+	_, err = parseCertificates(config.Certificates)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (c Config) validateMode() error {
+	switch c.Mode {
+	case "":
+		return nil
+	case ModeRecord:
+		if c.Upstream == "" {
+			return fmt.Errorf("upstream should be defined in record mode")
+		}
+		_, err := url.Parse(c.Upstream)
+		if err != nil {
+			return fmt.Errorf("invalid upstream: %v", err)
+		}
+	case ModeReplay:
+	default:
+		return fmt.Errorf("unknown mode %q, mode should be record or replay", c.Mode)
+	}
+	if c.Fixtures == "" {
+		return fmt.Errorf("fixtures should be defined in %s mode", c.Mode)
+	}
+	err := validateMatchBy(c.MatchBy)
+	if err != nil {
+		return fmt.Errorf("invalid match_by: %v", err)
+	}
+	return nil
+}
+
+func (c Config) matchBy() []string {
+	if len(c.MatchBy) == 0 {
+		return defaultMatchBy
+	}
+	return c.MatchBy
+}
+
+func validateRoutes(routes map[string]Response) error {
+	for route, response := range routes {
+		if !strings.HasPrefix(route, "/") && !strings.Contains(route, " /") {
+			return fmt.Errorf("invalid route %q: route should be like `/path` or `METHOD /path`", route)
+		}
+		if response.When != nil {
+			return fmt.Errorf("invalid route %q: conditions can be defined only in responses", route)
+		}
+		err := response.validate()
+		if err != nil {
+			return fmt.Errorf("invalid route %q: %v", route, err)
+		}
+	}
+	return nil
+}
+
+func (r Response) validate() error {
+	if r.Delay != "" {
+		_, err := time.ParseDuration(r.Delay)
+		if err != nil {
+			return fmt.Errorf("invalid delay: %v", err)
+		}
+	}
+	switch r.Fault {
+	case "", faultDrop, faultTimeout:
+	default:
+		return fmt.Errorf("unknown fault %q", r.Fault)
+	}
+	for i, response := range r.Sequence {
+		err := response.validate()
+		if err != nil {
+			return fmt.Errorf("sequence #%d: %v", i, err)
+		}
+	}
+	for i, response := range r.Responses {
+		if response.When == nil {
+			return fmt.Errorf("responses #%d: conditions not defined", i)
+		}
+		err := response.validate()
+		if err != nil {
+			return fmt.Errorf("responses #%d: %v", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package http_mock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	matchByMethod       = "method"
+	matchByPath         = "path"
+	matchByQuery        = "query"
+	matchByBody         = "body"
+	matchByQueryPrefix  = "query."
+	matchByHeaderPrefix = "header."
+)
+
+// defaultMatchBy is used if `match_by` is not defined in config
+var defaultMatchBy = []string{matchByMethod, matchByPath, matchByQuery, matchByBody}
+
+// Fixture is request to upstream and its response saved in record mode. Every fixture is saved to separate file
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+type FixtureRequest struct {
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Query   url.Values  `json:"query,omitempty"`
+	Headers http.Header `json:"headers,omitempty"`
+	FixtureBody
+}
+
+type FixtureResponse struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	FixtureBody
+}
+
+// FixtureBody is body saved as json if it is json, as string if it is text and as base64 otherwise. Only one field is defined
+type FixtureBody struct {
+	Json       json.RawMessage `json:"json,omitempty"`
+	Body       string          `json:"body,omitempty"`
+	BodyBase64 string          `json:"body_base64,omitempty"`
+}
+
+func newFixtureBody(body []byte) FixtureBody {
+	switch {
+	case len(body) == 0:
+		return FixtureBody{}
+	case json.Valid(body):
+		return FixtureBody{Json: json.RawMessage(body)}
+	case utf8.Valid(body):
+		return FixtureBody{Body: string(body)}
+	default:
+		return FixtureBody{BodyBase64: base64.StdEncoding.EncodeToString(body)}
+	}
+}
+
+func (b FixtureBody) bytes() ([]byte, error) {
+	switch {
+	case len(b.Json) != 0:
+		// json is indented in file
+		var buf bytes.Buffer
+		err := json.Compact(&buf, b.Json)
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case b.BodyBase64 != "":
+		return base64.StdEncoding.DecodeString(b.BodyBase64)
+	default:
+		return []byte(b.Body), nil
+	}
+}
+
+// LoadFixtures loads fixtures from files of directory in order of recording
+func LoadFixtures(dir string) ([]Fixture, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read directory of fixtures: %v", err)
+	}
+	var fixtures []Fixture
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		fixtureBytes, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read fixture %q: %v", file.Name(), err)
+		}
+		var fixture Fixture
+		err = json.Unmarshal(fixtureBytes, &fixture)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshal fixture %q: %v", file.Name(), err)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+var notFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9.-]+`)
+
+// saveFixture saves fixture to file like `0001_GET_users_1.json`. Index defines order of fixtures
+func saveFixture(dir string, index int, fixture Fixture) error {
+	name := strings.Trim(notFileNameChars.ReplaceAllString(fixture.Request.Path, "_"), "_")
+	if len(name) > 100 {
+		name = name[:100]
+	}
+	var fixtureBytes bytes.Buffer
+	encoder := json.NewEncoder(&fixtureBytes)
+	// bodies are saved as is
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(fixture)
+	if err != nil {
+		return fmt.Errorf("unable to marshal fixture: %v", err)
+	}
+	fileName := fmt.Sprintf("%04d_%s_%s.json", index+1, fixture.Request.Method, name)
+	err = ioutil.WriteFile(filepath.Join(dir, fileName), fixtureBytes.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write fixture %q: %v", fileName, err)
+	}
+	return nil
+}
+
+// clearFixtures creates directory of fixtures and removes fixtures recorded before
+func clearFixtures(dir string) error {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("unable to create directory of fixtures: %v", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		err = os.Remove(file)
+		if err != nil {
+			return fmt.Errorf("unable to remove fixture: %v", err)
+		}
+	}
+	return nil
+}
+
+func validateMatchBy(matchBy []string) error {
+	for _, key := range matchBy {
+		switch {
+		case key == matchByMethod, key == matchByPath, key == matchByQuery, key == matchByBody:
+		case strings.HasPrefix(key, matchByQueryPrefix) && key != matchByQueryPrefix:
+		case strings.HasPrefix(key, matchByHeaderPrefix) && key != matchByHeaderPrefix:
+		default:
+			return fmt.Errorf("unknown key %q, key should be one of method, path, query, body, query.<name> or header.<Name>", key)
+		}
+	}
+	return nil
+}
+
+// matchKey returns values of request by keys of `match_by`. Requests with the same key are replayed by the same fixtures
+func matchKey(matchBy []string, method string, path string, query url.Values, headers http.Header, body []byte) string {
+	values := make([]string, len(matchBy))
+	for i, key := range matchBy {
+		switch {
+		case key == matchByMethod:
+			values[i] = method
+		case key == matchByPath:
+			values[i] = path
+		case key == matchByQuery:
+			// Encode sorts params by name
+			values[i] = query.Encode()
+		case key == matchByBody:
+			values[i] = normalizeBody(body)
+		case strings.HasPrefix(key, matchByQueryPrefix):
+			values[i] = strings.Join(query[strings.TrimPrefix(key, matchByQueryPrefix)], ",")
+		case strings.HasPrefix(key, matchByHeaderPrefix):
+			values[i] = strings.Join(headers[http.CanonicalHeaderKey(strings.TrimPrefix(key, matchByHeaderPrefix))], ",")
+		}
+	}
+	res, _ := json.Marshal(values)
+	return string(res)
+}
+
+// normalizeBody removes formatting and sorts fields of json to match json bodies by content
+func normalizeBody(body []byte) string {
+	var value interface{}
+	if json.Unmarshal(body, &value) != nil {
+		return string(body)
+	}
+	res, err := json.Marshal(value)
+	if err != nil {
+		return string(body)
+	}
+	return string(res)
+}
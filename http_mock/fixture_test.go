@@ -0,0 +1,229 @@
+package http_mock
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchBy  []string
+		first    *http.Request
+		second   *http.Request
+		matched  bool
+		firstKey string
+	}{
+		{
+			name:     "default keys",
+			matchBy:  defaultMatchBy,
+			first:    newKeyRequest("GET", "/users?b=2&a=1", nil, ""),
+			second:   newKeyRequest("GET", "/users?a=1&b=2", nil, ""),
+			matched:  true,
+			firstKey: `["GET","/users","a=1\u0026b=2",""]`,
+		},
+		{
+			name:    "default keys with other method",
+			matchBy: defaultMatchBy,
+			first:   newKeyRequest("GET", "/users", nil, ""),
+			second:  newKeyRequest("POST", "/users", nil, ""),
+		},
+		{
+			name:    "formatted json body",
+			matchBy: []string{matchByBody},
+			first:   newKeyRequest("POST", "/users", nil, `{"name": "alice", "age": 30}`),
+			second:  newKeyRequest("POST", "/users", nil, `{"age":30,"name":"alice"}`),
+			matched: true,
+		},
+		{
+			name:    "other text body",
+			matchBy: []string{matchByBody},
+			first:   newKeyRequest("POST", "/users", nil, "name=alice"),
+			second:  newKeyRequest("POST", "/users", nil, "name=bob"),
+		},
+		{
+			name:    "query param",
+			matchBy: []string{matchByPath, "query.id"},
+			first:   newKeyRequest("GET", "/users?id=1&ts=1", nil, ""),
+			second:  newKeyRequest("GET", "/users?ts=2&id=1", nil, ""),
+			matched: true,
+		},
+		{
+			name:    "header",
+			matchBy: []string{"header.x-tenant"},
+			first:   newKeyRequest("GET", "/users", http.Header{"X-Tenant": {"a"}}, ""),
+			second:  newKeyRequest("GET", "/users", http.Header{"X-Tenant": {"b"}}, ""),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			firstKey := requestMatchKey(test.matchBy, test.first)
+			secondKey := requestMatchKey(test.matchBy, test.second)
+			if (firstKey == secondKey) != test.matched {
+				t.Errorf("expected matched %v, keys %s and %s", test.matched, firstKey, secondKey)
+			}
+			if test.firstKey != "" && firstKey != test.firstKey {
+				t.Errorf("expected key %s, got %s", test.firstKey, firstKey)
+			}
+		})
+	}
+}
+
+func newKeyRequest(method string, rawUrl string, headers http.Header, body string) *http.Request {
+	parsedUrl, _ := url.Parse(rawUrl)
+	return &http.Request{Method: method, URL: parsedUrl, Header: headers, Body: ioutil.NopCloser(strings.NewReader(body))}
+}
+
+func requestMatchKey(matchBy []string, r *http.Request) string {
+	body, _ := ioutil.ReadAll(r.Body)
+	return matchKey(matchBy, r.Method, r.URL.Path, r.URL.Query(), r.Header, body)
+}
+
+func TestNormalizeBody(t *testing.T) {
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{"", ""},
+		{"plain text", "plain text"},
+		{"{\n  \"b\": [1, 2],\n  \"a\": null\n}", `{"a":null,"b":[1,2]}`},
+		{`[ "x" ]`, `["x"]`},
+		{`{"broken": `, `{"broken": `},
+	}
+	for _, test := range tests {
+		t.Run(test.body, func(t *testing.T) {
+			res := normalizeBody([]byte(test.body))
+			if res != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, res)
+			}
+		})
+	}
+}
+
+// tempFixturesDir creates directory of fixtures, it should be removed by test
+func tempFixturesDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatalf("unable to create directory of fixtures: %v", err)
+	}
+	return dir
+}
+
+func TestSaveAndLoadFixtures(t *testing.T) {
+	dir := tempFixturesDir(t)
+	defer os.RemoveAll(dir)
+
+	fixtures := []Fixture{
+		{
+			Request:  FixtureRequest{Method: "POST", Path: "/users", FixtureBody: newFixtureBody([]byte(`{"name":"alice"}`))},
+			Response: FixtureResponse{Status: 201, Headers: http.Header{"Content-Type": {"application/json"}}, FixtureBody: newFixtureBody([]byte(`{"id":1}`))},
+		},
+		{
+			Request:  FixtureRequest{Method: "GET", Path: "/users/1/avatar.png", Query: url.Values{"size": {"s"}}},
+			Response: FixtureResponse{Status: 200, FixtureBody: newFixtureBody([]byte{0xff, 0xd8, 0x00})},
+		},
+		{
+			Request:  FixtureRequest{Method: "GET", Path: "/"},
+			Response: FixtureResponse{Status: 200, FixtureBody: newFixtureBody([]byte("<b>ok</b>"))},
+		},
+	}
+	for i, fixture := range fixtures {
+		err := saveFixture(dir, i, fixture)
+		if err != nil {
+			t.Fatalf("unable to save fixture: %v", err)
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	expectedNames := []string{"0001_POST_users.json", "0002_GET_users_1_avatar.png.json", "0003_GET_.json"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("expected files %v, got %v", expectedNames, names)
+	}
+
+	loaded, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatalf("unable to load fixtures: %v", err)
+	}
+	if len(loaded) != len(fixtures) {
+		t.Fatalf("expected %d fixtures, got %d", len(fixtures), len(loaded))
+	}
+	expectedBodies := []string{`{"id":1}`, "\xff\xd8\x00", "<b>ok</b>"}
+	for i, fixture := range loaded {
+		if fixture.Request.Method != fixtures[i].Request.Method || fixture.Request.Path != fixtures[i].Request.Path || fixture.Response.Status != fixtures[i].Response.Status {
+			t.Errorf("fixture #%d: expected %+v, got %+v", i, fixtures[i], fixture)
+		}
+		body, err := fixture.Response.bytes()
+		if err != nil {
+			t.Fatalf("fixture #%d: invalid body: %v", i, err)
+		}
+		if string(body) != expectedBodies[i] {
+			t.Errorf("fixture #%d: expected body %q, got %q", i, expectedBodies[i], body)
+		}
+	}
+	if loaded[1].Response.BodyBase64 == "" || loaded[2].Response.Body == "" {
+		t.Errorf("expected binary body saved as base64 and text body as string, got %+v and %+v", loaded[1].Response, loaded[2].Response)
+	}
+}
+
+func TestLoadCorruptFixture(t *testing.T) {
+	dir := tempFixturesDir(t)
+	defer os.RemoveAll(dir)
+
+	err := ioutil.WriteFile(filepath.Join(dir, "0001_GET_users.json"), []byte(`{"request": {"method": "GET"`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadFixtures(dir)
+	if err == nil || !strings.Contains(err.Error(), `unable to unmarshal fixture "0001_GET_users.json"`) {
+		t.Errorf("expected error of corrupt fixture, got %v", err)
+	}
+	_, err = LoadFixtures(filepath.Join(dir, "missing"))
+	if err == nil || !strings.Contains(err.Error(), "unable to read directory of fixtures") {
+		t.Errorf("expected error of missing directory, got %v", err)
+	}
+}
+
+func TestClearFixtures(t *testing.T) {
+	dir := tempFixturesDir(t)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"0001_GET_users.json", "README.md"} {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := clearFixtures(dir)
+	if err != nil {
+		t.Fatalf("unable to clear fixtures: %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "README.md" {
+		t.Errorf("expected only fixtures removed, got %v", files)
+	}
+
+	nestedDir := filepath.Join(dir, "nested", "fixtures")
+	err = clearFixtures(nestedDir)
+	if err != nil {
+		t.Fatalf("unable to clear fixtures: %v", err)
+	}
+	_, err = os.Stat(nestedDir)
+	if err != nil {
+		t.Errorf("expected directory of fixtures created: %v", err)
+	}
+}
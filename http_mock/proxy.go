@@ -0,0 +1,161 @@
+package http_mock
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// upstreamClient doesn't follow redirects to record them as is
+var upstreamClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// hopHeaders are headers of connection which are not copied from upstream response
+var hopHeaders = []string{"Connection", "Content-Length", "Keep-Alive", "Transfer-Encoding"}
+
+// setupFixtures clears fixtures in record mode and loads them in replay mode. Should be called with locked mutex
+func (s *Server) setupFixtures() {
+	s.fixtures = nil
+	s.fixtureKeys = nil
+	s.fixturesErr = nil
+	s.replayed = make(map[string]int)
+	s.recorded = 0
+	switch s.config.Mode {
+	case ModeRecord:
+		s.fixturesErr = clearFixtures(s.config.Fixtures)
+	case ModeReplay:
+		s.fixtures, s.fixturesErr = LoadFixtures(s.config.Fixtures)
+		for _, fixture := range s.fixtures {
+			body, err := fixture.Request.bytes()
+			if err != nil {
+				s.fixturesErr = fmt.Errorf("invalid body of fixture %s %s: %v", fixture.Request.Method, fixture.Request.Path, err)
+				break
+			}
+			s.fixtureKeys = append(s.fixtureKeys, matchKey(s.config.matchBy(), fixture.Request.Method, fixture.Request.Path, fixture.Request.Query, fixture.Request.Headers, body))
+		}
+	}
+	if s.fixturesErr != nil {
+		log.Printf("%s: unable to setup fixtures: %v", s.config.ServiceName, s.fixturesErr)
+	}
+}
+
+// handleRecord proxies request to upstream and saves request and response to fixture
+func (s *Server) handleRecord(w http.ResponseWriter, r *http.Request, body []byte, config Config) {
+	upstreamReq, err := http.NewRequest(r.Method, strings.TrimSuffix(config.Upstream, "/")+r.URL.Path, bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to create request to upstream: %v", err), http.StatusBadGateway)
+		return
+	}
+	upstreamReq = upstreamReq.WithContext(r.Context())
+	upstreamReq.URL.RawQuery = r.URL.RawQuery
+	for name, values := range r.Header {
+		upstreamReq.Header[name] = values
+	}
+	// compressed responses are decompressed by client to save readable fixtures
+	upstreamReq.Header.Del("Accept-Encoding")
+	resp, err := upstreamClient.Do(upstreamReq)
+	if err != nil {
+		log.Printf("%s: unable to send request to upstream: %v", config.ServiceName, err)
+		http.Error(w, fmt.Sprintf("unable to send request to upstream: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read response of upstream: %v", err), http.StatusBadGateway)
+		return
+	}
+	for _, name := range hopHeaders {
+		resp.Header.Del(name)
+	}
+
+	s.mutex.Lock()
+	index := s.recorded
+	s.recorded++
+	fixturesErr := s.fixturesErr
+	s.mutex.Unlock()
+	if fixturesErr == nil {
+		err = saveFixture(config.Fixtures, index, Fixture{
+			Request: FixtureRequest{
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				Query:       r.URL.Query(),
+				Headers:     r.Header,
+				FixtureBody: newFixtureBody(body),
+			},
+			Response: FixtureResponse{
+				Status:      resp.StatusCode,
+				Headers:     resp.Header,
+				FixtureBody: newFixtureBody(respBody),
+			},
+		})
+	} else {
+		err = fixturesErr
+	}
+	if err != nil {
+		log.Printf("%s: unable to save fixture of %s %s: %v", config.ServiceName, r.Method, r.URL.Path, err)
+	}
+	writeFixtureResponse(w, resp.StatusCode, resp.Header, respBody, config)
+}
+
+// handleReplay responds with fixture matched by keys of request
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request, body []byte, config Config) {
+	s.mutex.Lock()
+	fixture, err := s.replayFixture(matchKey(config.matchBy(), r.Method, r.URL.Path, r.URL.Query(), r.Header, body))
+	s.mutex.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if fixture == nil {
+		log.Printf("%s: fixture of %s %s not found", config.ServiceName, r.Method, r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+	respBody, err := fixture.Response.bytes()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid body of fixture: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeFixtureResponse(w, fixture.Response.Status, fixture.Response.Headers, respBody, config)
+}
+
+// replayFixture returns next fixture with key. Should be called with locked mutex
+func (s *Server) replayFixture(key string) (*Fixture, error) {
+	if s.fixturesErr != nil {
+		return nil, fmt.Errorf("unable to load fixtures: %v", s.fixturesErr)
+	}
+	var matched []int
+	for i, fixtureKey := range s.fixtureKeys {
+		if fixtureKey == key {
+			matched = append(matched, i)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+	index := s.replayed[key]
+	if index < len(matched)-1 {
+		s.replayed[key]++
+	} else {
+		index = len(matched) - 1
+	}
+	return &s.fixtures[matched[index]], nil
+}
+
+func writeFixtureResponse(w http.ResponseWriter, status int, headers http.Header, body []byte, config Config) {
+	for name, values := range headers {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(status)
+	_, err := w.Write(body)
+	if err != nil {
+		log.Printf("%s: unable to write response: %v", config.ServiceName, err)
+	}
+}
@@ -0,0 +1,197 @@
+package http_mock
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newStubUpstream counts requests and responds with count and path of request
+func newStubUpstream() *httptest.Server {
+	count := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/users", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream", "stub")
+		fmt.Fprintf(w, `{"n": %d, "path": %q, "tenant": %q}`, count, r.URL.Path, r.Header.Get("X-Tenant"))
+	}))
+}
+
+func doTenantRequest(t *testing.T, method string, url string, tenant string) (int, string) {
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tenant != "" {
+		request.Header.Set("X-Tenant", tenant)
+	}
+	// redirects are checked as responses of mock
+	resp, err := upstreamClient.Do(request)
+	if err != nil {
+		t.Fatalf("unable to send request %s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(respBody)
+}
+
+type proxyStep struct {
+	method         string
+	path           string
+	tenant         string
+	expectedStatus int
+	expectedBody   string
+}
+
+func checkProxySteps(t *testing.T, url string, steps []proxyStep) {
+	for i, step := range steps {
+		status, body := doTenantRequest(t, step.method, url+step.path, step.tenant)
+		if status != step.expectedStatus || !strings.Contains(body, step.expectedBody) {
+			t.Errorf("step #%d %s %s: expected %d %s, got %d %s", i, step.method, step.path, step.expectedStatus, step.expectedBody, status, body)
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := tempFixturesDir(t)
+	defer os.RemoveAll(dir)
+	// fixtures of previous record are removed
+	err := ioutil.WriteFile(filepath.Join(dir, "0001_GET_old.json"), []byte("{}"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upstream := newStubUpstream()
+	_, recordServer := newTestServer(t, fmt.Sprintf(`{
+		"mode": "record",
+		"upstream": %q,
+		"fixtures": %q,
+		"routes": {"/health": {"body": "mocked"}}
+	}`, upstream.URL+"/", dir))
+	checkProxySteps(t, recordServer.URL, []proxyStep{
+		{"GET", "/users?id=1", "", http.StatusOK, `{"n": 1, "path": "/users"`},
+		{"GET", "/users?id=1", "", http.StatusOK, `{"n": 2, "path": "/users"`},
+		{"GET", "/users?id=2", "", http.StatusOK, `{"n": 3, "path": "/users"`},
+		{"GET", "/redirect", "", http.StatusFound, ""},
+		// routes of config are not proxied
+		{"GET", "/health", "", http.StatusOK, "mocked"},
+	})
+	recordServer.Close()
+	upstream.Close()
+
+	fixtures, err := LoadFixtures(dir)
+	if err != nil {
+		t.Fatalf("unable to load fixtures: %v", err)
+	}
+	if len(fixtures) != 4 {
+		t.Fatalf("expected 4 fixtures, got %d: %+v", len(fixtures), fixtures)
+	}
+	if fixtures[3].Response.Status != http.StatusFound || fixtures[3].Response.Headers.Get("Location") != "/users" {
+		t.Errorf("expected redirect recorded as is, got %+v", fixtures[3].Response)
+	}
+	if fixtures[0].Response.Headers.Get("Content-Length") != "" || fixtures[0].Response.Headers.Get("X-Upstream") != "stub" {
+		t.Errorf("expected headers of upstream recorded without headers of connection, got %v", fixtures[0].Response.Headers)
+	}
+
+	// upstream is down, so responses are replayed from fixtures. Json of fixtures is compacted
+	_, replayServer := newTestServer(t, fmt.Sprintf(`{"mode": "replay", "fixtures": %q}`, dir))
+	defer replayServer.Close()
+	checkProxySteps(t, replayServer.URL, []proxyStep{
+		{"GET", "/users?id=2", "", http.StatusOK, `{"n":3,"path":"/users"`},
+		// fixtures with the same key are replayed in turn, last one is repeated
+		{"GET", "/users?id=1", "", http.StatusOK, `{"n":1,"path":"/users"`},
+		{"GET", "/users?id=1", "", http.StatusOK, `{"n":2,"path":"/users"`},
+		{"GET", "/users?id=1", "", http.StatusOK, `{"n":2,"path":"/users"`},
+		{"GET", "/redirect", "", http.StatusFound, ""},
+		{"GET", "/users?id=3", "", http.StatusNotFound, ""},
+		{"POST", "/users?id=1", "", http.StatusNotFound, ""},
+	})
+
+	// replayed fixtures are counted from start after reset of calls
+	status, body := doRequest(t, "POST", replayServer.URL+"/__reset_calls", "")
+	if status != http.StatusOK {
+		t.Fatalf("unable to reset mock: %d %s", status, body)
+	}
+	checkProxySteps(t, replayServer.URL, []proxyStep{
+		{"GET", "/users?id=1", "", http.StatusOK, `{"n":1,"path":"/users"`},
+	})
+}
+
+func TestReplayMatchBy(t *testing.T) {
+	dir := tempFixturesDir(t)
+	defer os.RemoveAll(dir)
+
+	upstream := newStubUpstream()
+	_, recordServer := newTestServer(t, fmt.Sprintf(`{
+		"mode": "record",
+		"upstream": %q,
+		"fixtures": %q,
+		"match_by": ["method", "path", "header.X-Tenant"]
+	}`, upstream.URL, dir))
+	checkProxySteps(t, recordServer.URL, []proxyStep{
+		{"GET", "/users?ts=1", "a", http.StatusOK, `"tenant": "a"`},
+		{"GET", "/users?ts=2", "b", http.StatusOK, `"tenant": "b"`},
+	})
+	recordServer.Close()
+	upstream.Close()
+
+	_, replayServer := newTestServer(t, fmt.Sprintf(`{
+		"mode": "replay",
+		"fixtures": %q,
+		"match_by": ["method", "path", "header.X-Tenant"]
+	}`, dir))
+	defer replayServer.Close()
+	checkProxySteps(t, replayServer.URL, []proxyStep{
+		// query is not used to match fixtures
+		{"GET", "/users?ts=3", "b", http.StatusOK, `"tenant":"b"`},
+		{"GET", "/users", "a", http.StatusOK, `"tenant":"a"`},
+		{"GET", "/users?ts=1", "c", http.StatusNotFound, ""},
+		{"GET", "/users?ts=1", "", http.StatusNotFound, ""},
+	})
+}
+
+func TestReplayCorruptFixture(t *testing.T) {
+	dir := tempFixturesDir(t)
+	defer os.RemoveAll(dir)
+	err := ioutil.WriteFile(filepath.Join(dir, "0001_GET_users.json"), []byte("not json"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, replayServer := newTestServer(t, fmt.Sprintf(`{"mode": "replay", "fixtures": %q}`, dir))
+	defer replayServer.Close()
+	status, body := doRequest(t, "GET", replayServer.URL+"/users", "")
+	if status != http.StatusInternalServerError || !strings.Contains(body, `unable to load fixtures: unable to unmarshal fixture "0001_GET_users.json"`) {
+		t.Errorf("expected error of corrupt fixture, got %d %s", status, body)
+	}
+}
+
+func TestRecordUpstreamDown(t *testing.T) {
+	dir := tempFixturesDir(t)
+	defer os.RemoveAll(dir)
+
+	upstream := newStubUpstream()
+	upstream.Close()
+	_, recordServer := newTestServer(t, fmt.Sprintf(`{"mode": "record", "upstream": %q, "fixtures": %q}`, upstream.URL, dir))
+	defer recordServer.Close()
+	status, body := doRequest(t, "GET", recordServer.URL+"/users", "")
+	if status != http.StatusBadGateway || !strings.Contains(body, "unable to send request to upstream") {
+		t.Errorf("expected bad gateway, got %d %s", status, body)
+	}
+	fixtures, err := LoadFixtures(dir)
+	if err != nil || len(fixtures) != 0 {
+		t.Errorf("expected no fixtures recorded, got %v %v", fixtures, err)
+	}
+}
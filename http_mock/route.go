@@ -0,0 +1,177 @@
+package http_mock
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// request is data of request available in conditions and in templates like `[[ .request.params.id ]]`
+type request struct {
+	method  string
+	path    string
+	params  map[string]string
+	query   map[string]string
+	headers map[string]string
+	body    string
+	// body parsed if it is json
+	json interface{}
+}
+
+func newRequest(r *http.Request, params map[string]string, body []byte) *request {
+	req := request{
+		method:  r.Method,
+		path:    r.URL.Path,
+		params:  params,
+		query:   firstValues(r.URL.Query()),
+		headers: firstValues(r.Header),
+		body:    string(body),
+	}
+	var parsedBody interface{}
+	if json.Unmarshal(body, &parsedBody) == nil {
+		req.json = parsedBody
+	}
+	return &req
+}
+
+func firstValues(values map[string][]string) map[string]string {
+	res := make(map[string]string, len(values))
+	for name, list := range values {
+		if len(list) != 0 {
+			res[name] = list[0]
+		}
+	}
+	return res
+}
+
+func (r request) templateData() map[string]interface{} {
+	return map[string]interface{}{
+		"method":  r.method,
+		"path":    r.path,
+		"params":  r.params,
+		"query":   r.query,
+		"headers": r.headers,
+		"body":    r.body,
+		"json":    r.json,
+	}
+}
+
+// matchRoute returns route for request and params of path. Route with method has priority over route without it, routes without params have priority over routes with params
+// and routes with less params have priority over routes with more params. Should be called with locked mutex
+func (s *Server) matchRoute(method string, path string) (string, Response, map[string]string, bool) {
+	route := method + " " + path
+	if response, ok := s.routes[route]; ok {
+		return route, response, nil, true
+	}
+	if response, ok := s.routes[path]; ok {
+		return path, response, nil, true
+	}
+	routes := make([]string, 0, len(s.routes))
+	for route := range s.routes {
+		if strings.Contains(route, "{") {
+			routes = append(routes, route)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		iWithMethod := !strings.HasPrefix(routes[i], "/")
+		jWithMethod := !strings.HasPrefix(routes[j], "/")
+		if iWithMethod != jWithMethod {
+			return iWithMethod
+		}
+		iParams := strings.Count(routes[i], "{")
+		jParams := strings.Count(routes[j], "{")
+		if iParams != jParams {
+			return iParams < jParams
+		}
+		return routes[i] < routes[j]
+	})
+	for _, route := range routes {
+		routeMethod, routePath := splitRoute(route)
+		if routeMethod != "" && routeMethod != method {
+			continue
+		}
+		params, ok := matchPath(routePath, path)
+		if ok {
+			return route, s.routes[route], params, true
+		}
+	}
+	return "", Response{}, nil, false
+}
+
+func splitRoute(route string) (string, string) {
+	if strings.HasPrefix(route, "/") {
+		return "", route
+	}
+	parts := strings.SplitN(route, " ", 2)
+	return parts[0], parts[1]
+}
+
+// matchPath matches path by pattern like `/users/{id}`
+func matchPath(pattern string, path string) (map[string]string, bool) {
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[segment[1:len(segment)-1]] = pathSegments[i]
+			continue
+		}
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+func (c Condition) match(r *request) bool {
+	if c.Method != "" && !strings.EqualFold(c.Method, r.method) {
+		return false
+	}
+	if !containsValues(r.params, c.Params) || !containsValues(r.query, c.Query) {
+		return false
+	}
+	for name, value := range c.Headers {
+		if r.headers[http.CanonicalHeaderKey(name)] != value {
+			return false
+		}
+	}
+	if c.Body == nil {
+		return true
+	}
+	if str, ok := c.Body.(string); ok {
+		return strings.Contains(r.body, str)
+	}
+	return containsJson(r.json, c.Body)
+}
+
+func containsValues(actual map[string]string, expected map[string]string) bool {
+	for name, value := range expected {
+		if actual[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// containsJson checks that actual json contains fields of expected objects. Arrays and other values should be equal
+func containsJson(actual interface{}, expected interface{}) bool {
+	expectedMap, ok := expected.(map[string]interface{})
+	if !ok {
+		return reflect.DeepEqual(actual, expected)
+	}
+	actualMap, ok := actual.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, value := range expectedMap {
+		if !containsJson(actualMap[key], value) {
+			return false
+		}
+	}
+	return true
+}
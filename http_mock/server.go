@@ -0,0 +1,433 @@
+// Package http_mock implements http server used as mock of upstream services.
+//
+// Routes respond with responses defined in config, every matched request is recorded as call.
+// Mock is controlled by endpoints:
+//
+//	GET /__calls - returns list of recorded calls like `[{"route": "POST /path", "method": "POST", "path": "/path", "query": {}, "headers": {}, "body": "..."}]`
+//	POST /__reset_calls - removes recorded calls and restarts sequences of responses and fixtures
+//	POST /__config - replaces config available in templates of bodies by json object from body. Key `routes` of object overrides responses of routes.
+//	  Sequences of responses and fixtures are restarted. Empty body restores initial config and routes
+//	PUT /__config - replaces whole config of mock (routes too) by json from body. Recorded calls are kept
+//
+// Bodies of responses are templates. `{{ .config.key }}` is replaced by value from config,
+// `[[ .request.params.id ]]` is replaced by field of request (method, path, params, query, headers, body, json) when request is handled.
+// Missing keys fail rendering and the error is responded with status 500, optional keys can be rendered like `[[ index .request.query "name" ]]`.
+//
+// Mock serves https if certificates are defined in config and it is launched with tls listener.
+//
+// Requests not matched by routes can be proxied to upstream and saved to fixtures (`record` mode) or responded by saved fixtures (`replay` mode)
+package http_mock
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	faultDrop    = "drop"
+	faultTimeout = "timeout"
+)
+
+type Call struct {
+	// route from config matched by request
+	Route   string      `json:"route"`
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Query   url.Values  `json:"query"`
+	Headers http.Header `json:"headers"`
+	// raw body of request
+	Body string `json:"body"`
+}
+
+func newCall(route string, r *http.Request, body []byte) Call {
+	return Call{
+		Route:   route,
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Headers: r.Header,
+		Body:    string(body),
+	}
+}
+
+type Server struct {
+	mutex  sync.Mutex
+	config Config
+	// routes of config with routes overridden by `POST /__config`
+	routes     map[string]Response
+	userConfig map[string]interface{}
+	calls      []Call
+	// count of calls of responses with sequences by path of response like `GET /path/responses/0`
+	sequences map[string]int
+	// fixtures loaded in replay mode and their keys made by `match_by`
+	fixtures    []Fixture
+	fixtureKeys []string
+	// error of clearing or loading of fixtures, returned by every request to fixtures
+	fixturesErr error
+	// count of replays of fixtures by key of request
+	replayed map[string]int
+	// count of fixtures saved in record mode
+	recorded int
+	// certificates of config by host names
+	certificates map[string]*tls.Certificate
+	// closed to stop handling of delayed requests and timeouts
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func NewServer(config Config) *Server {
+	s := &Server{
+		closed: make(chan struct{}),
+	}
+	s.SetConfig(config)
+	return s
+}
+
+// SetConfig replaces routes and config of mock
+func (s *Server) SetConfig(config Config) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.config = config
+	s.routes = config.Routes
+	s.userConfig = config.Config
+	s.sequences = make(map[string]int)
+	// certificates are validated with config
+	s.certificates, _ = parseCertificates(config.Certificates)
+	s.setupFixtures()
+}
+
+// Close interrupts requests waiting for delay or timeout
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+}
+
+func (s *Server) Calls() []Call {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	calls := make([]Call, len(s.calls))
+	copy(calls, s.calls)
+	return calls
+}
+
+func (s *Server) ResetCalls() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.calls = nil
+	s.sequences = make(map[string]int)
+	s.replayed = make(map[string]int)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/__calls":
+		s.handleCalls(w, r)
+	case "/__reset_calls":
+		s.ResetCalls()
+	case "/__config":
+		s.handleConfig(w, r)
+	default:
+		s.handleRoute(w, r)
+	}
+}
+
+func (s *Server) handleCalls(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(s.Calls())
+	if err != nil {
+		log.Printf("unable to write calls: %v", err)
+	}
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		config, err := ParseConfig(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.SetConfig(*config)
+	case http.MethodPost:
+		userConfig, routes, err := parseUserConfig(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mutex.Lock()
+		if userConfig == nil {
+			userConfig = s.config.Config
+		}
+		s.userConfig = userConfig
+		s.routes = s.config.Routes
+		if routes != nil {
+			s.routes = make(map[string]Response, len(s.config.Routes)+len(routes))
+			for route, response := range s.config.Routes {
+				s.routes[route] = response
+			}
+			for route, response := range routes {
+				s.routes[route] = response
+			}
+		}
+		s.sequences = make(map[string]int)
+		s.replayed = make(map[string]int)
+		s.mutex.Unlock()
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// parseUserConfig parses body of `POST /__config`. Key `routes` is returned separately
+func parseUserConfig(body []byte) (map[string]interface{}, map[string]Response, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil, nil
+	}
+	var userConfig map[string]json.RawMessage
+	err := json.Unmarshal(body, &userConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to unmarshal config: %v", err)
+	}
+	var routes map[string]Response
+	if routesBytes, ok := userConfig["routes"]; ok {
+		err = json.Unmarshal(routesBytes, &routes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to unmarshal routes: %v", err)
+		}
+		err = validateRoutes(routes)
+		if err != nil {
+			return nil, nil, err
+		}
+		delete(userConfig, "routes")
+	}
+	res := make(map[string]interface{}, len(userConfig))
+	for key, valueBytes := range userConfig {
+		var value interface{}
+		err = json.Unmarshal(valueBytes, &value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to unmarshal config %q: %v", key, err)
+		}
+		res[key] = value
+	}
+	return res, routes, nil
+}
+
+func (s *Server) handleRoute(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read body: %v", err), http.StatusBadRequest)
+		return
+	}
+	s.mutex.Lock()
+	route, response, params, ok := s.matchRoute(r.Method, r.URL.Path)
+	req := newRequest(r, params, body)
+	if ok {
+		s.calls = append(s.calls, newCall(route, r, body))
+		response = s.chooseResponse(route, response, req)
+	}
+	config := s.config
+	userConfig := s.userConfig
+	if !ok && config.Mode != "" {
+		// proxied and replayed requests are recorded as calls of route like `GET /path`
+		s.calls = append(s.calls, newCall(r.Method+" "+r.URL.Path, r, body))
+	}
+	s.mutex.Unlock()
+	switch {
+	case !ok && config.Mode == ModeRecord:
+		s.handleRecord(w, r, body, config)
+		return
+	case !ok && config.Mode == ModeReplay:
+		s.handleReplay(w, r, body, config)
+		return
+	case !ok:
+		log.Printf("%s: route %s %s not defined", config.ServiceName, r.Method, r.URL.Path)
+		http.NotFound(w, r)
+		return
+	}
+
+	if response.Delay != "" {
+		// delay is validated with config
+		delay, _ := time.ParseDuration(response.Delay)
+		if !s.wait(r, time.After(delay)) {
+			return
+		}
+	}
+	switch response.Fault {
+	case faultDrop:
+		dropConnection(w)
+		return
+	case faultTimeout:
+		s.wait(r, nil)
+		return
+	}
+
+	contentType := config.ResponseContentType
+	if contentType == "" {
+		contentType = defaultResponseContentType
+	}
+	responseBody, err := renderBody(response.Body, contentType, userConfig, req)
+	if err != nil {
+		log.Printf("%s: unable to render response of route %q: %v", config.ServiceName, route, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	status := response.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	_, err = w.Write(responseBody)
+	if err != nil {
+		log.Printf("%s: unable to write response: %v", config.ServiceName, err)
+	}
+}
+
+// chooseResponse chooses response by conditions and sequences. Should be called with locked mutex
+func (s *Server) chooseResponse(path string, response Response, req *request) Response {
+	for i, conditionalResponse := range response.Responses {
+		if conditionalResponse.When.match(req) {
+			return s.chooseResponse(fmt.Sprintf("%s/responses/%d", path, i), conditionalResponse, req)
+		}
+	}
+	if len(response.Sequence) == 0 {
+		return response
+	}
+	index := s.sequences[path]
+	if index < len(response.Sequence)-1 {
+		s.sequences[path]++
+	} else {
+		index = len(response.Sequence) - 1
+	}
+	return s.chooseResponse(fmt.Sprintf("%s/sequence/%d", path, index), response.Sequence[index], req)
+}
+
+// wait waits for channel, closing of request by client or closing of server. Returns true if channel is received
+func (s *Server) wait(r *http.Request, ch <-chan time.Time) bool {
+	select {
+	case <-ch:
+		return true
+	case <-r.Context().Done():
+		return false
+	case <-s.closed:
+		return false
+	}
+}
+
+// dropConnection closes connection without response
+func dropConnection(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	conn.Close()
+}
+
+// renderBody renders strings of body as templates with config and then as templates with request. Body is marshaled to json if content type is json
+func renderBody(body interface{}, contentType string, userConfig map[string]interface{}, req *request) ([]byte, error) {
+	renderedBody, err := renderValue(body, userConfig, req)
+	if err != nil {
+		return nil, err
+	}
+	if str, ok := renderedBody.(string); ok && !strings.Contains(contentType, "json") {
+		return []byte(str), nil
+	}
+	if renderedBody == nil {
+		return nil, nil
+	}
+	res, err := json.Marshal(renderedBody)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal body: %v", err)
+	}
+	return res, nil
+}
+
+func renderValue(value interface{}, userConfig map[string]interface{}, req *request) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			renderedItem, err := renderValue(item, userConfig, req)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			res[key] = renderedItem
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			renderedItem, err := renderValue(item, userConfig, req)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %v", i, err)
+			}
+			res[i] = renderedItem
+		}
+		return res, nil
+	case string:
+		res, err := renderTemplate(v, "{{", "}}", map[string]interface{}{
+			"config": userConfig,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// request is rendered after config to not execute templates from request
+		return renderTemplate(res, "[[", "]]", map[string]interface{}{
+			"config":  userConfig,
+			"request": req.templateData(),
+		})
+	default:
+		return value, nil
+	}
+}
+
+func renderTemplate(str string, leftDelim string, rightDelim string, data map[string]interface{}) (string, error) {
+	if !strings.Contains(str, leftDelim) {
+		return str, nil
+	}
+	// missing key fails rendering, so misspelled name is not rendered as `<no value>`.
+	// optional query or headers of request can be rendered like `[[ index .request.query "name" ]]`, missing ones are empty strings
+	tmpl, err := template.New("body").Delims(leftDelim, rightDelim).Option("missingkey=error").Funcs(template.FuncMap{
+		"json": toJson,
+	}).Parse(str)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse template: %v", err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, data)
+	if err != nil {
+		return "", fmt.Errorf("unable to execute template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// toJson is used in templates of string bodies to insert value to json like `{"name": [[ json .request.query.name ]]}`
+func toJson(v interface{}) (string, error) {
+	res, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(res), nil
+}
@@ -0,0 +1,526 @@
+package http_mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer starts mock with config in json, it should be closed by test
+func newTestServer(t *testing.T, configJson string) (*Server, *httptest.Server) {
+	config, err := ParseConfig([]byte(configJson))
+	if err != nil {
+		t.Fatalf("unable to parse config: %v", err)
+	}
+	server := NewServer(*config)
+	return server, httptest.NewServer(server)
+}
+
+func doRequest(t *testing.T, method string, url string, body string) (int, string) {
+	request, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("unable to send request %s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(respBody)
+}
+
+func getCalls(t *testing.T, url string) []Call {
+	status, body := doRequest(t, "GET", url+"/__calls", "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d of calls: %s", status, body)
+	}
+	var calls []Call
+	err := json.Unmarshal([]byte(body), &calls)
+	if err != nil {
+		t.Fatalf("unable to unmarshal calls %s: %v", body, err)
+	}
+	return calls
+}
+
+func TestCalls(t *testing.T) {
+	server, httpServer := newTestServer(t, `{
+		"routes": {
+			"POST /users": {"status": 201, "body": {"id": 1}},
+			"/users/{id}": {"sequence": [{"body": {"n": 1}}, {"body": {"n": 2}}]}
+		}
+	}`)
+	defer httpServer.Close()
+	defer server.Close()
+
+	doRequest(t, "POST", httpServer.URL+"/users?source=test", `{"name": "alice"}`)
+	doRequest(t, "GET", httpServer.URL+"/users/1", "")
+	doRequest(t, "GET", httpServer.URL+"/missing", "")
+
+	calls := getCalls(t, httpServer.URL)
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls of routes, got %#v", calls)
+	}
+	if calls[0].Route != "POST /users" || calls[0].Method != "POST" || calls[0].Path != "/users" ||
+		calls[0].Query.Get("source") != "test" || calls[0].Body != `{"name": "alice"}` {
+		t.Errorf("unexpected call %#v", calls[0])
+	}
+	if calls[1].Route != "/users/{id}" || calls[1].Method != "GET" || calls[1].Path != "/users/1" {
+		t.Errorf("unexpected call %#v", calls[1])
+	}
+	if _, body := doRequest(t, "GET", httpServer.URL+"/users/1", ""); body != `{"n":2}` {
+		t.Errorf("expected second response of sequence, got %s", body)
+	}
+
+	status, _ := doRequest(t, "POST", httpServer.URL+"/__reset_calls", "")
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d of reset", status)
+	}
+	if calls := getCalls(t, httpServer.URL); len(calls) != 0 {
+		t.Errorf("expected no calls after reset, got %#v", calls)
+	}
+	if _, body := doRequest(t, "GET", httpServer.URL+"/users/1", ""); body != `{"n":1}` {
+		t.Errorf("expected sequence restarted after reset, got %s", body)
+	}
+}
+
+func TestPostConfig(t *testing.T) {
+	server, httpServer := newTestServer(t, `{
+		"config": {"name": "initial"},
+		"routes": {
+			"/name": {"body": {"name": "{{ .config.name }}"}},
+			"/kept": {"body": {"kept": true}},
+			"/overridden": {"body": {"overridden": false}}
+		}
+	}`)
+	defer httpServer.Close()
+	defer server.Close()
+	doRequest(t, "GET", httpServer.URL+"/kept", "")
+
+	status, body := doRequest(t, "POST", httpServer.URL+"/__config", `{
+		"name": "updated",
+		"routes": {
+			"/overridden": {"body": {"overridden": true}},
+			"/added": {"status": 202}
+		}
+	}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d of config: %s", status, body)
+	}
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		// config is replaced
+		{"/name", 200, `{"name":"updated"}`},
+		// routes are merged with initial routes
+		{"/kept", 200, `{"kept":true}`},
+		{"/overridden", 200, `{"overridden":true}`},
+		{"/added", 202, ``},
+	}
+	for _, test := range tests {
+		status, body := doRequest(t, "GET", httpServer.URL+test.path, "")
+		if status != test.status || body != test.body {
+			t.Errorf("expected %d %s for %s, got %d %s", test.status, test.body, test.path, status, body)
+		}
+	}
+	// calls are kept
+	if calls := getCalls(t, httpServer.URL); len(calls) != 5 {
+		t.Errorf("expected calls kept after config, got %#v", calls)
+	}
+
+	// config without routes restores initial routes
+	doRequest(t, "POST", httpServer.URL+"/__config", `{"name": "second"}`)
+	if _, body := doRequest(t, "GET", httpServer.URL+"/name", ""); body != `{"name":"second"}` {
+		t.Errorf("expected updated config, got %s", body)
+	}
+	if _, body := doRequest(t, "GET", httpServer.URL+"/overridden", ""); body != `{"overridden":false}` {
+		t.Errorf("expected initial route, got %s", body)
+	}
+	if status, _ := doRequest(t, "GET", httpServer.URL+"/added", ""); status != http.StatusNotFound {
+		t.Errorf("expected added route removed, got status %d", status)
+	}
+
+	// empty body restores initial config
+	doRequest(t, "POST", httpServer.URL+"/__config", "")
+	if _, body := doRequest(t, "GET", httpServer.URL+"/name", ""); body != `{"name":"initial"}` {
+		t.Errorf("expected initial config, got %s", body)
+	}
+
+	status, _ = doRequest(t, "POST", httpServer.URL+"/__config", `{"routes": {"invalid": {}}}`)
+	if status != http.StatusBadRequest {
+		t.Errorf("expected bad request for invalid route, got %d", status)
+	}
+}
+
+func TestPutConfig(t *testing.T) {
+	server, httpServer := newTestServer(t, `{
+		"config": {"name": "initial"},
+		"routes": {
+			"/name": {"body": {"name": "{{ .config.name }}"}},
+			"/removed": {}
+		}
+	}`)
+	defer httpServer.Close()
+	defer server.Close()
+	doRequest(t, "GET", httpServer.URL+"/removed", "")
+
+	status, body := doRequest(t, "PUT", httpServer.URL+"/__config", `{
+		"config": {"name": "replaced"},
+		"routes": {
+			"/name": {"body": {"replaced": "{{ .config.name }}"}}
+		}
+	}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d of config: %s", status, body)
+	}
+	if _, body := doRequest(t, "GET", httpServer.URL+"/name", ""); body != `{"replaced":"replaced"}` {
+		t.Errorf("expected replaced route, got %s", body)
+	}
+	if status, _ := doRequest(t, "GET", httpServer.URL+"/removed", ""); status != http.StatusNotFound {
+		t.Errorf("expected route not defined in new config removed, got status %d", status)
+	}
+	if calls := getCalls(t, httpServer.URL); len(calls) != 2 {
+		t.Errorf("expected calls kept after config, got %#v", calls)
+	}
+
+	// empty body of POST restores config replaced by PUT
+	doRequest(t, "POST", httpServer.URL+"/__config", `{"name": "posted"}`)
+	doRequest(t, "POST", httpServer.URL+"/__config", "")
+	if _, body := doRequest(t, "GET", httpServer.URL+"/name", ""); body != `{"replaced":"replaced"}` {
+		t.Errorf("expected config of PUT, got %s", body)
+	}
+
+	status, _ = doRequest(t, "PUT", httpServer.URL+"/__config", `{"routes": {"/delayed": {"delay": "soon"}}}`)
+	if status != http.StatusBadRequest {
+		t.Errorf("expected bad request for invalid config, got %d", status)
+	}
+	status, _ = doRequest(t, "DELETE", httpServer.URL+"/__config", "")
+	if status != http.StatusMethodNotAllowed {
+		t.Errorf("expected method not allowed, got %d", status)
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	server := NewServer(Config{
+		Routes: map[string]Response{
+			"/users":                    {},
+			"GET /users":                {},
+			"/users/me":                 {},
+			"/users/{id}":               {},
+			"DELETE /users/{id}":        {},
+			"/users/{id}/posts/{post}":  {},
+			"/{collection}/{id}/posts":  {},
+			"/users/{user}/posts/draft": {},
+		},
+	})
+	tests := []struct {
+		method string
+		path   string
+		route  string
+		params map[string]string
+	}{
+		// route with method has priority
+		{"GET", "/users", "GET /users", nil},
+		{"POST", "/users", "/users", nil},
+		// route without params has priority
+		{"GET", "/users/me", "/users/me", nil},
+		{"DELETE", "/users/me", "/users/me", nil},
+		{"GET", "/users/1", "/users/{id}", map[string]string{"id": "1"}},
+		// route with method and params has priority over route with params
+		{"DELETE", "/users/1", "DELETE /users/{id}", map[string]string{"id": "1"}},
+		{"GET", "/users/1/posts/2", "/users/{id}/posts/{post}", map[string]string{"id": "1", "post": "2"}},
+		// route with less params has priority
+		{"GET", "/users/1/posts/draft", "/users/{user}/posts/draft", map[string]string{"user": "1"}},
+		{"GET", "/groups/1/posts", "/{collection}/{id}/posts", map[string]string{"collection": "groups", "id": "1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			route, _, params, ok := server.matchRoute(test.method, test.path)
+			if !ok {
+				t.Fatalf("route not matched")
+			}
+			if route != test.route {
+				t.Errorf("expected route %q, got %q", test.route, route)
+			}
+			if len(params) != 0 || len(test.params) != 0 {
+				if !reflect.DeepEqual(params, test.params) {
+					t.Errorf("expected params %v, got %v", test.params, params)
+				}
+			}
+		})
+	}
+
+	for _, path := range []string{"/", "/groups", "/users/1/comments", "/users/1/posts/2/3"} {
+		if route, _, _, ok := server.matchRoute("GET", path); ok {
+			t.Errorf("expected %q not matched, but it matched %q", path, route)
+		}
+	}
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		params  map[string]string
+		ok      bool
+	}{
+		{"/users", "/users", map[string]string{}, true},
+		{"/users", "/users/", nil, false},
+		{"/users/{id}", "/users/42", map[string]string{"id": "42"}, true},
+		{"/users/{id}", "/users/", map[string]string{"id": ""}, true},
+		{"/users/{id}", "/users/42/posts", nil, false},
+		{"/users/{id}", "/groups/42", nil, false},
+		{"/users/{id}/posts/{post}", "/users/1/posts/abc", map[string]string{"id": "1", "post": "abc"}, true},
+		{"/{a}/{b}", "/x/y", map[string]string{"a": "x", "b": "y"}, true},
+		// only whole segments are params
+		{"/users/id{id}", "/users/id1", nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.path, func(t *testing.T) {
+			params, ok := matchPath(test.pattern, test.path)
+			if ok != test.ok {
+				t.Fatalf("expected match %v, got %v", test.ok, ok)
+			}
+			if !reflect.DeepEqual(params, test.params) {
+				t.Errorf("expected params %#v, got %#v", test.params, params)
+			}
+		})
+	}
+}
+
+func TestRenderTemplates(t *testing.T) {
+	server, httpServer := newTestServer(t, `{
+		"config": {"currency": "USD"},
+		"routes": {
+			"/users/{id}": {"body": {"id": "[[ .request.params.id ]]", "currency": "{{ .config.currency }}", "name": "[[ .request.json.name ]]"}},
+			"/optional": {"body": {"source": "[[ index .request.query \"source\" ]]"}},
+			"/missing_config": {"body": {"currency": "{{ .config.curency }}"}},
+			"/missing_query": {"body": {"source": "[[ .request.query.source ]]"}},
+			"/missing_json": {"body": {"name": "[[ .request.json.nmae ]]"}}
+		}
+	}`)
+	defer httpServer.Close()
+	defer server.Close()
+
+	tests := []struct {
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{path: "/users/1", body: `{"name": "alice"}`, expectedStatus: http.StatusOK, expectedBody: `{"currency":"USD","id":"1","name":"alice"}`},
+		{path: "/optional?source=test", expectedStatus: http.StatusOK, expectedBody: `{"source":"test"}`},
+		{path: "/optional", expectedStatus: http.StatusOK, expectedBody: `{"source":""}`},
+		{path: "/missing_config", expectedStatus: http.StatusInternalServerError, expectedBody: "curency"},
+		{path: "/missing_query", expectedStatus: http.StatusInternalServerError, expectedBody: "source"},
+		{path: "/missing_json", body: `{"name": "alice"}`, expectedStatus: http.StatusInternalServerError, expectedBody: "nmae"},
+	}
+	for _, test := range tests {
+		status, body := doRequest(t, "POST", httpServer.URL+test.path, test.body)
+		if status != test.expectedStatus {
+			t.Errorf("%s: expected status %d, got %d (%s)", test.path, test.expectedStatus, status, body)
+			continue
+		}
+		if status == http.StatusOK && body != test.expectedBody {
+			t.Errorf("%s: expected body %s, got %s", test.path, test.expectedBody, body)
+		}
+		if status != http.StatusOK && !strings.Contains(body, test.expectedBody) {
+			t.Errorf("%s: expected error about %q, got %s", test.path, test.expectedBody, body)
+		}
+	}
+}
+
+func TestChooseResponse(t *testing.T) {
+	server, httpServer := newTestServer(t, `{
+		"routes": {
+			"POST /orders": {
+				"body": "default",
+				"responses": [
+					{"when": {"body": {"type": "express"}}, "sequence": [{"status": 201, "body": "express 1"}, {"status": 201, "body": "express 2"}]},
+					{"when": {"query": {"dry_run": "1"}}, "status": 204},
+					{"when": {"headers": {"x-tenant": "b"}}, "body": "tenant b", "responses": [
+						{"when": {"method": "post", "body": "urgent"}, "status": 202, "body": "tenant b urgent"}
+					]}
+				]
+			},
+			"/items/{id}": {
+				"responses": [{"when": {"params": {"id": "1"}}, "body": "first"}],
+				"sequence": [{"body": "s1"}, {"sequence": [{"body": "n1"}, {"body": "n2"}]}]
+			}
+		}
+	}`)
+	defer httpServer.Close()
+	defer server.Close()
+
+	tests := []struct {
+		method         string
+		path           string
+		headers        map[string]string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"POST", "/orders", nil, `{"type": "express", "id": 1}`, http.StatusCreated, `"express 1"`},
+		{"POST", "/orders", nil, `{"type": "regular"}`, http.StatusOK, `"default"`},
+		{"POST", "/orders", nil, `{"type": "express"}`, http.StatusCreated, `"express 2"`},
+		// last response of sequence is repeated
+		{"POST", "/orders", nil, `{"type": "express"}`, http.StatusCreated, `"express 2"`},
+		{"POST", "/orders?dry_run=1", nil, `{"type": "regular"}`, http.StatusNoContent, ""},
+		{"POST", "/orders", map[string]string{"X-Tenant": "b"}, "urgent order", http.StatusAccepted, `"tenant b urgent"`},
+		// conditional response is used itself if no one of its responses matched
+		{"POST", "/orders", map[string]string{"X-Tenant": "b"}, "order", http.StatusOK, `"tenant b"`},
+		{"GET", "/items/2", nil, "", http.StatusOK, `"s1"`},
+		// conditional response doesn't move sequence
+		{"GET", "/items/1", nil, "", http.StatusOK, `"first"`},
+		{"GET", "/items/3", nil, "", http.StatusOK, `"n1"`},
+		{"GET", "/items/2", nil, "", http.StatusOK, `"n2"`},
+		{"GET", "/items/2", nil, "", http.StatusOK, `"n2"`},
+	}
+	for i, test := range tests {
+		request, err := http.NewRequest(test.method, httpServer.URL+test.path, strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, value := range test.headers {
+			request.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("#%d: unable to send request: %v", i, err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != test.expectedStatus || string(body) != test.expectedBody {
+			t.Errorf("#%d %s %s: expected %d %s, got %d %s", i, test.method, test.path, test.expectedStatus, test.expectedBody, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestDelay(t *testing.T) {
+	server, httpServer := newTestServer(t, `{
+		"routes": {
+			"/slow": {"delay": "100ms", "body": "slow"},
+			"/sequence": {"sequence": [{"body": "fast"}, {"delay": "100ms", "body": "slow"}]}
+		}
+	}`)
+	defer httpServer.Close()
+	defer server.Close()
+
+	tests := []struct {
+		path         string
+		expectedBody string
+		expectedWait bool
+	}{
+		{"/slow", `"slow"`, true},
+		{"/sequence", `"fast"`, false},
+		{"/sequence", `"slow"`, true},
+	}
+	for i, test := range tests {
+		start := time.Now()
+		status, body := doRequest(t, "GET", httpServer.URL+test.path, "")
+		elapsed := time.Since(start)
+		if status != http.StatusOK || body != test.expectedBody {
+			t.Errorf("#%d %s: expected %s, got %d %s", i, test.path, test.expectedBody, status, body)
+		}
+		if test.expectedWait != (elapsed >= 100*time.Millisecond) {
+			t.Errorf("#%d %s: expected delay %v, got response in %v", i, test.path, test.expectedWait, elapsed)
+		}
+	}
+}
+
+func TestFaults(t *testing.T) {
+	server, httpServer := newTestServer(t, `{
+		"routes": {
+			"/drop": {"fault": "drop"},
+			"/timeout": {"fault": "timeout"},
+			"/delayed_drop": {"delay": "50ms", "fault": "drop"}
+		}
+	}`)
+	defer httpServer.Close()
+	defer server.Close()
+
+	client := &http.Client{Timeout: 200 * time.Millisecond}
+	for _, path := range []string{"/drop", "/delayed_drop"} {
+		resp, err := client.Get(httpServer.URL + path)
+		if err == nil {
+			resp.Body.Close()
+			t.Errorf("%s: expected connection dropped, got status %d", path, resp.StatusCode)
+			continue
+		}
+		if strings.Contains(err.Error(), "Timeout") {
+			t.Errorf("%s: expected connection dropped without waiting, got %v", path, err)
+		}
+	}
+	resp, err := client.Get(httpServer.URL + "/timeout")
+	if err == nil {
+		resp.Body.Close()
+		t.Errorf("expected timeout, got status %d", resp.StatusCode)
+	} else if !strings.Contains(err.Error(), "Timeout") {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	calls := getCalls(t, httpServer.URL)
+	if len(calls) != 3 {
+		t.Errorf("expected calls recorded for faults, got %+v", calls)
+	}
+}
+
+func TestCloseReleasesWaitingRequests(t *testing.T) {
+	server, httpServer := newTestServer(t, `{
+		"routes": {
+			"/timeout": {"fault": "timeout"},
+			"/slow": {"delay": "1h", "body": "slow"}
+		}
+	}`)
+	defer httpServer.Close()
+
+	done := make(chan string, 2)
+	for _, path := range []string{"/timeout", "/slow"} {
+		go func(path string) {
+			resp, err := http.Get(httpServer.URL + path)
+			if err != nil {
+				done <- fmt.Sprintf("%s: %v", path, err)
+				return
+			}
+			body, _ := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			done <- fmt.Sprintf("%s: %d %s", path, resp.StatusCode, body)
+		}(path)
+	}
+	// requests are waiting after they are recorded
+	for start := time.Now(); len(server.Calls()) != 2; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("expected calls recorded, got %+v", server.Calls())
+		}
+	}
+	select {
+	case res := <-done:
+		t.Fatalf("expected request is waiting, got %s", res)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	server.Close()
+	for i := 0; i < 2; i++ {
+		select {
+		case res := <-done:
+			if !strings.HasSuffix(res, ": 200 ") {
+				t.Errorf("expected empty response after close, got %s", res)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected requests released by close")
+		}
+	}
+}
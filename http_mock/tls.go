@@ -0,0 +1,54 @@
+package http_mock
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"strings"
+)
+
+// Certificate is served by tls listener of mock for host names of certificate
+type Certificate struct {
+	// certificate and private key in pem format
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+// TlsConfig returns config of tls listener. Certificate is chosen by host name requested by client
+func (s *Server) TlsConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: s.getCertificate,
+	}
+}
+
+func (s *Server) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	certificate, ok := s.certificates[strings.ToLower(hello.ServerName)]
+	if !ok {
+		return nil, fmt.Errorf("certificate for host %q not defined", hello.ServerName)
+	}
+	return certificate, nil
+}
+
+// parseCertificates returns certificates by their host names
+func parseCertificates(certificates []Certificate) (map[string]*tls.Certificate, error) {
+	res := make(map[string]*tls.Certificate)
+	for i, certificate := range certificates {
+		pair, err := tls.X509KeyPair([]byte(certificate.Cert), []byte(certificate.Key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate #%d: %v", i, err)
+		}
+		pair.Leaf, err = x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate #%d: %v", i, err)
+		}
+		if len(pair.Leaf.DNSNames) == 0 {
+			return nil, fmt.Errorf("invalid certificate #%d: host names not defined", i)
+		}
+		for _, hostname := range pair.Leaf.DNSNames {
+			res[strings.ToLower(hostname)] = &pair
+		}
+	}
+	return res, nil
+}
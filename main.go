@@ -3,9 +3,14 @@ package main
 import (
 	"integration_framework/application"
 	_ "integration_framework/plugins/docker_compose"
+	_ "integration_framework/plugins/docker_engine"
+	_ "integration_framework/plugins/external"
 	_ "integration_framework/plugins/filesystem"
 	_ "integration_framework/plugins/graphql"
 	_ "integration_framework/plugins/http_server"
+	_ "integration_framework/plugins/local"
+	_ "integration_framework/plugins/logs"
+	_ "integration_framework/plugins/metrics"
 	_ "integration_framework/plugins/mysql"
 	_ "integration_framework/plugins/postgres"
 	_ "integration_framework/plugins/smtp"
@@ -14,8 +19,6 @@ import (
 	"time"
 )
 
-// TODO хорошо бы вынести нейминг сервисов и их файлов из самих сервисов в docker_compose плагин чтобы он диктовал условия, а не плагины
-
 func main() {
 	rand.Seed(time.Now().UnixNano())
 
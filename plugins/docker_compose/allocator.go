@@ -0,0 +1,175 @@
+package docker_compose
+
+import (
+	"fmt"
+	"integration_framework/application_config"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var invalidServiceNameCharsRegexp = regexp.MustCompile(`[^a-z0-9_.\-]+`)
+
+// Allocator dictates names of docker compose services, their host ports and placement of their files.
+// Everything allocated for key is remembered, so while allocator lives same key gets same value and generated config remains the same
+type Allocator struct {
+	tmpDirectory string
+
+	mutex        sync.Mutex
+	serviceNames map[string]string
+	usedNames    map[string]bool
+	// names of applications are chosen by user, so they are reserved as is
+	applicationNames map[string]bool
+	ports            map[string]int
+	usedPorts        map[int]bool
+	// files registered while generating current config
+	files ServicesFiles
+}
+
+func NewAllocator(tmpDirectory string) *Allocator {
+	return &Allocator{
+		tmpDirectory: tmpDirectory,
+		serviceNames: make(map[string]string),
+		usedNames: map[string]bool{
+			// name of application defined by `application` section is reserved even if it is not used now
+			application_config.DefaultApplicationName: true,
+		},
+		applicationNames: make(map[string]bool),
+		ports:            make(map[string]int),
+		usedPorts:        make(map[int]bool),
+		files:            make(ServicesFiles),
+	}
+}
+
+// ServiceName returns unique name of docker compose service for service of type, like `postgres_main_db`.
+// Name of docker compose service is also host name of service in network
+func (a *Allocator) ServiceName(serviceType string, serviceName string) string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	key := serviceType + "/" + serviceName
+	if name, ok := a.serviceNames[key]; ok {
+		return name
+	}
+	baseName := strings.Trim(invalidServiceNameCharsRegexp.ReplaceAllString(strings.ToLower(serviceType+"_"+serviceName), "_"), "_.-")
+	name := baseName
+	for i := 2; a.usedNames[name]; i++ {
+		name = fmt.Sprintf("%s_%d", baseName, i)
+	}
+	a.serviceNames[key] = name
+	a.usedNames[name] = true
+	return name
+}
+
+// ReserveApplicationName reserves name of application as name of docker compose service.
+// It fails only if name already allocated for service in previous generation of config
+func (a *Allocator) ReserveApplicationName(applicationName string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.applicationNames[applicationName] {
+		return nil
+	}
+	if a.usedNames[applicationName] && applicationName != application_config.DefaultApplicationName {
+		return fmt.Errorf("name of application %q already used by service, restart is required to use it", applicationName)
+	}
+	a.applicationNames[applicationName] = true
+	a.usedNames[applicationName] = true
+	return nil
+}
+
+// HostPort returns free host port to publish container port of docker compose service
+func (a *Allocator) HostPort(dockerComposeServiceName string, containerPort int) (int, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	key := fmt.Sprintf("%s:%d", dockerComposeServiceName, containerPort)
+	if port, ok := a.ports[key]; ok {
+		return port, nil
+	}
+	for {
+		port, err := freePort()
+		if err != nil {
+			return 0, fmt.Errorf("unable to allocate port for %q: %v", key, err)
+		}
+		if a.usedPorts[port] {
+			continue
+		}
+		a.ports[key] = port
+		a.usedPorts[port] = true
+		return port, nil
+	}
+}
+
+// ReserveHostPort uses specified host port for container port of docker compose service instead of allocating free one
+func (a *Allocator) ReserveHostPort(dockerComposeServiceName string, containerPort int, hostPort int) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	key := fmt.Sprintf("%s:%d", dockerComposeServiceName, containerPort)
+	if allocatedPort, ok := a.ports[key]; ok {
+		if allocatedPort == hostPort {
+			return nil
+		}
+		delete(a.usedPorts, allocatedPort)
+	}
+	for otherKey, otherPort := range a.ports {
+		if otherPort == hostPort && otherKey != key {
+			return fmt.Errorf("port %d already used by %q", hostPort, otherKey)
+		}
+	}
+	a.ports[key] = hostPort
+	a.usedPorts[hostPort] = true
+	return nil
+}
+
+// ConfigFile registers file of docker compose service to be written by launcher and returns path to it on host
+func (a *Allocator) ConfigFile(dockerComposeServiceName string, fileName string, contents []byte) string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	path := filepath.Join(a.tmpDirectory, "configs", dockerComposeServiceName, filepath.Base(fileName))
+	if a.files[dockerComposeServiceName] == nil {
+		a.files[dockerComposeServiceName] = make(map[string][]byte)
+	}
+	a.files[dockerComposeServiceName][path] = contents
+	return path
+}
+
+// VolumesDirectory returns directory on host for volumes of docker compose service
+func (a *Allocator) VolumesDirectory(dockerComposeServiceName string) string {
+	return filepath.Join(a.tmpDirectory, "volumes", dockerComposeServiceName)
+}
+
+// VolumePath creates directory for volume of docker compose service and returns path to it on host.
+// Directory is created manually or docker will create it using root as owner and it will not be accessible by current user
+func (a *Allocator) VolumePath(dockerComposeServiceName string, volumeName string) (string, error) {
+	path := filepath.Join(a.VolumesDirectory(dockerComposeServiceName), filepath.Base(volumeName))
+	err := os.MkdirAll(path, os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("unable to create volume directory %q: %v", path, err)
+	}
+	return path, nil
+}
+
+// takeFiles returns files registered since previous call
+func (a *Allocator) takeFiles() ServicesFiles {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	files := a.files
+	a.files = make(ServicesFiles)
+	return files
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// NewProjectName returns unique name of project so several runs on one host do not collide
+func NewProjectName() string {
+	return fmt.Sprintf("integration_framework_%08x", rand.Uint32())
+}
@@ -0,0 +1,93 @@
+package docker_compose
+
+import (
+	"fmt"
+	"integration_framework/application_config"
+	"strings"
+)
+
+const (
+	defaultApplicationContainerPort = 8080
+	defaultApplicationDockerfile    = "Dockerfile.prod"
+	defaultApplicationRestart       = "on-failure"
+	defaultApplicationUser          = "${UID}:${GID}"
+)
+
+// ApplicationContainerPort returns port application listens to inside of container
+func ApplicationContainerPort(config *application_config.ApplicationConfig) int {
+	if config.Port != 0 {
+		return config.Port
+	}
+	return defaultApplicationContainerPort
+}
+
+// ApplicationHostPort returns host port of application. It is `host_port` of application if defined or allocated free port
+func ApplicationHostPort(allocator *Allocator, applicationName string, config *application_config.ApplicationConfig) (int, error) {
+	containerPort := ApplicationContainerPort(config)
+	if config.HostPort != 0 {
+		err := allocator.ReserveHostPort(applicationName, containerPort, config.HostPort)
+		if err != nil {
+			return 0, err
+		}
+		return config.HostPort, nil
+	}
+	return allocator.HostPort(applicationName, containerPort)
+}
+
+// newApplicationService creates service of application. name of application is used as name of docker compose service
+func newApplicationService(allocator *Allocator, applicationName string, config *application_config.ApplicationConfig, commonEnvironment map[string]string) (*DockerComposeService, error) {
+	applicationPort, err := ApplicationHostPort(allocator, applicationName, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to allocate port for application: %v", err)
+	}
+	service := DockerComposeService{
+		config:     config,
+		Image:      config.Image,
+		Command:    config.Command,
+		Entrypoint: config.Entrypoint,
+		Ports: []string{
+			fmt.Sprintf("%d:%d", applicationPort, ApplicationContainerPort(config)),
+		},
+		Environment: make(map[string]string, len(commonEnvironment)+len(config.Environment)),
+		EnvFile:     config.EnvFile,
+		Restart:     config.Restart,
+		User:        config.User,
+	}
+	for name, value := range commonEnvironment {
+		service.Environment[name] = value
+	}
+	for name, value := range config.Environment {
+		service.Environment[name] = value
+	}
+	if service.Image == "" {
+		service.Build = DockerComposeServiceBuild{
+			Context:    config.Path,
+			Dockerfile: config.Dockerfile,
+			Args:       config.BuildArgs,
+			Target:     config.Target,
+		}
+		if service.Build.Dockerfile == "" {
+			service.Build.Dockerfile = defaultApplicationDockerfile
+		}
+	}
+	if service.Restart == "" {
+		service.Restart = defaultApplicationRestart
+	}
+	if service.User == "" {
+		service.User = defaultApplicationUser
+	}
+	if config.Healthcheck != nil {
+		service.Healthcheck = &DockerComposeHealthcheck{
+			Test:        []string{"CMD-SHELL", config.Healthcheck.Test},
+			Interval:    config.Healthcheck.Interval,
+			Timeout:     config.Healthcheck.Timeout,
+			Retries:     config.Healthcheck.Retries,
+			StartPeriod: config.Healthcheck.StartPeriod,
+		}
+	}
+	if config.Resources != nil {
+		service.Cpus = config.Resources.Cpus
+		service.MemLimit = strings.ToLower(config.Resources.Memory)
+	}
+	return &service, nil
+}
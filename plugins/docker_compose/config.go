@@ -1,9 +1,13 @@
 package docker_compose
 
 import (
+	"bytes"
 	"fmt"
 	"integration_framework/application_config"
 	"integration_framework/plugins"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 )
@@ -13,28 +17,32 @@ type IDockerComposeConfigModifier interface {
 }
 
 type IServiceWithDockerComposeConfigGenerator interface {
-	GenerateDockerComposeConfig(tmpDirectory string, serviceName string, applicationService *DockerComposeService) (dockerComposeServiceName string, dockerComposeService *DockerComposeService, configs *ServiceConfigs, err error)
+	// GenerateDockerComposeConfig should take names, ports, files and volumes of service from allocator.
+	// It is called once, environment, volumes and dependencies added to applicationService are added to every application receiving environment of service
+	GenerateDockerComposeConfig(allocator *Allocator, serviceName string, applicationService *DockerComposeService) (dockerComposeServiceName string, dockerComposeService *DockerComposeService, err error)
 }
 
-func NewDockerComposeConfig(tmpDirectory string, config *application_config.Config, services map[string]plugins.IService) (*DockerComposeConfig, []ServiceConfigs, error) {
+// NewDockerComposeConfig generates docker compose config and returns it with files registered by services in allocator
+func NewDockerComposeConfig(allocator *Allocator, config *application_config.Config, services map[string]plugins.IService) (*DockerComposeConfig, ServicesFiles, error) {
 	dockerComposeConfig := DockerComposeConfig{
-		Version:  "3.4",
-		Services: make(map[string]*DockerComposeService),
-	}
-	applicationService := DockerComposeService{
-		config: config.Application,
-		Build: DockerComposeServiceBuild{
-			Context:    config.Application.Path,
-			Dockerfile: "Dockerfile.prod",
-		},
-		Ports: []string{
-			"8080:8080",
-		},
-		Environment: config.Environment,
-		Restart:     "on-failure",
-		User:        "${UID}:${GID}",
-	}
-	var servicesConfigs []ServiceConfigs
+		Version:       "2.4",
+		Services:      make(map[string]*DockerComposeService),
+		servicesNames: make(map[string]string),
+	}
+	// drop files left from previous failed generation
+	allocator.takeFiles()
+	applicationsServices := make(map[string]*DockerComposeService)
+	for _, applicationName := range config.ApplicationsNames() {
+		err := allocator.ReserveApplicationName(applicationName)
+		if err != nil {
+			return nil, nil, err
+		}
+		applicationService, err := newApplicationService(allocator, applicationName, config.Applications[applicationName], config.Environment)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to generate docker compose service for application %q: %v", applicationName, err)
+		}
+		applicationsServices[applicationName] = applicationService
+	}
 	var servicesNames []string
 	for serviceName := range services {
 		servicesNames = append(servicesNames, serviceName)
@@ -45,55 +53,253 @@ func NewDockerComposeConfig(tmpDirectory string, config *application_config.Conf
 		if !ok {
 			return nil, nil, fmt.Errorf("service %q doesnt support generating docker compose", serviceName)
 		}
-		dockerComposeServiceName, dockerComposeService, serviceConfigs, err := service.GenerateDockerComposeConfig(tmpDirectory, serviceName, &applicationService)
+		receivingApplicationsServices := receivingApplications(config, serviceName, applicationsServices)
+		dockerComposeServiceName, dockerComposeService, err := generateServiceConfig(allocator, serviceName, service, receivingApplicationsServices)
 		if err != nil {
 			return nil, nil, fmt.Errorf("unable to generate docker compose service for external service %q: %v", serviceName, err)
 		}
 		if dockerComposeService != nil {
 			dockerComposeConfig.Services[dockerComposeServiceName] = dockerComposeService
-			if serviceConfigs != nil {
-				servicesConfigs = append(servicesConfigs, *serviceConfigs)
+			dockerComposeConfig.servicesNames[dockerComposeServiceName] = serviceName
+		}
+	}
+	for applicationName, applicationService := range applicationsServices {
+		// wait for healthy state of dependencies which have healthcheck
+		for dependencyName := range applicationService.DependsOn {
+			dependency, ok := dockerComposeConfig.Services[dependencyName]
+			if ok && dependency.Healthcheck != nil {
+				applicationService.DependsOn[dependencyName] = DockerComposeDependency{
+					Condition: "service_healthy",
+				}
 			}
 		}
+		dockerComposeConfig.Services[applicationName] = applicationService
+	}
+	return &dockerComposeConfig, allocator.takeFiles(), nil
+}
+
+// receivingApplications returns services of applications which receive environment of service
+func receivingApplications(config *application_config.Config, serviceName string, applicationsServices map[string]*DockerComposeService) []*DockerComposeService {
+	applicationsNames := config.Services[serviceName].Applications
+	if applicationsNames == nil {
+		applicationsNames = config.ApplicationsNames()
+	}
+	res := make([]*DockerComposeService, len(applicationsNames))
+	for i, applicationName := range applicationsNames {
+		res[i] = applicationsServices[applicationName]
 	}
-	dockerComposeConfig.Services["application"] = &applicationService
-	return &dockerComposeConfig, servicesConfigs, nil
+	return res
+}
+
+// generateServiceConfig generates service once and adds environment, volumes and dependencies of service to every application receiving its environment
+func generateServiceConfig(allocator *Allocator, serviceName string, service IServiceWithDockerComposeConfigGenerator, applicationsServices []*DockerComposeService) (dockerComposeServiceName string, dockerComposeService *DockerComposeService, err error) {
+	// environment of service is generated even if it is not used by applications
+	generatedApplicationService := &DockerComposeService{
+		config:      &application_config.ApplicationConfig{},
+		Environment: make(map[string]string),
+	}
+	dockerComposeServiceName, dockerComposeService, err = service.GenerateDockerComposeConfig(allocator, serviceName, generatedApplicationService)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, applicationService := range applicationsServices {
+		applicationService.attach(generatedApplicationService)
+	}
+	return dockerComposeServiceName, dockerComposeService, nil
 }
 
 type DockerComposeServiceBuild struct {
-	Context    string `yaml:"context"`
-	Dockerfile string `yaml:"dockerfile,omitempty"`
+	Context    string            `yaml:"context,omitempty"`
+	Dockerfile string            `yaml:"dockerfile,omitempty"`
+	Args       map[string]string `yaml:"args,omitempty"`
+	Target     string            `yaml:"target,omitempty"`
 }
 
 type DockerComposeService struct {
 	config      *application_config.ApplicationConfig
-	Image       string                    `yaml:"image,omitempty"`
-	Build       DockerComposeServiceBuild `yaml:"build,omitempty"`
-	Restart     string                    `yaml:"restart,omitempty"`
-	Volumes     []string                  `yaml:"volumes,omitempty"`
-	Environment map[string]string         `yaml:"environment,omitempty"`
-	Ports       []string                  `yaml:"ports,omitempty"`
-	DependsOn   []string                  `yaml:"depends_on,omitempty"`
-	Command     string                    `yaml:"command,omitempty"`
-	WorkingDir  string                    `yaml:"working_dir,omitempty"`
-	User        string                    `yaml:"user,omitempty"`
-	TmpFs       []string                  `yaml:"tmpfs,omitempty"`
-	waitFor     []string
+	Image       string                             `yaml:"image,omitempty"`
+	Build       DockerComposeServiceBuild          `yaml:"build,omitempty"`
+	Restart     string                             `yaml:"restart,omitempty"`
+	Volumes     []string                           `yaml:"volumes,omitempty"`
+	Environment map[string]string                  `yaml:"environment,omitempty"`
+	Ports       []string                           `yaml:"ports,omitempty"`
+	DependsOn   map[string]DockerComposeDependency `yaml:"depends_on,omitempty"`
+	Healthcheck *DockerComposeHealthcheck          `yaml:"healthcheck,omitempty"`
+	Command     string                             `yaml:"command,omitempty"`
+	Entrypoint  string                             `yaml:"entrypoint,omitempty"`
+	EnvFile     []string                           `yaml:"env_file,omitempty"`
+	Cpus        float64                            `yaml:"cpus,omitempty"`
+	MemLimit    string                             `yaml:"mem_limit,omitempty"`
+	WorkingDir  string                             `yaml:"working_dir,omitempty"`
+	User        string                             `yaml:"user,omitempty"`
+	TmpFs       []string                           `yaml:"tmpfs,omitempty"`
+	Networks    map[string]DockerComposeNetwork    `yaml:"networks,omitempty"`
+	// args of dockerize for dependencies, they are used if application is dockerized
+	waitFor []string
+	// variables which are set only if they are not defined in config of application
+	defaultEnvironment map[string]string
+	// command building image which is not published to registry, such image is not pulled if it is missing
+	localImageBuildCommand string
+}
+
+// defaultNetwork is network created by docker compose for project
+const defaultNetwork = "default"
+
+type DockerComposeNetwork struct {
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+type DockerComposeDependency struct {
+	Condition string `yaml:"condition"`
+}
+
+type DockerComposeHealthcheck struct {
+	Test        []string `yaml:"test"`
+	Interval    string   `yaml:"interval,omitempty"`
+	Timeout     string   `yaml:"timeout,omitempty"`
+	Retries     int      `yaml:"retries,omitempty"`
+	StartPeriod string   `yaml:"start_period,omitempty"`
+}
+
+// ServiceName returns name of service in config of framework by name of docker compose service
+func (c *DockerComposeConfig) ServiceName(dockerComposeServiceName string) string {
+	return c.servicesNames[dockerComposeServiceName]
 }
 
 type DockerComposeConfig struct {
 	Version  string                           `yaml:"version"`
 	Services map[string]*DockerComposeService `yaml:"services"`
+	// names of services in config of framework by names of docker compose services
+	servicesNames map[string]string
 }
 
-type ServiceConfigs struct {
-	Files map[string][]byte
-}
+// ServicesFiles are files registered by services in allocator: name of docker compose service -> path -> contents
+type ServicesFiles map[string]map[string][]byte
 
 func (s *DockerComposeService) AddDependency(serviceName string, protocol string, port int) {
-	s.DependsOn = append(s.DependsOn, serviceName)
+	if s.DependsOn == nil {
+		s.DependsOn = make(map[string]DockerComposeDependency)
+	}
+	s.DependsOn[serviceName] = DockerComposeDependency{
+		Condition: "service_started",
+	}
+	s.addWaitFor(fmt.Sprintf("-wait %s://%s:%d -timeout 60s", protocol, serviceName, port))
+}
+
+func (s *DockerComposeService) addWaitFor(waitFor ...string) {
+	s.waitFor = append(s.waitFor, waitFor...)
 	if s.config.Dockerize {
-		s.waitFor = append(s.waitFor, fmt.Sprintf("-wait %s://%s:%d -timeout 60s", protocol, serviceName, port))
 		s.Environment["DOCKERIZE_ARGS"] = strings.Join(s.waitFor, " ")
 	}
 }
+
+// SetDefaultEnvironment sets variable if it is not defined in config of application
+func (s *DockerComposeService) SetDefaultEnvironment(name string, value string) {
+	if s.defaultEnvironment == nil {
+		s.defaultEnvironment = make(map[string]string)
+	}
+	s.defaultEnvironment[name] = value
+	if _, ok := s.Environment[name]; !ok {
+		s.Environment[name] = value
+	}
+}
+
+// SetLocalImage marks image of service as built by command from repository of framework, so missing image is reported instead of pulled
+func (s *DockerComposeService) SetLocalImage(buildCommand string) {
+	s.localImageBuildCommand = buildCommand
+}
+
+// MissingLocalImageError returns error of missing image built by command from repository of framework or nil if image can be pulled
+func (s *DockerComposeService) MissingLocalImageError() error {
+	if s.localImageBuildCommand == "" {
+		return nil
+	}
+	return fmt.Errorf("image %q missing, run `%s` in repository of integration_framework", s.Image, s.localImageBuildCommand)
+}
+
+// attach adds environment, volumes and dependencies generated by service to application
+func (s *DockerComposeService) attach(generated *DockerComposeService) {
+	for name, value := range generated.Environment {
+		if _, ok := generated.defaultEnvironment[name]; !ok {
+			s.Environment[name] = value
+		}
+	}
+	for name, value := range generated.defaultEnvironment {
+		if _, ok := s.Environment[name]; !ok {
+			s.Environment[name] = value
+		}
+	}
+	for _, volume := range generated.Volumes {
+		s.AddVolume(volume)
+	}
+	for dependencyName, dependency := range generated.DependsOn {
+		if s.DependsOn == nil {
+			s.DependsOn = make(map[string]DockerComposeDependency)
+		}
+		s.DependsOn[dependencyName] = dependency
+	}
+	s.addWaitFor(generated.waitFor...)
+}
+
+// AddNetworkAliases adds host names which are resolved to service in default network
+func (s *DockerComposeService) AddNetworkAliases(aliases ...string) {
+	if s.Networks == nil {
+		s.Networks = make(map[string]DockerComposeNetwork)
+	}
+	network := s.Networks[defaultNetwork]
+	network.Aliases = append(network.Aliases, aliases...)
+	s.Networks[defaultNetwork] = network
+}
+
+// NetworkAliases returns host names of service in default network except of name of service
+func (s *DockerComposeService) NetworkAliases() []string {
+	return s.Networks[defaultNetwork].Aliases
+}
+
+// AddVolume adds volume like `host_path:container_path:ro`. Volume mounted to the same path in container is replaced
+func (s *DockerComposeService) AddVolume(volume string) {
+	containerPath := volumeContainerPath(volume)
+	for i, existingVolume := range s.Volumes {
+		if volumeContainerPath(existingVolume) == containerPath {
+			s.Volumes[i] = volume
+			return
+		}
+	}
+	s.Volumes = append(s.Volumes, volume)
+}
+
+func volumeContainerPath(volume string) string {
+	parts := strings.Split(volume, ":")
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+// WriteChangedFiles writes files registered in allocator if they differ from already writed ones.
+// It returns names of docker compose services which files changed
+func WriteChangedFiles(lastWritedFiles map[string][]byte, files ServicesFiles) (changedServices []string, err error) {
+	for dockerComposeServiceName, serviceFiles := range files {
+		changed := false
+		for path, fileContents := range serviceFiles {
+			if _, ok := lastWritedFiles[path]; ok && bytes.Equal(lastWritedFiles[path], fileContents) {
+				continue
+			}
+			changed = true
+			err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create directory for config of service: %v", err)
+			}
+			err = ioutil.WriteFile(path, fileContents, os.ModePerm)
+			if err != nil {
+				return nil, fmt.Errorf("unable to write config of service: %v", err)
+			}
+			lastWritedFiles[path] = fileContents
+		}
+		if changed {
+			changedServices = append(changedServices, dockerComposeServiceName)
+		}
+	}
+	sort.Strings(changedServices)
+	return changedServices, nil
+}
@@ -0,0 +1,58 @@
+package docker_compose
+
+import (
+	"integration_framework/application_config"
+	"reflect"
+	"testing"
+)
+
+type testGenerator struct {
+	calls int
+}
+
+func (g *testGenerator) GenerateDockerComposeConfig(allocator *Allocator, serviceName string, applicationService *DockerComposeService) (string, *DockerComposeService, error) {
+	g.calls++
+	applicationService.Environment["DB_URL"] = "postgres://db"
+	applicationService.SetDefaultEnvironment("SSL_CERT_FILE", "/bundle.crt")
+	applicationService.AddVolume("/tmp/ca.crt:/ca.crt:ro")
+	applicationService.AddDependency("db", "tcp", 5432)
+	return "db", &DockerComposeService{Image: "postgres"}, nil
+}
+
+func TestGenerateServiceConfig(t *testing.T) {
+	first := &DockerComposeService{
+		config:      &application_config.ApplicationConfig{},
+		Environment: map[string]string{"SSL_CERT_FILE": "/own.crt"},
+	}
+	second := &DockerComposeService{
+		config:      &application_config.ApplicationConfig{Dockerize: true},
+		Environment: map[string]string{"DB_URL": "overridden"},
+		Volumes:     []string{"/tmp/other.crt:/ca.crt:ro"},
+	}
+	generator := &testGenerator{}
+	dockerComposeServiceName, dockerComposeService, err := generateServiceConfig(NewAllocator(t.Name()), "db", generator, []*DockerComposeService{first, second})
+	if err != nil {
+		t.Fatalf("unable to generate service: %v", err)
+	}
+	if generator.calls != 1 {
+		t.Errorf("expected service is generated once, but it is generated %d times", generator.calls)
+	}
+	if dockerComposeServiceName != "db" || dockerComposeService.Image != "postgres" {
+		t.Errorf("unexpected service %q: %#v", dockerComposeServiceName, dockerComposeService)
+	}
+	expectedEnvironments := []map[string]string{
+		{"DB_URL": "postgres://db", "SSL_CERT_FILE": "/own.crt"},
+		{"DB_URL": "postgres://db", "SSL_CERT_FILE": "/bundle.crt", "DOCKERIZE_ARGS": "-wait tcp://db:5432 -timeout 60s"},
+	}
+	for i, applicationService := range []*DockerComposeService{first, second} {
+		if !reflect.DeepEqual(applicationService.Environment, expectedEnvironments[i]) {
+			t.Errorf("application #%d: expected environment %v, got %v", i, expectedEnvironments[i], applicationService.Environment)
+		}
+		if !reflect.DeepEqual(applicationService.Volumes, []string{"/tmp/ca.crt:/ca.crt:ro"}) {
+			t.Errorf("application #%d: unexpected volumes %v", i, applicationService.Volumes)
+		}
+		if _, ok := applicationService.DependsOn["db"]; !ok {
+			t.Errorf("application #%d: expected dependency on service, got %v", i, applicationService.DependsOn)
+		}
+	}
+}
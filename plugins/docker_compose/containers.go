@@ -0,0 +1,119 @@
+package docker_compose
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"integration_framework/plugins"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type ContainerState struct {
+	Status     string `json:"Status"`
+	Restarting bool   `json:"Restarting"`
+	OOMKilled  bool   `json:"OOMKilled"`
+	ExitCode   int    `json:"ExitCode"`
+	Error      string `json:"Error"`
+}
+
+// ServiceContainerId returns id of container of service. It is empty if container is not created
+func ServiceContainerId(projectName string, pathToConfig string, serviceName string) (string, error) {
+	cmd, err := dockerComposeCommand(projectName, pathToConfig, "ps", "-q", serviceName)
+	if err != nil {
+		return "", err
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unable to get container of service %q: %v", serviceName, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ServiceContainerState returns state of container of service
+func ServiceContainerState(projectName string, pathToConfig string, serviceName string) (*ContainerState, error) {
+	containerId, err := ServiceContainerId(projectName, pathToConfig, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if containerId == "" {
+		return nil, fmt.Errorf("container of service %q not found", serviceName)
+	}
+	output, err := exec.Command("docker", "inspect", "--format", "{{json .State}}", containerId).Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to inspect container of service %q: %v", serviceName, err)
+	}
+	var state ContainerState
+	err = json.Unmarshal(output, &state)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal state of container of service %q: %v", serviceName, err)
+	}
+	return &state, nil
+}
+
+// ServiceAliveProbe fails fatally if container of service exited or is restarted by restart policy. Error contains last lines of logs of service
+func ServiceAliveProbe(projectName string, pathToConfig string, serviceName string, logs *plugins.RunLogs) plugins.IProbe {
+	return plugins.ProbeFunc(func(_ context.Context) error {
+		state, err := ServiceContainerState(projectName, pathToConfig, serviceName)
+		if err != nil {
+			return err
+		}
+		if state.Status != "exited" && state.Status != "dead" && !state.Restarting {
+			return nil
+		}
+		message := fmt.Sprintf("container of service %q is %s with exit code %d", serviceName, state.Status, state.ExitCode)
+		if state.OOMKilled {
+			message += " (killed by OOM)"
+		}
+		if state.Error != "" {
+			message += ": " + state.Error
+		}
+		return plugins.FatalProbeError{Err: logs.WithTail(serviceName, fmt.Errorf("%s", message))}
+	})
+}
+
+// FollowServicesLogs writes logs of containers of services to logs of run
+func FollowServicesLogs(projectName string, pathToConfig string, servicesNames []string, logs *plugins.RunLogs) ([]*exec.Cmd, error) {
+	var logsCmds []*exec.Cmd
+	for _, serviceName := range servicesNames {
+		logsWriter, err := logs.Writer(serviceName)
+		if err != nil {
+			return logsCmds, err
+		}
+		logsCmd, err := FollowServiceLogs(projectName, pathToConfig, serviceName, logsWriter)
+		if err != nil {
+			return logsCmds, fmt.Errorf("unable to follow logs of service %q: %v", serviceName, err)
+		}
+		logsCmds = append(logsCmds, logsCmd)
+	}
+	return logsCmds, nil
+}
+
+// FollowServiceLogs writes logs of container of service to w until container is removed or cmd is interrupted
+func FollowServiceLogs(projectName string, pathToConfig string, serviceName string, w io.Writer) (*exec.Cmd, error) {
+	containerId, err := ServiceContainerId(projectName, pathToConfig, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	if containerId == "" {
+		return nil, fmt.Errorf("container of service %q not found", serviceName)
+	}
+	cmd := exec.Command("docker", "logs", "--follow", containerId)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err = cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("unable to start cmd: %v", err)
+	}
+	return cmd, nil
+}
+
+// StopFollowingLogs interrupts cmds started by FollowServiceLogs. Cmds of removed containers are already exited
+func StopFollowingLogs(logsCmds []*exec.Cmd) {
+	for _, logsCmd := range logsCmds {
+		_ = logsCmd.Process.Signal(os.Interrupt)
+		_ = logsCmd.Wait()
+	}
+}
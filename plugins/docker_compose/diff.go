@@ -0,0 +1,151 @@
+package docker_compose
+
+import (
+	"bytes"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"integration_framework/plugins"
+	"sort"
+)
+
+// IServiceWithHotReload is implemented by services which can apply changed files without recreating container
+type IServiceWithHotReload interface {
+	HotReload() error
+}
+
+// ConfigDiff describes how to get from launched config to new one
+type ConfigDiff struct {
+	// docker compose services which should be created or recreated
+	Recreate []string
+	// docker compose services which are not defined anymore
+	Remove []string
+	// docker compose services which changed files can be applied without recreating, they are reloaded by HotReload
+	HotReload []string
+}
+
+func (d ConfigDiff) IsEmpty() bool {
+	return len(d.Recreate) == 0 && len(d.Remove) == 0 && len(d.HotReload) == 0
+}
+
+// DiffConfigs compares definitions of docker compose services. If only files of service changed and it supports hot reload then it is candidate for hot reload.
+// Services which depend on recreated services are recreated too, because they are not touched by partial update otherwise.
+// If previous config is nil then all services should be created
+func DiffConfigs(previous *DockerComposeConfig, current *DockerComposeConfig, services map[string]plugins.IService, filesChangedServices []string) (ConfigDiff, error) {
+	var diff ConfigDiff
+	filesChanged := make(map[string]bool, len(filesChangedServices))
+	for _, dockerComposeServiceName := range filesChangedServices {
+		filesChanged[dockerComposeServiceName] = true
+	}
+	for _, dockerComposeServiceName := range sortedServicesNames(current) {
+		if previous == nil {
+			diff.Recreate = append(diff.Recreate, dockerComposeServiceName)
+			continue
+		}
+		previousService, ok := previous.Services[dockerComposeServiceName]
+		if !ok {
+			diff.Recreate = append(diff.Recreate, dockerComposeServiceName)
+			continue
+		}
+		equal, err := isEqualServices(previousService, current.Services[dockerComposeServiceName])
+		if err != nil {
+			return ConfigDiff{}, fmt.Errorf("unable to compare docker compose service %q: %v", dockerComposeServiceName, err)
+		}
+		if !equal {
+			diff.Recreate = append(diff.Recreate, dockerComposeServiceName)
+			continue
+		}
+		if !filesChanged[dockerComposeServiceName] {
+			continue
+		}
+		if _, ok := services[current.servicesNames[dockerComposeServiceName]].(IServiceWithHotReload); ok {
+			diff.HotReload = append(diff.HotReload, dockerComposeServiceName)
+			continue
+		}
+		diff.Recreate = append(diff.Recreate, dockerComposeServiceName)
+	}
+	diff.recreateDependents(current)
+	if previous != nil {
+		for _, dockerComposeServiceName := range sortedServicesNames(previous) {
+			if _, ok := current.Services[dockerComposeServiceName]; !ok {
+				diff.Remove = append(diff.Remove, dockerComposeServiceName)
+			}
+		}
+	}
+	return diff, nil
+}
+
+// HotReloadServices reloads candidates for hot reload, it should be called after changed files are written.
+// Services which failed to reload are recreated along with their dependents
+func (d *ConfigDiff) HotReloadServices(current *DockerComposeConfig, services map[string]plugins.IService) {
+	var hotReloaded []string
+	for _, dockerComposeServiceName := range d.HotReload {
+		service := services[current.servicesNames[dockerComposeServiceName]].(IServiceWithHotReload)
+		err := service.HotReload()
+		if err != nil {
+			fmt.Printf("unable to hot reload service %q, it will be recreated: %v\n", dockerComposeServiceName, err)
+			d.Recreate = append(d.Recreate, dockerComposeServiceName)
+			continue
+		}
+		fmt.Printf("---> service %q reloaded\n", dockerComposeServiceName)
+		hotReloaded = append(hotReloaded, dockerComposeServiceName)
+	}
+	d.HotReload = hotReloaded
+	d.recreateDependents(current)
+}
+
+// recreateDependents adds services which depend on recreated services (directly or transitively) to recreated ones
+func (d *ConfigDiff) recreateDependents(current *DockerComposeConfig) {
+	recreate := make(map[string]bool, len(d.Recreate))
+	for _, dockerComposeServiceName := range d.Recreate {
+		recreate[dockerComposeServiceName] = true
+	}
+	for added := true; added; {
+		added = false
+		for _, dockerComposeServiceName := range sortedServicesNames(current) {
+			if recreate[dockerComposeServiceName] {
+				continue
+			}
+			for dependencyName := range current.Services[dockerComposeServiceName].DependsOn {
+				if recreate[dependencyName] {
+					recreate[dockerComposeServiceName] = true
+					added = true
+					break
+				}
+			}
+		}
+	}
+	d.Recreate = nil
+	var hotReload []string
+	for _, dockerComposeServiceName := range sortedServicesNames(current) {
+		if recreate[dockerComposeServiceName] {
+			d.Recreate = append(d.Recreate, dockerComposeServiceName)
+		}
+	}
+	for _, dockerComposeServiceName := range d.HotReload {
+		if !recreate[dockerComposeServiceName] {
+			hotReload = append(hotReload, dockerComposeServiceName)
+		}
+	}
+	d.HotReload = hotReload
+}
+
+func isEqualServices(a *DockerComposeService, b *DockerComposeService) (bool, error) {
+	aBytes, err := yaml.Marshal(a)
+	if err != nil {
+		return false, err
+	}
+	bBytes, err := yaml.Marshal(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aBytes, bBytes), nil
+}
+
+func sortedServicesNames(config *DockerComposeConfig) []string {
+	var res []string
+	for dockerComposeServiceName := range config.Services {
+		res = append(res, dockerComposeServiceName)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,126 @@
+package docker_compose
+
+import (
+	"fmt"
+	"integration_framework/plugins"
+	"reflect"
+	"testing"
+)
+
+type testService struct {
+	plugins.IService
+}
+
+type testHotReloadService struct {
+	plugins.IService
+	err      error
+	reloaded int
+}
+
+func (s *testHotReloadService) HotReload() error {
+	s.reloaded++
+	return s.err
+}
+
+// testConfig creates config where `app` depends on `db` and `mock`, and `worker` depends on `app`
+func testConfig(image string) *DockerComposeConfig {
+	return &DockerComposeConfig{
+		Services: map[string]*DockerComposeService{
+			"db":     {Image: image},
+			"mock":   {Image: "mock"},
+			"app":    {Image: "app", DependsOn: map[string]DockerComposeDependency{"db": {}, "mock": {}}},
+			"worker": {Image: "worker", DependsOn: map[string]DockerComposeDependency{"app": {}}},
+		},
+		servicesNames: map[string]string{"db": "db", "mock": "mock", "app": "app", "worker": "worker"},
+	}
+}
+
+func TestDiffConfigs(t *testing.T) {
+	tests := []struct {
+		name         string
+		previous     *DockerComposeConfig
+		filesChanged []string
+		expected     ConfigDiff
+	}{
+		{
+			name:     "first launch",
+			previous: nil,
+			expected: ConfigDiff{Recreate: []string{"app", "db", "mock", "worker"}},
+		},
+		{
+			name:     "nothing changed",
+			previous: testConfig("postgres"),
+			expected: ConfigDiff{},
+		},
+		{
+			name:     "dependency changed",
+			previous: testConfig("mysql"),
+			expected: ConfigDiff{Recreate: []string{"app", "db", "worker"}},
+		},
+		{
+			name:         "files of service with hot reload changed",
+			previous:     testConfig("postgres"),
+			filesChanged: []string{"mock"},
+			expected:     ConfigDiff{HotReload: []string{"mock"}},
+		},
+		{
+			name:         "files of service without hot reload changed",
+			previous:     testConfig("postgres"),
+			filesChanged: []string{"db", "mock"},
+			expected:     ConfigDiff{Recreate: []string{"app", "db", "worker"}, HotReload: []string{"mock"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			services := map[string]plugins.IService{
+				"db":     testService{},
+				"mock":   &testHotReloadService{},
+				"app":    testService{},
+				"worker": testService{},
+			}
+			diff, err := DiffConfigs(test.previous, testConfig("postgres"), services, test.filesChanged)
+			if err != nil {
+				t.Fatalf("unable to diff configs: %v", err)
+			}
+			if !reflect.DeepEqual(diff, test.expected) {
+				t.Errorf("expected diff %#v, got %#v", test.expected, diff)
+			}
+			if services["mock"].(*testHotReloadService).reloaded != 0 {
+				t.Errorf("expected service is not reloaded by diff")
+			}
+		})
+	}
+}
+
+func TestHotReloadServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected ConfigDiff
+	}{
+		{
+			name:     "reloaded",
+			expected: ConfigDiff{HotReload: []string{"mock"}},
+		},
+		{
+			name:     "failed to reload",
+			err:      fmt.Errorf("mock is not reachable"),
+			expected: ConfigDiff{Recreate: []string{"app", "mock", "worker"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := &testHotReloadService{err: test.err}
+			services := map[string]plugins.IService{"mock": mock}
+			config := testConfig("postgres")
+			diff := ConfigDiff{HotReload: []string{"mock"}}
+			diff.HotReloadServices(config, services)
+			if mock.reloaded != 1 {
+				t.Errorf("expected service is reloaded once, but it is reloaded %d times", mock.reloaded)
+			}
+			if !reflect.DeepEqual(diff, test.expected) {
+				t.Errorf("expected diff %#v, got %#v", test.expected, diff)
+			}
+		})
+	}
+}
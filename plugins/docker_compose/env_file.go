@@ -0,0 +1,52 @@
+package docker_compose
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadEnvFiles reads files in format of docker compose `env_file`: `NAME=value` lines, empty lines and comments started with `#`.
+// Line with only name takes value from environment of current process. Values of later files override values of earlier ones
+func ReadEnvFiles(paths []string) (map[string]string, error) {
+	res := make(map[string]string)
+	for _, path := range paths {
+		err := readEnvFile(path, res)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read env file %q: %v", path, err)
+		}
+	}
+	return res, nil
+}
+
+func readEnvFile(path string, res map[string]string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		nameAndValue := strings.SplitN(line, "=", 2)
+		name := strings.TrimSpace(nameAndValue[0])
+		if name == "" {
+			return fmt.Errorf("line %d: variable name not specified", lineNumber)
+		}
+		if len(nameAndValue) == 1 {
+			value, ok := os.LookupEnv(name)
+			if ok {
+				res[name] = value
+			}
+			continue
+		}
+		res[name] = nameAndValue[1]
+	}
+	return scanner.Err()
+}
@@ -6,21 +6,67 @@ import (
 	"os/exec"
 	"os/user"
 	"path"
+	"sort"
+	"strings"
 )
 
-func launchApplication(tmpDirectory string) (*exec.Cmd, error) {
-	cmd := exec.Command("docker-compose", []string{
-		"--file", path.Join(tmpDirectory, "docker-compose.generated-test.yml"),
-		"up", "--build",
-	}...)
-
-	// https://github.com/moby/moby/issues/3206#issuecomment-152682860
-	currentUser, err := user.Current()
+// upServices creates or recreates specified services in background and removes services not defined in config anymore
+func upServices(tmpDirectory string, projectName string, servicesNames []string, partial bool) error {
+	cmd, err := RunDockerCompose(projectName, path.Join(tmpDirectory, "docker-compose.generated-test.yml"), UpServicesArgs(servicesNames, partial)...)
 	if err != nil {
-		return nil, fmt.Errorf("unable to get current user info: %v", err)
+		return err
 	}
-	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", "UID", currentUser.Uid), fmt.Sprintf("%s=%s", "GID", currentUser.Gid))
+	return cmd.Wait()
+}
+
+// UpServicesArgs returns args of docker-compose to recreate specified services and remove orphans.
+// If update is partial then other services are not touched even if specified services depend on them
+func UpServicesArgs(servicesNames []string, partial bool) []string {
+	args := []string{"up", "--detach", "--remove-orphans"}
+	if partial {
+		args = append(args, "--no-deps")
+	}
+	if len(servicesNames) == 0 {
+		return args
+	}
+	args = append(args, "--build", "--force-recreate")
+	return append(args, servicesNames...)
+}
+
+// CheckLocalImages returns error if image built from repository of framework is missing, docker-compose would try to pull it otherwise
+func CheckLocalImages(dockerComposeConfig *DockerComposeConfig) error {
+	var servicesNames []string
+	for serviceName := range dockerComposeConfig.Services {
+		servicesNames = append(servicesNames, serviceName)
+	}
+	sort.Strings(servicesNames)
+	for _, serviceName := range servicesNames {
+		service := dockerComposeConfig.Services[serviceName]
+		if service.MissingLocalImageError() == nil {
+			continue
+		}
+		output, err := exec.Command("docker", "image", "inspect", "--format", "{{.Id}}", service.Image).CombinedOutput()
+		if err == nil {
+			continue
+		}
+		if strings.Contains(strings.ToLower(string(output)), "no such image") {
+			return fmt.Errorf("service %q: %v", serviceName, service.MissingLocalImageError())
+		}
+		return fmt.Errorf("unable to inspect image %q of service %q: %v: %s", service.Image, serviceName, err, output)
+	}
+	return nil
+}
+
+func shutdownApplication(tmpDirectory string, projectName string) (*exec.Cmd, error) {
+	return RunDockerCompose(projectName, path.Join(tmpDirectory, "docker-compose.generated-test.yml"), "down")
+}
 
+// RunDockerCompose starts docker-compose with specified project name, config file and args. Output of docker-compose is piped to stdout
+func RunDockerCompose(projectName string, pathToConfig string, args ...string) (*exec.Cmd, error) {
+	cmd, err := dockerComposeCommand(projectName, pathToConfig, args...)
+	if err != nil {
+		return nil, err
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err = cmd.Start()
@@ -31,11 +77,11 @@ func launchApplication(tmpDirectory string) (*exec.Cmd, error) {
 	return cmd, nil
 }
 
-func shutdownApplication(tmpDirectory string) (*exec.Cmd, error) {
-	cmd := exec.Command("docker-compose", []string{
-		"--file", path.Join(tmpDirectory, "docker-compose.generated-test.yml"),
-		"down",
-	}...)
+func dockerComposeCommand(projectName string, pathToConfig string, args ...string) (*exec.Cmd, error) {
+	cmd := exec.Command("docker-compose", append([]string{
+		"--project-name", projectName,
+		"--file", pathToConfig,
+	}, args...)...)
 
 	// https://github.com/moby/moby/issues/3206#issuecomment-152682860
 	currentUser, err := user.Current()
@@ -43,13 +89,5 @@ func shutdownApplication(tmpDirectory string) (*exec.Cmd, error) {
 		return nil, fmt.Errorf("unable to get current user info: %v", err)
 	}
 	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", "UID", currentUser.Uid), fmt.Sprintf("%s=%s", "GID", currentUser.Gid))
-
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err = cmd.Start()
-	if err != nil {
-		return nil, fmt.Errorf("unable to start cmd: %v", err)
-	}
-
 	return cmd, nil
 }
@@ -5,106 +5,106 @@ import (
 	"fmt"
 	"gopkg.in/yaml.v2"
 	"integration_framework/application_config"
-	"integration_framework/helper"
 	"integration_framework/plugins"
-	"integration_framework/testing"
 	"io/ioutil"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"time"
 )
 
-func NewLauncher(tmpDirectory string) *Launcher {
+func NewLauncher(tmpDirectory string, logs *plugins.RunLogs) *Launcher {
 	return &Launcher{
 		tmpDirectory: tmpDirectory,
+		logs:         logs,
+		allocator:    NewAllocator(tmpDirectory),
+		projectName:  NewProjectName(),
 	}
 }
 
 type Launcher struct {
-	tmpDirectory                     string
-	lastGeneratedDockerComposeConfig []byte
-	lastWritedFiles                  map[string][]byte
-	cmd                              *exec.Cmd
-	shutdownRequested                bool
-	cancelContext                    func()
+	tmpDirectory     string
+	logs             *plugins.RunLogs
+	allocator        *Allocator
+	projectName      string
+	applicationsUrls map[string]string
+	// config of running services. it is nil if services are not launched or launch failed
+	launchedConfig  *DockerComposeConfig
+	lastWritedFiles map[string][]byte
+	// docker-compose was started, so project should be shut down
+	launched          bool
+	logsCmds          []*exec.Cmd
+	shutdownRequested bool
+	cancelContext     func()
 }
 
-func (l *Launcher) createConfig(config *application_config.Config, services map[string]plugins.IService) (configChanged bool, err error) {
-	dockerComposeConfig, servicesConfigs, err := NewDockerComposeConfig(l.tmpDirectory, config, services)
+func (l *Launcher) createConfig(config *application_config.Config, services map[string]plugins.IService) (*DockerComposeConfig, ConfigDiff, error) {
+	dockerComposeConfig, files, err := NewDockerComposeConfig(l.allocator, config, services)
 	if err != nil {
-		return false, fmt.Errorf("unable to generate docker-compose config: %v", err)
+		return nil, ConfigDiff{}, fmt.Errorf("unable to generate docker-compose config: %v", err)
 	}
-	dockerComposeBytes, err := yaml.Marshal(dockerComposeConfig)
+	if l.lastWritedFiles == nil {
+		l.lastWritedFiles = make(map[string][]byte)
+	}
+	filesChangedServices, err := WriteChangedFiles(l.lastWritedFiles, files)
 	if err != nil {
-		return false, fmt.Errorf("unable to marshal docker compose config: %v", err)
+		return nil, ConfigDiff{}, fmt.Errorf("unable to write services configs: %v", err)
 	}
-
-	dockerComposeConfigUpdated := true
-	if l.lastGeneratedDockerComposeConfig != nil {
-		dockerComposeConfigUpdated, err = testing.IsEqualYamlMaps(l.lastGeneratedDockerComposeConfig, dockerComposeBytes)
-		if err != nil {
-			return false, fmt.Errorf("unable to check docker compose config equality: %v", err)
-		}
+	diff, err := DiffConfigs(l.launchedConfig, dockerComposeConfig, services, filesChangedServices)
+	if err != nil {
+		return nil, ConfigDiff{}, fmt.Errorf("unable to compare docker compose configs: %v", err)
 	}
-	if dockerComposeConfigUpdated {
-		configChanged = true
-		l.lastGeneratedDockerComposeConfig = dockerComposeBytes
-		err = ioutil.WriteFile(l.tmpDirectory+"/docker-compose.generated-test.yml", dockerComposeBytes, os.ModePerm)
+	// changed files are already written, so services can reload them
+	diff.HotReloadServices(dockerComposeConfig, services)
+	if len(diff.Recreate) != 0 || len(diff.Remove) != 0 {
+		dockerComposeBytes, err := yaml.Marshal(dockerComposeConfig)
 		if err != nil {
-			return false, fmt.Errorf("unable to write docker compose config: %v", err)
+			return nil, ConfigDiff{}, fmt.Errorf("unable to marshal docker compose config: %v", err)
 		}
-	}
-
-	if l.lastWritedFiles == nil {
-		l.lastWritedFiles = make(map[string][]byte)
-	}
-	for _, serviceConfigs := range servicesConfigs {
-		for fileName, fileContents := range serviceConfigs.Files {
-			alreadyWrited := l.lastWritedFiles[fileName]
-			err = testing.IsEqual(alreadyWrited, fileContents)
-			if err != nil {
-				configChanged = true
-				l.lastWritedFiles[fileName] = fileContents
-				err = ioutil.WriteFile(filepath.Join(l.tmpDirectory, fileName), fileContents, os.ModePerm)
-				if err != nil {
-					return false, fmt.Errorf("unable to write docker compose config: %v", err)
-				}
-			}
+		err = ioutil.WriteFile(l.pathToConfig(), dockerComposeBytes, os.ModePerm)
+		if err != nil {
+			return nil, ConfigDiff{}, fmt.Errorf("unable to write docker compose config: %v", err)
 		}
 	}
-	return
+	return dockerComposeConfig, diff, nil
 }
 
-func (l *Launcher) launchApplication(services map[string]plugins.IService) error {
+// launchApplication creates or recreates changed services and waits for readiness of services and applications
+func (l *Launcher) launchApplication(config *application_config.Config, services map[string]plugins.IService, diff ConfigDiff) error {
 	fmt.Println("--------------------> launch app")
-	cmd, err := launchApplication(l.tmpDirectory)
+	l.launched = true
+	err := upServices(l.tmpDirectory, l.projectName, diff.Recreate, l.launchedConfig != nil)
 	if err != nil {
 		return fmt.Errorf("unable to launch docker compose: %v", err)
 	}
-	l.cmd = cmd
-	fmt.Println("---> launched")
-
-	for {
-		portAvailable := helper.IsHttpPortAvailable("localhost", 8080)
-		if portAvailable {
-			break
-		}
-		if l.shutdownRequested {
-			break
-		}
-		time.Sleep(5 * time.Second)
+	// logs of recreated containers are not followed by already running cmds
+	logsCmds, err := FollowServicesLogs(l.projectName, l.pathToConfig(), diff.Recreate, l.logs)
+	l.logsCmds = append(l.logsCmds, logsCmds...)
+	if err != nil {
+		return err
 	}
+	fmt.Println("---> launched")
 
 	ctx, cancel := context.WithCancel(context.Background())
 	l.cancelContext = cancel
-	for _, service := range services {
+	err = plugins.WaitForServicesReady(ctx, config, services)
+	if err != nil {
 		if l.shutdownRequested {
 			return nil
 		}
-		err := service.WaitForPortAvailable(ctx)
+		return err
+	}
+	l.applicationsUrls = make(map[string]string)
+	for _, applicationName := range config.ApplicationsNames() {
+		applicationPort, err := ApplicationHostPort(l.allocator, applicationName, config.Applications[applicationName])
 		if err != nil {
-			return fmt.Errorf("wait for port error: %v", err)
+			return fmt.Errorf("unable to get port of application %q: %v", applicationName, err)
+		}
+		l.applicationsUrls[applicationName] = fmt.Sprintf("http://localhost:%d", applicationPort)
+		err = plugins.WaitForApplicationReady(ctx, config, applicationName, fmt.Sprintf("localhost:%d", applicationPort), ServiceAliveProbe(l.projectName, l.pathToConfig(), applicationName, l.logs))
+		if err != nil {
+			if l.shutdownRequested {
+				return nil
+			}
+			return err
 		}
 	}
 	fmt.Println("---> return from launch application")
@@ -112,22 +112,28 @@ func (l *Launcher) launchApplication(services map[string]plugins.IService) error
 }
 
 func (l *Launcher) ConfigUpdated(config *application_config.Config, services map[string]plugins.IService) error {
-	updated, err := l.createConfig(config, services)
+	dockerComposeConfig, diff, err := l.createConfig(config, services)
 	if err != nil {
 		return fmt.Errorf("unable to create config: %v", err)
 	}
-	if !updated {
+	if len(diff.Recreate) == 0 && len(diff.Remove) == 0 {
 		return nil
 	}
-	err = l.Shutdown()
+	err = CheckLocalImages(dockerComposeConfig)
 	if err != nil {
-		return fmt.Errorf("unable to shutdown application")
+		return err
+	}
+	if l.cancelContext != nil {
+		l.cancelContext()
 	}
 	l.shutdownRequested = false
-	err = l.launchApplication(services)
+	err = l.launchApplication(config, services, diff)
 	if err != nil {
+		// state of services is unknown, so all of them will be recreated next time
+		l.launchedConfig = nil
 		return fmt.Errorf("unable to launch application: %v", err)
 	}
+	l.launchedConfig = dockerComposeConfig
 	return nil
 }
 
@@ -136,17 +142,10 @@ func (l *Launcher) Shutdown() error {
 	if l.cancelContext != nil {
 		l.cancelContext()
 	}
-	if l.cmd != nil {
-		err := l.cmd.Process.Signal(os.Interrupt)
-		if err != nil {
-			return fmt.Errorf("unable to interrupt cmd: %v", err)
-		}
-		err = l.cmd.Wait()
-		if err != nil {
-			fmt.Printf("unable to wait for cmd: %v\n", err)
-		}
-		l.cmd = nil
-		shutdownCmd, err := shutdownApplication(l.tmpDirectory)
+	StopFollowingLogs(l.logsCmds)
+	l.logsCmds = nil
+	if l.launched {
+		shutdownCmd, err := shutdownApplication(l.tmpDirectory, l.projectName)
 		if err != nil {
 			return fmt.Errorf("unable to shutdown application: %v", err)
 		}
@@ -154,6 +153,17 @@ func (l *Launcher) Shutdown() error {
 		if err != nil {
 			fmt.Printf("unable to wait for shutdown cmd: %v\n", err)
 		}
+		l.launched = false
 	}
+	l.launchedConfig = nil
+	l.lastWritedFiles = nil
 	return nil
 }
+
+func (l *Launcher) pathToConfig() string {
+	return l.tmpDirectory + "/docker-compose.generated-test.yml"
+}
+
+func (l *Launcher) ApplicationsUrls() map[string]string {
+	return l.applicationsUrls
+}
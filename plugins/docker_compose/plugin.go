@@ -5,7 +5,7 @@ import (
 )
 
 func init() {
-	plugins.DefineLauncher("docker compose", func(tmpDirectory string) (plugins.ILauncher, error) {
-		return NewLauncher(tmpDirectory), nil
+	plugins.DefineLauncher("docker compose", func(tmpDirectory string, logs *plugins.RunLogs) (plugins.ILauncher, error) {
+		return NewLauncher(tmpDirectory, logs), nil
 	})
 }
@@ -0,0 +1,259 @@
+package docker_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"integration_framework/plugins/docker_compose"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type ContainerConfig struct {
+	Image        string              `json:"Image"`
+	Env          []string            `json:"Env,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	Healthcheck  *Healthcheck        `json:"Healthcheck,omitempty"`
+	HostConfig   HostConfig          `json:"HostConfig"`
+
+	NetworkingConfig NetworkingConfig `json:"NetworkingConfig"`
+}
+
+// Healthcheck durations are in nanoseconds
+type Healthcheck struct {
+	Test        []string `json:"Test"`
+	Interval    int64    `json:"Interval,omitempty"`
+	Timeout     int64    `json:"Timeout,omitempty"`
+	Retries     int      `json:"Retries,omitempty"`
+	StartPeriod int64    `json:"StartPeriod,omitempty"`
+}
+
+func newHealthcheck(healthcheck *docker_compose.DockerComposeHealthcheck) (*Healthcheck, error) {
+	interval, err := parseOptionalDuration(healthcheck.Interval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interval: %v", err)
+	}
+	timeout, err := parseOptionalDuration(healthcheck.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %v", err)
+	}
+	startPeriod, err := parseOptionalDuration(healthcheck.StartPeriod)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_period: %v", err)
+	}
+	return &Healthcheck{
+		Test:        healthcheck.Test,
+		Interval:    int64(interval),
+		Timeout:     int64(timeout),
+		Retries:     healthcheck.Retries,
+		StartPeriod: int64(startPeriod),
+	}, nil
+}
+
+func parseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+type HostConfig struct {
+	Binds         []string                 `json:"Binds,omitempty"`
+	PortBindings  map[string][]PortBinding `json:"PortBindings,omitempty"`
+	RestartPolicy RestartPolicy            `json:"RestartPolicy"`
+	Tmpfs         map[string]string        `json:"Tmpfs,omitempty"`
+	NetworkMode   string                   `json:"NetworkMode,omitempty"`
+	// cpus in units of 10^-9 cpus
+	NanoCpus int64 `json:"NanoCpus,omitempty"`
+	// memory limit in bytes
+	Memory int64 `json:"Memory,omitempty"`
+}
+
+type PortBinding struct {
+	HostIp   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+type RestartPolicy struct {
+	Name string `json:"Name,omitempty"`
+}
+
+type NetworkingConfig struct {
+	EndpointsConfig map[string]EndpointConfig `json:"EndpointsConfig,omitempty"`
+}
+
+type EndpointConfig struct {
+	Aliases []string `json:"Aliases,omitempty"`
+}
+
+type ContainerInspect struct {
+	Id           string         `json:"Id"`
+	Name         string         `json:"Name"`
+	RestartCount int            `json:"RestartCount"`
+	State        ContainerState `json:"State"`
+}
+
+type ContainerState struct {
+	Status     string           `json:"Status"`
+	Running    bool             `json:"Running"`
+	Restarting bool             `json:"Restarting"`
+	OOMKilled  bool             `json:"OOMKilled"`
+	ExitCode   int              `json:"ExitCode"`
+	Error      string           `json:"Error"`
+	Health     *ContainerHealth `json:"Health,omitempty"`
+}
+
+type ContainerHealth struct {
+	Status string `json:"Status"`
+}
+
+type ContainerSummary struct {
+	Id     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type NetworkSummary struct {
+	Id     string            `json:"Id"`
+	Name   string            `json:"Name"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type ImageSummary struct {
+	Id       string            `json:"Id"`
+	RepoTags []string          `json:"RepoTags"`
+	Labels   map[string]string `json:"Labels"`
+}
+
+func (c *Client) CreateNetwork(ctx context.Context, name string, labels map[string]string) (id string, err error) {
+	var resp struct {
+		Id string `json:"Id"`
+	}
+	err = c.doJson(ctx, "POST", "/networks/create", nil, map[string]interface{}{
+		"Name":           name,
+		"CheckDuplicate": true,
+		"Labels":         labels,
+	}, &resp)
+	if err != nil {
+		return "", wrapError(err, "unable to create network %q", name)
+	}
+	return resp.Id, nil
+}
+
+func (c *Client) RemoveNetwork(ctx context.Context, id string) error {
+	err := c.doJson(ctx, "DELETE", "/networks/"+url.PathEscape(id), nil, nil, nil)
+	if err != nil {
+		return wrapError(err, "unable to remove network %q", id)
+	}
+	return nil
+}
+
+func (c *Client) ListNetworks(ctx context.Context, label string) ([]NetworkSummary, error) {
+	var resp []NetworkSummary
+	err := c.doJson(ctx, "GET", "/networks", labelFilter(label), nil, &resp)
+	if err != nil {
+		return nil, wrapError(err, "unable to list networks")
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateContainer(ctx context.Context, name string, config ContainerConfig) (id string, err error) {
+	var resp struct {
+		Id       string   `json:"Id"`
+		Warnings []string `json:"Warnings"`
+	}
+	err = c.doJson(ctx, "POST", "/containers/create", url.Values{"name": {name}}, config, &resp)
+	if err != nil {
+		return "", wrapError(err, "unable to create container %q", name)
+	}
+	for _, warning := range resp.Warnings {
+		fmt.Printf("container %q warning: %s\n", name, warning)
+	}
+	return resp.Id, nil
+}
+
+func (c *Client) StartContainer(ctx context.Context, id string) error {
+	err := c.doJson(ctx, "POST", "/containers/"+url.PathEscape(id)+"/start", nil, nil, nil)
+	if err != nil {
+		return wrapError(err, "unable to start container %q", id)
+	}
+	return nil
+}
+
+func (c *Client) StopContainer(ctx context.Context, id string, timeoutSeconds int) error {
+	err := c.doJson(ctx, "POST", "/containers/"+url.PathEscape(id)+"/stop", url.Values{"t": {strconv.Itoa(timeoutSeconds)}}, nil, nil)
+	if err != nil {
+		return wrapError(err, "unable to stop container %q", id)
+	}
+	return nil
+}
+
+func (c *Client) RemoveContainer(ctx context.Context, id string) error {
+	err := c.doJson(ctx, "DELETE", "/containers/"+url.PathEscape(id), url.Values{"force": {"1"}, "v": {"1"}}, nil, nil)
+	if err != nil {
+		return wrapError(err, "unable to remove container %q", id)
+	}
+	return nil
+}
+
+func (c *Client) InspectContainer(ctx context.Context, id string) (*ContainerInspect, error) {
+	var resp ContainerInspect
+	err := c.doJson(ctx, "GET", "/containers/"+url.PathEscape(id)+"/json", nil, nil, &resp)
+	if err != nil {
+		return nil, wrapError(err, "unable to inspect container %q", id)
+	}
+	return &resp, nil
+}
+
+func (c *Client) ListContainers(ctx context.Context, label string) ([]ContainerSummary, error) {
+	query := labelFilter(label)
+	query.Set("all", "1")
+	var resp []ContainerSummary
+	err := c.doJson(ctx, "GET", "/containers/json", query, nil, &resp)
+	if err != nil {
+		return nil, wrapError(err, "unable to list containers")
+	}
+	return resp, nil
+}
+
+// RemoveImage removes tag of image. Parent layers are kept to be used as build cache by next runs
+func (c *Client) RemoveImage(ctx context.Context, name string) error {
+	err := c.doJson(ctx, "DELETE", "/images/"+name, url.Values{"noprune": {"1"}}, nil, nil)
+	if err != nil {
+		return wrapError(err, "unable to remove image %q", name)
+	}
+	return nil
+}
+
+func (c *Client) ListImages(ctx context.Context, label string) ([]ImageSummary, error) {
+	var resp []ImageSummary
+	err := c.doJson(ctx, "GET", "/images/json", labelFilter(label), nil, &resp)
+	if err != nil {
+		return nil, wrapError(err, "unable to list images")
+	}
+	return resp, nil
+}
+
+func (c *Client) ImageExists(ctx context.Context, name string) (bool, error) {
+	err := c.doJson(ctx, "GET", "/images/"+name+"/json", nil, nil, nil)
+	if err == nil {
+		return true, nil
+	}
+	if IsNotFound(err) {
+		return false, nil
+	}
+	return false, wrapError(err, "unable to inspect image %q", name)
+}
+
+func labelFilter(label string) url.Values {
+	filters, _ := json.Marshal(map[string][]string{
+		"label": {label},
+	})
+	return url.Values{"filters": {string(filters)}}
+}
@@ -0,0 +1,142 @@
+package docker_engine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCreateAndStartContainer(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	ctx := context.Background()
+
+	config := ContainerConfig{
+		Image:      "app:latest",
+		Env:        []string{"A=1"},
+		Cmd:        []string{"sh", "-c", "echo a b"},
+		Labels:     map[string]string{projectLabel: "project"},
+		HostConfig: HostConfig{NetworkMode: "network"},
+	}
+	id, err := client.CreateContainer(ctx, "project_app", config)
+	if err != nil {
+		t.Fatalf("unable to create container: %v", err)
+	}
+	created := fake.containers[id]
+	if created == nil || created.name != "project_app" {
+		t.Fatalf("container %q not created with name: %#v", id, created)
+	}
+	if !reflect.DeepEqual(created.config, config) {
+		t.Errorf("expected config %#v, got %#v", config, created.config)
+	}
+
+	err = client.StartContainer(ctx, id)
+	if err != nil {
+		t.Fatalf("unable to start container: %v", err)
+	}
+	inspect, err := client.InspectContainer(ctx, id)
+	if err != nil {
+		t.Fatalf("unable to inspect container: %v", err)
+	}
+	if !inspect.State.Running || inspect.State.Status != "running" {
+		t.Errorf("expected running container, got %#v", inspect.State)
+	}
+
+	containers, err := client.ListContainers(ctx, projectLabel+"=project")
+	if err != nil {
+		t.Fatalf("unable to list containers: %v", err)
+	}
+	if len(containers) != 1 || containers[0].Id != id {
+		t.Errorf("expected container %q in list, got %#v", id, containers)
+	}
+	containers, err = client.ListContainers(ctx, projectLabel+"=other")
+	if err != nil {
+		t.Fatalf("unable to list containers: %v", err)
+	}
+	if len(containers) != 0 {
+		t.Errorf("expected no containers of other project, got %#v", containers)
+	}
+}
+
+func TestRemoveContainer(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	ctx := context.Background()
+
+	id, err := client.CreateContainer(ctx, "app", ContainerConfig{Image: "app"})
+	if err != nil {
+		t.Fatalf("unable to create container: %v", err)
+	}
+	err = client.StopContainer(ctx, id, 1)
+	if err != nil {
+		t.Fatalf("unable to stop container: %v", err)
+	}
+	err = client.RemoveContainer(ctx, id)
+	if err != nil {
+		t.Fatalf("unable to remove container: %v", err)
+	}
+	if _, ok := fake.containers[id]; ok {
+		t.Errorf("container %q is not removed", id)
+	}
+
+	err = client.RemoveContainer(ctx, id)
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error for removed container, got %v", err)
+	}
+	_, err = client.InspectContainer(ctx, id)
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error for inspect of removed container, got %v", err)
+	}
+}
+
+func TestNetworks(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	ctx := context.Background()
+
+	id, err := client.CreateNetwork(ctx, "project", map[string]string{projectLabel: "project"})
+	if err != nil {
+		t.Fatalf("unable to create network: %v", err)
+	}
+	networks, err := client.ListNetworks(ctx, projectLabel+"=project")
+	if err != nil {
+		t.Fatalf("unable to list networks: %v", err)
+	}
+	if len(networks) != 1 || networks[0].Id != id || networks[0].Name != "project" {
+		t.Errorf("expected network %q in list, got %#v", id, networks)
+	}
+	err = client.RemoveNetwork(ctx, id)
+	if err != nil {
+		t.Fatalf("unable to remove network: %v", err)
+	}
+	if len(fake.networks) != 0 {
+		t.Errorf("network is not removed: %#v", fake.networks)
+	}
+}
+
+func TestImages(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	ctx := context.Background()
+
+	fake.images["registry:5000/app:1.0"] = ImageSummary{Id: "sha256:1", RepoTags: []string{"registry:5000/app:1.0"}}
+	exists, err := client.ImageExists(ctx, "registry:5000/app:1.0")
+	if err != nil || !exists {
+		t.Errorf("expected existing image, got %v, %v", exists, err)
+	}
+	exists, err = client.ImageExists(ctx, "missing")
+	if err != nil || exists {
+		t.Errorf("expected missing image, got %v, %v", exists, err)
+	}
+	err = client.RemoveImage(ctx, "registry:5000/app:1.0")
+	if err != nil {
+		t.Fatalf("unable to remove image: %v", err)
+	}
+	if len(fake.images) != 0 {
+		t.Errorf("image is not removed: %#v", fake.images)
+	}
+	err = client.RemoveImage(ctx, "registry:5000/app:1.0")
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error for removed image, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package docker_engine
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const buildErrorLogLines = 20
+
+// BuildError is returned when image build failed. It contains message and code reported by docker and last lines of build output
+type BuildError struct {
+	Service  string
+	Message  string
+	Code     int
+	LastLogs []string
+}
+
+func (e BuildError) Error() string {
+	res := fmt.Sprintf("build of service %q failed", e.Service)
+	if e.Code != 0 {
+		res += fmt.Sprintf(" with code %d", e.Code)
+	}
+	res += ": " + e.Message
+	if len(e.LastLogs) != 0 {
+		res += "\nlast build output:\n" + strings.Join(e.LastLogs, "\n")
+	}
+	return res
+}
+
+type buildMessage struct {
+	Stream      string `json:"stream"`
+	Status      string `json:"status"`
+	Error       string `json:"error"`
+	ErrorDetail *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+}
+
+// BuildOptions are options of image build like in `build` section of docker compose service
+type BuildOptions struct {
+	Dockerfile string
+	Args       map[string]string
+	// target stage of multi-stage dockerfile
+	Target string
+	Labels map[string]string
+}
+
+// BuildImage builds image with specified tag from context directory
+func (c *Client) BuildImage(ctx context.Context, service string, contextDirectory string, options BuildOptions, tag string) error {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(writeBuildContext(pipeWriter, contextDirectory))
+	}()
+	defer pipeReader.Close()
+
+	query := url.Values{
+		"t":  {tag},
+		"rm": {"1"},
+	}
+	if options.Dockerfile != "" {
+		query.Set("dockerfile", options.Dockerfile)
+	}
+	if len(options.Args) != 0 {
+		buildArgs, err := json.Marshal(options.Args)
+		if err != nil {
+			return fmt.Errorf("unable to marshal build args: %v", err)
+		}
+		query.Set("buildargs", string(buildArgs))
+	}
+	if options.Target != "" {
+		query.Set("target", options.Target)
+	}
+	if len(options.Labels) != 0 {
+		labels, err := json.Marshal(options.Labels)
+		if err != nil {
+			return fmt.Errorf("unable to marshal labels: %v", err)
+		}
+		query.Set("labels", string(labels))
+	}
+	resp, err := c.do(ctx, "POST", "/build", query, pipeReader, "application/x-tar")
+	if err != nil {
+		return fmt.Errorf("unable to build image for service %q: %v", service, err)
+	}
+	defer resp.Body.Close()
+	return readBuildOutput(service, resp.Body)
+}
+
+// PullImage pulls image from registry
+func (c *Client) PullImage(ctx context.Context, service string, image string) error {
+	fromImage, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		fromImage, tag = image[:i], image[i+1:]
+	}
+	resp, err := c.do(ctx, "POST", "/images/create", url.Values{"fromImage": {fromImage}, "tag": {tag}}, nil, "")
+	if err != nil {
+		return fmt.Errorf("unable to pull image %q for service %q: %v", image, service, err)
+	}
+	defer resp.Body.Close()
+	return readBuildOutput(service, resp.Body)
+}
+
+// readBuildOutput reads json stream of build (or pull) and returns BuildError if any error reported
+func readBuildOutput(service string, r io.Reader) error {
+	var lastLogs []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var message buildMessage
+		err := json.Unmarshal(line, &message)
+		if err != nil {
+			return fmt.Errorf("unable to parse build output of service %q: %v", service, err)
+		}
+		if message.Error != "" || message.ErrorDetail != nil {
+			buildError := BuildError{
+				Service:  service,
+				Message:  message.Error,
+				LastLogs: lastLogs,
+			}
+			if message.ErrorDetail != nil {
+				buildError.Code = message.ErrorDetail.Code
+				if buildError.Message == "" {
+					buildError.Message = message.ErrorDetail.Message
+				}
+			}
+			return buildError
+		}
+		text := strings.TrimRight(message.Stream, "\n")
+		if text == "" {
+			text = message.Status
+		}
+		if text == "" {
+			continue
+		}
+		lastLogs = append(lastLogs, text)
+		if len(lastLogs) > buildErrorLogLines {
+			lastLogs = lastLogs[1:]
+		}
+	}
+	err := scanner.Err()
+	if err != nil {
+		return fmt.Errorf("unable to read build output of service %q: %v", service, err)
+	}
+	return nil
+}
+
+// writeBuildContext writes tar archive of directory skipping files matched by .dockerignore
+func writeBuildContext(w io.Writer, contextDirectory string) error {
+	ignorePatterns, err := readDockerignore(contextDirectory)
+	if err != nil {
+		return err
+	}
+	tarWriter := tar.NewWriter(w)
+	err = filepath.Walk(contextDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(contextDirectory, path)
+		if err != nil {
+			return err
+		}
+		if relativePath == "." {
+			return nil
+		}
+		relativePath = filepath.ToSlash(relativePath)
+		if isIgnored(ignorePatterns, relativePath) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = relativePath
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tarWriter, file)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to pack build context %q: %v", contextDirectory, err)
+	}
+	return tarWriter.Close()
+}
+
+func readDockerignore(contextDirectory string) ([]string, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(contextDirectory, ".dockerignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read .dockerignore: %v", err)
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, strings.Trim(filepath.ToSlash(filepath.Clean(line)), "/"))
+	}
+	return patterns, nil
+}
+
+// isIgnored supports only simple .dockerignore patterns: glob of path or its parent directory and `!` exceptions
+func isIgnored(patterns []string, relativePath string) bool {
+	ignored := false
+	for _, pattern := range patterns {
+		exception := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		if matchesIgnorePattern(pattern, relativePath) {
+			ignored = !exception
+		}
+	}
+	return ignored
+}
+
+func matchesIgnorePattern(pattern string, relativePath string) bool {
+	for path := relativePath; path != "." && path != "/"; path = filepath.Dir(path) {
+		matched, err := filepath.Match(pattern, path)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
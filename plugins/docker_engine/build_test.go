@@ -0,0 +1,214 @@
+package docker_engine
+
+import (
+	"context"
+	"encoding/json"
+	"integration_framework/plugins/docker_compose"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildImage(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	contextDirectory, err := ioutil.TempDir("", "build_context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(contextDirectory)
+	files := map[string]string{
+		"Dockerfile":       "FROM alpine\n",
+		"main.go":          "package main\n",
+		"logs/app.log":     "ignored\n",
+		"node_modules/a":   "ignored\n",
+		"node_modules/b":   "kept by exception\n",
+		".dockerignore":    "# comment\nlogs\nnode_modules/*\n!node_modules/b\n",
+		"internal/util.go": "package internal\n",
+	}
+	for name, contents := range files {
+		path := filepath.Join(contextDirectory, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake.buildOutput = []string{
+		`{"stream":"Step 1/1 : FROM alpine\n"}`,
+		`{"stream":"Successfully built 123\n"}`,
+	}
+
+	err = client.BuildImage(context.Background(), "app", contextDirectory, BuildOptions{
+		Dockerfile: "Dockerfile",
+		Args:       map[string]string{"VERSION": "1"},
+		Target:     "release",
+		Labels:     map[string]string{projectLabel: "project"},
+	}, "project_app")
+	if err != nil {
+		t.Fatalf("unable to build image: %v", err)
+	}
+
+	var sentFiles []string
+	for name := range fake.buildFiles {
+		if !strings.HasSuffix(name, "/") && fake.buildFiles[name] != "" {
+			sentFiles = append(sentFiles, name)
+		}
+	}
+	for _, name := range []string{"Dockerfile", "main.go", "internal/util.go", "node_modules/b", ".dockerignore"} {
+		if fake.buildFiles[name] != files[name] {
+			t.Errorf("expected file %q with contents %q in build context, got %q", name, files[name], fake.buildFiles[name])
+		}
+	}
+	for _, name := range []string{"logs/app.log", "node_modules/a"} {
+		if _, ok := fake.buildFiles[name]; ok {
+			t.Errorf("expected file %q to be ignored, build context has files %v", name, sentFiles)
+		}
+	}
+
+	query := fake.buildQuery
+	if query.Get("t") != "project_app" || query.Get("dockerfile") != "Dockerfile" || query.Get("target") != "release" {
+		t.Errorf("unexpected build query %v", query)
+	}
+	var buildArgs map[string]string
+	json.Unmarshal([]byte(query.Get("buildargs")), &buildArgs)
+	if !reflect.DeepEqual(buildArgs, map[string]string{"VERSION": "1"}) {
+		t.Errorf("unexpected build args %v", buildArgs)
+	}
+	if fake.images["project_app"].Labels[projectLabel] != "project" {
+		t.Errorf("expected built image labeled with project, got %#v", fake.images["project_app"])
+	}
+}
+
+func TestBuildImageError(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	contextDirectory, err := ioutil.TempDir("", "build_context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(contextDirectory)
+	fake.buildOutput = []string{
+		`{"stream":"Step 1/2 : FROM alpine\n"}`,
+		`{"stream":"Step 2/2 : RUN make\n"}`,
+		`{"stream":"make: not found\n"}`,
+		`{"errorDetail":{"code":127,"message":"The command '/bin/sh -c make' returned a non-zero code: 127"},"error":"The command '/bin/sh -c make' returned a non-zero code: 127"}`,
+		`{"stream":"not read after error\n"}`,
+	}
+
+	err = client.BuildImage(context.Background(), "app", contextDirectory, BuildOptions{}, "project_app")
+	buildError, ok := err.(BuildError)
+	if !ok {
+		t.Fatalf("expected BuildError, got %T (%v)", err, err)
+	}
+	expected := BuildError{
+		Service:  "app",
+		Message:  "The command '/bin/sh -c make' returned a non-zero code: 127",
+		Code:     127,
+		LastLogs: []string{"Step 1/2 : FROM alpine", "Step 2/2 : RUN make", "make: not found"},
+	}
+	if !reflect.DeepEqual(buildError, expected) {
+		t.Errorf("expected %#v, got %#v", expected, buildError)
+	}
+	if !strings.Contains(buildError.Error(), "with code 127") || !strings.Contains(buildError.Error(), "make: not found") {
+		t.Errorf("expected code and last logs in error message, got %q", buildError.Error())
+	}
+}
+
+func TestBuildImageKeepsLastLogs(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	contextDirectory, err := ioutil.TempDir("", "build_context")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(contextDirectory)
+	for i := 0; i < buildErrorLogLines+5; i++ {
+		fake.buildOutput = append(fake.buildOutput, `{"stream":"line\n"}`)
+	}
+	fake.buildOutput = append(fake.buildOutput, `{"stream":"last\n"}`, `{"error":"failed"}`)
+
+	err = client.BuildImage(context.Background(), "app", contextDirectory, BuildOptions{}, "project_app")
+	buildError, ok := err.(BuildError)
+	if !ok {
+		t.Fatalf("expected BuildError, got %T (%v)", err, err)
+	}
+	if len(buildError.LastLogs) != buildErrorLogLines || buildError.LastLogs[buildErrorLogLines-1] != "last" {
+		t.Errorf("expected %d last lines of output, got %v", buildErrorLogLines, buildError.LastLogs)
+	}
+	if buildError.Message != "failed" || buildError.Code != 0 {
+		t.Errorf("unexpected error %#v", buildError)
+	}
+}
+
+func TestPullImage(t *testing.T) {
+	tests := []struct {
+		image  string
+		pulled string
+	}{
+		{"alpine", "alpine:latest"},
+		{"postgres:12", "postgres:12"},
+		{"registry:5000/team/app", "registry:5000/team/app:latest"},
+		{"registry:5000/team/app:1.2", "registry:5000/team/app:1.2"},
+	}
+	for _, test := range tests {
+		t.Run(test.image, func(t *testing.T) {
+			fake, client := newFakeDocker(t)
+			defer fake.server.Close()
+			fake.buildOutput = []string{
+				`{"status":"Pulling from library/alpine","id":"latest"}`,
+				`{"status":"Download complete","id":"123"}`,
+			}
+			err := client.PullImage(context.Background(), "app", test.image)
+			if err != nil {
+				t.Fatalf("unable to pull image: %v", err)
+			}
+			if !reflect.DeepEqual(fake.pulled, []string{test.pulled}) {
+				t.Errorf("expected pull of %q, got %v", test.pulled, fake.pulled)
+			}
+		})
+	}
+}
+
+func TestPullImageError(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	fake.buildOutput = []string{
+		`{"status":"Pulling from team/app","id":"1.2"}`,
+		`{"errorDetail":{"message":"manifest for team/app:1.2 not found"},"error":"manifest for team/app:1.2 not found"}`,
+	}
+	err := client.PullImage(context.Background(), "app", "team/app:1.2")
+	buildError, ok := err.(BuildError)
+	if !ok {
+		t.Fatalf("expected BuildError, got %T (%v)", err, err)
+	}
+	if buildError.Message != "manifest for team/app:1.2 not found" || !reflect.DeepEqual(buildError.LastLogs, []string{"Pulling from team/app"}) {
+		t.Errorf("unexpected error %#v", buildError)
+	}
+}
+
+func TestEnsureLocalImage(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	launcher := NewLauncher(os.TempDir(), client, nil)
+	service := &docker_compose.DockerComposeService{Image: "integration_framework/smtp_mock"}
+	service.SetLocalImage("make smtp_mock_image")
+
+	_, err := launcher.ensureImage(context.Background(), "smtp", service)
+	if err == nil || err.Error() != "image \"integration_framework/smtp_mock\" missing, run `make smtp_mock_image` in repository of integration_framework" {
+		t.Errorf("expected error of missing image, got %v", err)
+	}
+	if len(fake.pulled) != 0 {
+		t.Errorf("expected local image is not pulled, got %v", fake.pulled)
+	}
+
+	fake.images["integration_framework/smtp_mock"] = ImageSummary{Id: "sha256:smtp_mock"}
+	image, err := launcher.ensureImage(context.Background(), "smtp", service)
+	if err != nil || image != "integration_framework/smtp_mock" {
+		t.Errorf("expected existing image used, got %q, %v", image, err)
+	}
+}
@@ -0,0 +1,156 @@
+package docker_engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	apiVersion        = "v1.40"
+	defaultDockerHost = "unix:///var/run/docker.sock"
+)
+
+// Client is minimal client of Docker Engine API.
+// It can work over unix socket (like docker cli) or over tcp/http, so it can be tested against fake Docker API server
+type Client struct {
+	httpClient *http.Client
+	baseUrl    string
+}
+
+// NewClientFromEnv creates client for host defined in DOCKER_HOST or for default unix socket
+func NewClientFromEnv() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = defaultDockerHost
+	}
+	return NewClient(host)
+}
+
+// NewClient creates client for host like `unix:///var/run/docker.sock`, `tcp://127.0.0.1:2375` or `http://127.0.0.1:2375`
+func NewClient(host string) (*Client, error) {
+	parsedHost, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse docker host %q: %v", host, err)
+	}
+	switch parsedHost.Scheme {
+	case "unix":
+		socketPath := parsedHost.Path
+		return &Client{
+			httpClient: &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+						var dialer net.Dialer
+						return dialer.DialContext(ctx, "unix", socketPath)
+					},
+				},
+			},
+			// host is ignored when dialing unix socket, but it should be valid
+			baseUrl: "http://docker",
+		}, nil
+	case "tcp", "http":
+		return &Client{
+			httpClient: &http.Client{},
+			baseUrl:    "http://" + parsedHost.Host,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported docker host scheme %q", parsedHost.Scheme)
+	}
+}
+
+// APIError is error returned by Docker Engine API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("docker api error (status %d): %s", e.StatusCode, e.Message)
+}
+
+// wrapError adds context to error keeping status code of APIError, so IsNotFound works for errors returned by methods of client
+func wrapError(err error, format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	if apiErr, ok := err.(APIError); ok {
+		apiErr.Message = message + ": " + apiErr.Message
+		return apiErr
+	}
+	return fmt.Errorf("%s: %v", message, err)
+}
+
+func IsNotFound(err error) bool {
+	apiErr, ok := err.(APIError)
+	return ok && apiErr.StatusCode == http.StatusNotFound
+}
+
+// do sends request and returns response with successful status code. caller should close body of response
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	requestUrl := c.baseUrl + "/" + apiVersion + path
+	if len(query) != 0 {
+		requestUrl += "?" + query.Encode()
+	}
+	request, err := http.NewRequest(method, requestUrl, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %v", err)
+	}
+	request = request.WithContext(ctx)
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request %s %s: %v", method, path, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	var errorBody struct {
+		Message string `json:"message"`
+	}
+	message := strings.TrimSpace(string(respBody))
+	if json.Unmarshal(respBody, &errorBody) == nil && errorBody.Message != "" {
+		message = errorBody.Message
+	}
+	return nil, APIError{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+	}
+}
+
+// doJson sends request with json-encoded body (if it is not nil) and decodes json response to result (if it is not nil)
+func (c *Client) doJson(ctx context.Context, method string, path string, query url.Values, body interface{}, result interface{}) error {
+	var bodyReader io.Reader
+	contentType := ""
+	if body != nil {
+		encodedBody, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("unable to marshal request body: %v", err)
+		}
+		bodyReader = bytes.NewReader(encodedBody)
+		contentType = "application/json"
+	}
+	resp, err := c.do(ctx, method, path, query, bodyReader, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if result == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	err = json.NewDecoder(resp.Body).Decode(result)
+	if err != nil {
+		return fmt.Errorf("unable to decode response of %s %s: %v", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package docker_engine
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDocker is fake of Docker Engine API keeping containers, networks and images in memory
+type fakeDocker struct {
+	server *httptest.Server
+
+	mutex      sync.Mutex
+	lastId     int
+	containers map[string]*fakeContainer
+	networks   map[string]NetworkSummary
+	// by name of image
+	images map[string]ImageSummary
+	// json messages streamed by build and pull
+	buildOutput []string
+	// files of build context and query of last build
+	buildFiles map[string]string
+	buildQuery url.Values
+	pulled     []string
+	// multiplexed frames of logs: stream type and payload
+	logs []fakeLogFrame
+}
+
+type fakeContainer struct {
+	name    string
+	config  ContainerConfig
+	running bool
+	stopped bool
+}
+
+type fakeLogFrame struct {
+	stream  byte
+	payload string
+}
+
+// newFakeDocker starts fake server, it should be closed by test
+func newFakeDocker(t *testing.T) (*fakeDocker, *Client) {
+	f := &fakeDocker{
+		containers: make(map[string]*fakeContainer),
+		networks:   make(map[string]NetworkSummary),
+		images:     make(map[string]ImageSummary),
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	client, err := NewClient(f.server.URL)
+	if err != nil {
+		f.server.Close()
+		t.Fatalf("unable to create client: %v", err)
+	}
+	return f, client
+}
+
+func (f *fakeDocker) nextId(prefix string) string {
+	f.lastId++
+	return fmt.Sprintf("%s%d", prefix, f.lastId)
+}
+
+func (f *fakeDocker) handle(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/"+apiVersion)
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case r.Method == "POST" && path == "/networks/create":
+		var body struct {
+			Name   string            `json:"Name"`
+			Labels map[string]string `json:"Labels"`
+		}
+		decodeBody(w, r, &body)
+		id := f.nextId("network")
+		f.networks[id] = NetworkSummary{Id: id, Name: body.Name, Labels: body.Labels}
+		writeJson(w, map[string]string{"Id": id})
+	case r.Method == "GET" && path == "/networks":
+		var res []NetworkSummary
+		for _, network := range f.networks {
+			if matchLabelFilter(r, network.Labels) {
+				res = append(res, network)
+			}
+		}
+		writeJson(w, res)
+	case r.Method == "DELETE" && len(parts) == 2 && parts[0] == "networks":
+		if _, ok := f.networks[parts[1]]; !ok {
+			writeError(w, http.StatusNotFound, "network not found")
+			return
+		}
+		delete(f.networks, parts[1])
+	case r.Method == "POST" && path == "/containers/create":
+		var config ContainerConfig
+		decodeBody(w, r, &config)
+		id := f.nextId("container")
+		f.containers[id] = &fakeContainer{name: r.URL.Query().Get("name"), config: config}
+		writeJson(w, map[string]interface{}{"Id": id, "Warnings": []string{}})
+	case r.Method == "GET" && path == "/containers/json":
+		var res []ContainerSummary
+		for id, container := range f.containers {
+			if matchLabelFilter(r, container.config.Labels) {
+				res = append(res, ContainerSummary{Id: id, Names: []string{"/" + container.name}, Labels: container.config.Labels})
+			}
+		}
+		writeJson(w, res)
+	case len(parts) >= 2 && parts[0] == "containers":
+		container, ok := f.containers[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "No such container: "+parts[1])
+			return
+		}
+		f.handleContainer(w, r, parts[1], container, strings.Join(parts[2:], "/"))
+	case r.Method == "POST" && path == "/build":
+		f.handleBuild(w, r)
+	case r.Method == "POST" && path == "/images/create":
+		f.pulled = append(f.pulled, r.URL.Query().Get("fromImage")+":"+r.URL.Query().Get("tag"))
+		writeStream(w, f.buildOutput)
+	case r.Method == "GET" && path == "/images/json":
+		var res []ImageSummary
+		for _, image := range f.images {
+			if matchLabelFilter(r, image.Labels) {
+				res = append(res, image)
+			}
+		}
+		writeJson(w, res)
+	case r.Method == "GET" && strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/json"):
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json")
+		if _, ok := f.images[name]; !ok {
+			writeError(w, http.StatusNotFound, "No such image: "+name)
+			return
+		}
+		writeJson(w, f.images[name])
+	case r.Method == "DELETE" && strings.HasPrefix(path, "/images/"):
+		name := strings.TrimPrefix(path, "/images/")
+		if _, ok := f.images[name]; !ok {
+			writeError(w, http.StatusNotFound, "No such image: "+name)
+			return
+		}
+		delete(f.images, name)
+		writeJson(w, []interface{}{})
+	default:
+		writeError(w, http.StatusNotImplemented, fmt.Sprintf("%s %s is not implemented by fake", r.Method, path))
+	}
+}
+
+func (f *fakeDocker) handleContainer(w http.ResponseWriter, r *http.Request, id string, container *fakeContainer, action string) {
+	switch {
+	case r.Method == "POST" && action == "start":
+		container.running = true
+	case r.Method == "POST" && action == "stop":
+		container.running = false
+		container.stopped = true
+	case r.Method == "DELETE" && action == "":
+		delete(f.containers, id)
+	case r.Method == "GET" && action == "json":
+		status := "created"
+		if container.running {
+			status = "running"
+		} else if container.stopped {
+			status = "exited"
+		}
+		writeJson(w, ContainerInspect{
+			Id:    id,
+			Name:  "/" + container.name,
+			State: ContainerState{Status: status, Running: container.running},
+		})
+	case r.Method == "GET" && action == "logs":
+		for _, frame := range f.logs {
+			header := make([]byte, 8)
+			header[0] = frame.stream
+			binary.BigEndian.PutUint32(header[4:], uint32(len(frame.payload)))
+			w.Write(header)
+			w.Write([]byte(frame.payload))
+		}
+	default:
+		writeError(w, http.StatusNotImplemented, fmt.Sprintf("%s container %s is not implemented by fake", r.Method, action))
+	}
+}
+
+func (f *fakeDocker) handleBuild(w http.ResponseWriter, r *http.Request) {
+	f.buildQuery = r.URL.Query()
+	f.buildFiles = make(map[string]string)
+	tarReader := tar.NewReader(r.Body)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		contents, _ := ioutil.ReadAll(tarReader)
+		f.buildFiles[header.Name] = string(contents)
+	}
+	var labels map[string]string
+	json.Unmarshal([]byte(f.buildQuery.Get("labels")), &labels)
+	tag := f.buildQuery.Get("t")
+	f.images[tag] = ImageSummary{Id: "sha256:" + tag, RepoTags: []string{tag}, Labels: labels}
+	writeStream(w, f.buildOutput)
+}
+
+// matchLabelFilter checks filter like `{"label": ["key=value"]}`
+func matchLabelFilter(r *http.Request, labels map[string]string) bool {
+	var filters map[string][]string
+	json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+	for _, label := range filters["label"] {
+		parts := strings.SplitN(label, "=", 2)
+		value, ok := labels[parts[0]]
+		if !ok || (len(parts) == 2 && value != parts[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeStream(w http.ResponseWriter, messages []string) {
+	w.Header().Set("Content-Type", "application/json")
+	for _, message := range messages {
+		fmt.Fprintln(w, message)
+		w.(http.Flusher).Flush()
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,664 @@
+package docker_engine
+
+import (
+	"context"
+	"fmt"
+	"integration_framework/application_config"
+	"integration_framework/plugins"
+	"integration_framework/plugins/docker_compose"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	serviceLabel   = "integration_framework.service"
+	stopTimeout    = 10
+	requestTimeout = time.Minute
+)
+
+func NewLauncher(tmpDirectory string, client *Client, logs *plugins.RunLogs) *Launcher {
+	return &Launcher{
+		tmpDirectory: tmpDirectory,
+		client:       client,
+		logs:         logs,
+		allocator:    docker_compose.NewAllocator(tmpDirectory),
+		projectName:  docker_compose.NewProjectName(),
+		owner:        newOwner(),
+		builtImages:  make(map[string]bool),
+	}
+}
+
+// Launcher starts services defined by generated docker compose config using Docker Engine API
+type Launcher struct {
+	tmpDirectory string
+	client       *Client
+	logs         *plugins.RunLogs
+	allocator    *docker_compose.Allocator
+	projectName  string
+	// owner of resources of run, used to find resources of killed runs
+	owner            owner
+	applicationsUrls map[string]string
+	// config of running services. it is nil if services are not launched or launch failed
+	launchedConfig  *docker_compose.DockerComposeConfig
+	lastWritedFiles map[string][]byte
+	networkId       string
+	// images built in this run, they are removed at shutdown
+	builtImages map[string]bool
+	// launchMutex serializes launches and removal of services, Shutdown waits for interrupted launch
+	launchMutex sync.Mutex
+	// mutex guards fields below, they are used by Shutdown called from other goroutine while launch is in progress
+	mutex sync.Mutex
+	// docker compose service name -> container id
+	containers        map[string]string
+	shutdownRequested bool
+	cancelContext     func()
+	// logs are followed until shutdown, even if config updated
+	logsContext   context.Context
+	cancelLogs    func()
+	logsWaitGroup sync.WaitGroup
+}
+
+func (l *Launcher) createConfig(config *application_config.Config, services map[string]plugins.IService) (*docker_compose.DockerComposeConfig, docker_compose.ConfigDiff, error) {
+	dockerComposeConfig, files, err := docker_compose.NewDockerComposeConfig(l.allocator, config, services)
+	if err != nil {
+		return nil, docker_compose.ConfigDiff{}, fmt.Errorf("unable to generate docker-compose config: %v", err)
+	}
+	if l.lastWritedFiles == nil {
+		l.lastWritedFiles = make(map[string][]byte)
+	}
+	filesChangedServices, err := docker_compose.WriteChangedFiles(l.lastWritedFiles, files)
+	if err != nil {
+		return nil, docker_compose.ConfigDiff{}, fmt.Errorf("unable to write services configs: %v", err)
+	}
+	diff, err := docker_compose.DiffConfigs(l.launchedConfig, dockerComposeConfig, services, filesChangedServices)
+	if err != nil {
+		return nil, docker_compose.ConfigDiff{}, fmt.Errorf("unable to compare docker compose configs: %v", err)
+	}
+	// changed files are already written, so services can reload them
+	diff.HotReloadServices(dockerComposeConfig, services)
+	return dockerComposeConfig, diff, nil
+}
+
+func (l *Launcher) ConfigUpdated(config *application_config.Config, services map[string]plugins.IService) error {
+	l.launchMutex.Lock()
+	defer l.launchMutex.Unlock()
+	if l.isShutdownRequested() {
+		return nil
+	}
+	dockerComposeConfig, diff, err := l.createConfig(config, services)
+	if err != nil {
+		return fmt.Errorf("unable to create config: %v", err)
+	}
+	if len(diff.Recreate) == 0 && len(diff.Remove) == 0 {
+		return nil
+	}
+	if l.launchedConfig == nil {
+		// remove everything left from failed launch
+		err = l.removeAll()
+		if err != nil {
+			return fmt.Errorf("unable to shutdown application: %v", err)
+		}
+	}
+	err = l.launchApplication(config, dockerComposeConfig, services, diff)
+	if err != nil {
+		// state of services is unknown, so all of them will be recreated next time
+		l.launchedConfig = nil
+		return fmt.Errorf("unable to launch application: %v", err)
+	}
+	l.launchedConfig = dockerComposeConfig
+	return nil
+}
+
+// launchApplication creates or recreates changed services and waits for readiness of services and applications
+func (l *Launcher) launchApplication(config *application_config.Config, dockerComposeConfig *docker_compose.DockerComposeConfig, services map[string]plugins.IService, diff docker_compose.ConfigDiff) error {
+	fmt.Println("--------------------> launch app")
+	ctx, ok := l.launchContext()
+	if !ok {
+		return nil
+	}
+
+	if l.networkId == "" {
+		err := l.removeLeftovers()
+		if err != nil {
+			return err
+		}
+		requestCtx, cancelRequest := context.WithTimeout(ctx, requestTimeout)
+		l.networkId, err = l.client.CreateNetwork(requestCtx, l.projectName+"_default", l.labels())
+		cancelRequest()
+		if err != nil {
+			return err
+		}
+		l.logsContext, l.cancelLogs = context.WithCancel(context.Background())
+	}
+
+	recreate := make(map[string]bool, len(diff.Recreate))
+	for _, serviceName := range diff.Recreate {
+		recreate[serviceName] = true
+	}
+	for _, serviceName := range append(append([]string{}, diff.Remove...), diff.Recreate...) {
+		err := l.removeService(serviceName)
+		if err != nil {
+			return err
+		}
+	}
+
+	servicesOrder, err := startupOrder(dockerComposeConfig.Services)
+	if err != nil {
+		return err
+	}
+	for _, serviceName := range servicesOrder {
+		if l.isShutdownRequested() {
+			return nil
+		}
+		if !recreate[serviceName] {
+			continue
+		}
+		service := dockerComposeConfig.Services[serviceName]
+		err = l.waitForHealthyDependencies(ctx, config, service)
+		if err != nil {
+			if l.isShutdownRequested() {
+				return nil
+			}
+			return err
+		}
+		err = l.startService(ctx, serviceName, service)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Println("---> launched")
+
+	err = plugins.WaitForServicesReady(ctx, config, services)
+	if err != nil {
+		if l.isShutdownRequested() {
+			return nil
+		}
+		return err
+	}
+	containersAliveProbe := plugins.ProbeFunc(func(ctx context.Context) error {
+		err := l.checkContainersAlive(ctx)
+		if err != nil {
+			return plugins.FatalProbeError{Err: err}
+		}
+		return nil
+	})
+	l.applicationsUrls = make(map[string]string)
+	for _, applicationName := range config.ApplicationsNames() {
+		applicationPort, err := docker_compose.ApplicationHostPort(l.allocator, applicationName, config.Applications[applicationName])
+		if err != nil {
+			return fmt.Errorf("unable to get port of application %q: %v", applicationName, err)
+		}
+		l.applicationsUrls[applicationName] = fmt.Sprintf("http://localhost:%d", applicationPort)
+		err = plugins.WaitForApplicationReady(ctx, config, applicationName, fmt.Sprintf("localhost:%d", applicationPort), containersAliveProbe)
+		if err != nil {
+			if l.isShutdownRequested() {
+				return nil
+			}
+			return err
+		}
+	}
+	fmt.Println("---> return from launch application")
+	return nil
+}
+
+func (l *Launcher) startService(ctx context.Context, serviceName string, service *docker_compose.DockerComposeService) error {
+	image, err := l.ensureImage(ctx, serviceName, service)
+	if err != nil {
+		return err
+	}
+	containerConfig, err := l.containerConfig(serviceName, image, service)
+	if err != nil {
+		return fmt.Errorf("unable to create config of container for service %q: %v", serviceName, err)
+	}
+
+	requestCtx, cancelRequest := context.WithTimeout(ctx, requestTimeout)
+	defer cancelRequest()
+	containerId, err := l.client.CreateContainer(requestCtx, l.projectName+"_"+serviceName, *containerConfig)
+	if err != nil {
+		return err
+	}
+	l.setContainer(serviceName, containerId)
+	err = l.client.StartContainer(requestCtx, containerId)
+	if err != nil {
+		return err
+	}
+
+	logsWriter, err := l.logs.Writer(serviceName)
+	if err != nil {
+		return err
+	}
+	l.logsWaitGroup.Add(1)
+	go func() {
+		defer l.logsWaitGroup.Done()
+		err := l.client.FollowLogs(l.logsContext, containerId, logsWriter)
+		if err != nil && l.logsContext.Err() == nil {
+			fmt.Printf("unable to follow logs of service %q: %v\n", serviceName, err)
+		}
+	}()
+	return nil
+}
+
+// waitForHealthyDependencies waits for dependencies with condition `service_healthy` like docker-compose does
+func (l *Launcher) waitForHealthyDependencies(ctx context.Context, config *application_config.Config, service *docker_compose.DockerComposeService) error {
+	options, err := plugins.NewReadinessOptions(config.Readiness, nil)
+	if err != nil {
+		return fmt.Errorf("invalid readiness config: %v", err)
+	}
+	for _, dependencyName := range dependenciesNames(service) {
+		if service.DependsOn[dependencyName].Condition != "service_healthy" {
+			continue
+		}
+		containerId, _ := l.container(dependencyName)
+		err := plugins.WaitForReady(ctx, fmt.Sprintf("container of service %q", dependencyName), plugins.ProbeFunc(func(ctx context.Context) error {
+			inspect, err := l.client.InspectContainer(ctx, containerId)
+			if err != nil {
+				return err
+			}
+			if inspect.State.Status == "exited" || inspect.State.Status == "dead" {
+				return plugins.FatalProbeError{Err: fmt.Errorf("container is %s with exit code %d", inspect.State.Status, inspect.State.ExitCode)}
+			}
+			if inspect.State.Health == nil {
+				return plugins.FatalProbeError{Err: fmt.Errorf("container has no healthcheck")}
+			}
+			if inspect.State.Health.Status != "healthy" {
+				return fmt.Errorf("container is %s", inspect.State.Health.Status)
+			}
+			return nil
+		}), options)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dependenciesNames(service *docker_compose.DockerComposeService) []string {
+	var res []string
+	for dependencyName := range service.DependsOn {
+		res = append(res, dependencyName)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// ensureImage builds image of service or pulls it if it is not exists. Missing image built from repository of framework is reported
+func (l *Launcher) ensureImage(ctx context.Context, serviceName string, service *docker_compose.DockerComposeService) (string, error) {
+	if service.Build.Context != "" {
+		// image name is unique per run, so parallel runs don't replace images of each other
+		image := l.projectName + "_" + serviceName
+		fmt.Printf("---> building image for service %q\n", serviceName)
+		contextDirectory := service.Build.Context
+		if !filepath.IsAbs(contextDirectory) {
+			contextDirectory = filepath.Join(l.tmpDirectory, contextDirectory)
+		}
+		err := l.client.BuildImage(ctx, serviceName, contextDirectory, BuildOptions{
+			Dockerfile: service.Build.Dockerfile,
+			Args:       service.Build.Args,
+			Target:     service.Build.Target,
+			Labels:     l.labels(),
+		}, image)
+		if err != nil {
+			return "", err
+		}
+		l.builtImages[image] = true
+		return image, nil
+	}
+
+	exists, err := l.client.ImageExists(ctx, service.Image)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		err = service.MissingLocalImageError()
+		if err != nil {
+			return "", err
+		}
+		fmt.Printf("---> pulling image %q for service %q\n", service.Image, serviceName)
+		err = l.client.PullImage(ctx, serviceName, service.Image)
+		if err != nil {
+			return "", err
+		}
+	}
+	return service.Image, nil
+}
+
+func (l *Launcher) containerConfig(serviceName string, image string, service *docker_compose.DockerComposeService) (*ContainerConfig, error) {
+	expandedUser, err := expandUserVariables(service.User)
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := splitShellWords(service.Command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command: %v", err)
+	}
+	entrypoint, err := splitShellWords(service.Entrypoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid entrypoint: %v", err)
+	}
+	containerConfig := ContainerConfig{
+		Image:      image,
+		Cmd:        cmd,
+		Entrypoint: entrypoint,
+		WorkingDir: service.WorkingDir,
+		User:       expandedUser,
+		Labels:     l.labels(),
+		HostConfig: HostConfig{
+			RestartPolicy: RestartPolicy{
+				Name: service.Restart,
+			},
+			NetworkMode: l.projectName + "_default",
+		},
+		NetworkingConfig: NetworkingConfig{
+			EndpointsConfig: map[string]EndpointConfig{
+				l.projectName + "_default": {
+					Aliases: append([]string{serviceName}, service.NetworkAliases()...),
+				},
+			},
+		},
+	}
+
+	// values from environment have priority over values from env files like in docker compose
+	environment, err := docker_compose.ReadEnvFiles(service.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range service.Environment {
+		environment[name] = value
+	}
+	for name, value := range environment {
+		containerConfig.Env = append(containerConfig.Env, name+"="+value)
+	}
+	sort.Strings(containerConfig.Env)
+
+	containerConfig.Labels[serviceLabel] = serviceName
+	containerConfig.HostConfig.NanoCpus = int64(service.Cpus * 1e9)
+	if service.MemLimit != "" {
+		containerConfig.HostConfig.Memory, err = application_config.ParseMemoryLimit(service.MemLimit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, port := range service.Ports {
+		hostPort, containerPort := "", port
+		if i := strings.LastIndex(port, ":"); i != -1 {
+			hostPort, containerPort = port[:i], port[i+1:]
+		}
+		if !strings.Contains(containerPort, "/") {
+			containerPort += "/tcp"
+		}
+		if containerConfig.ExposedPorts == nil {
+			containerConfig.ExposedPorts = make(map[string]struct{})
+			containerConfig.HostConfig.PortBindings = make(map[string][]PortBinding)
+		}
+		containerConfig.ExposedPorts[containerPort] = struct{}{}
+		containerConfig.HostConfig.PortBindings[containerPort] = append(containerConfig.HostConfig.PortBindings[containerPort], PortBinding{
+			HostPort: hostPort,
+		})
+	}
+
+	for _, volume := range service.Volumes {
+		// relative paths in docker compose are resolved relative to directory of docker-compose file
+		if !filepath.IsAbs(volume) && strings.HasPrefix(volume, ".") {
+			volume = filepath.Join(l.tmpDirectory, volume)
+		}
+		containerConfig.HostConfig.Binds = append(containerConfig.HostConfig.Binds, volume)
+	}
+
+	if service.Healthcheck != nil {
+		healthcheck, err := newHealthcheck(service.Healthcheck)
+		if err != nil {
+			return nil, fmt.Errorf("invalid healthcheck: %v", err)
+		}
+		containerConfig.Healthcheck = healthcheck
+	}
+
+	for _, tmpfs := range service.TmpFs {
+		if containerConfig.HostConfig.Tmpfs == nil {
+			containerConfig.HostConfig.Tmpfs = make(map[string]string)
+		}
+		containerConfig.HostConfig.Tmpfs[tmpfs] = ""
+	}
+	return &containerConfig, nil
+}
+
+// expandUserVariables expands ${UID} and ${GID} like docker-compose launcher does
+func expandUserVariables(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	// https://github.com/moby/moby/issues/3206#issuecomment-152682860
+	currentUser, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("unable to get current user info: %v", err)
+	}
+	return os.Expand(s, func(name string) string {
+		switch name {
+		case "UID":
+			return currentUser.Uid
+		case "GID":
+			return currentUser.Gid
+		}
+		return os.Getenv(name)
+	}), nil
+}
+
+// startupOrder sorts services so every service starts after its dependencies
+func startupOrder(services map[string]*docker_compose.DockerComposeService) ([]string, error) {
+	var servicesNames []string
+	for serviceName := range services {
+		servicesNames = append(servicesNames, serviceName)
+	}
+	sort.Strings(servicesNames)
+
+	var res []string
+	// 1 - visiting, 2 - visited
+	state := make(map[string]int)
+	var visit func(serviceName string) error
+	visit = func(serviceName string) error {
+		switch state[serviceName] {
+		case 1:
+			return fmt.Errorf("circular dependency of service %q", serviceName)
+		case 2:
+			return nil
+		}
+		service, ok := services[serviceName]
+		if !ok {
+			return fmt.Errorf("service %q not defined", serviceName)
+		}
+		state[serviceName] = 1
+		for _, dependency := range dependenciesNames(service) {
+			err := visit(dependency)
+			if err != nil {
+				return err
+			}
+		}
+		state[serviceName] = 2
+		res = append(res, serviceName)
+		return nil
+	}
+	for _, serviceName := range servicesNames {
+		err := visit(serviceName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// ContainersState returns state of container of every launched service
+func (l *Launcher) ContainersState(ctx context.Context) (map[string]ContainerState, error) {
+	containers := l.containersSnapshot()
+	res := make(map[string]ContainerState, len(containers))
+	for serviceName, containerId := range containers {
+		inspect, err := l.client.InspectContainer(ctx, containerId)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get state of service %q: %v", serviceName, err)
+		}
+		res[serviceName] = inspect.State
+	}
+	return res, nil
+}
+
+// checkContainersAlive returns error if some of containers are stopped or unhealthy
+func (l *Launcher) checkContainersAlive(ctx context.Context) error {
+	states, err := l.ContainersState(ctx)
+	if err != nil {
+		return err
+	}
+	var servicesNames []string
+	for serviceName := range states {
+		servicesNames = append(servicesNames, serviceName)
+	}
+	sort.Strings(servicesNames)
+	for _, serviceName := range servicesNames {
+		state := states[serviceName]
+		switch {
+		case state.Status == "exited" || state.Status == "dead" || state.Restarting:
+			// container restarted by restart policy is treated as died too, otherwise crash loop is waited until timeout
+			message := fmt.Sprintf("container of service %q is %s with exit code %d", serviceName, state.Status, state.ExitCode)
+			if state.OOMKilled {
+				message += " (killed by OOM)"
+			}
+			if state.Error != "" {
+				message += ": " + state.Error
+			}
+			return l.logs.WithTail(serviceName, fmt.Errorf("%s", message))
+		case state.Health != nil && state.Health.Status == "unhealthy":
+			return fmt.Errorf("container of service %q is unhealthy", serviceName)
+		}
+	}
+	return nil
+}
+
+// removeService stops and removes container of service if it is launched
+func (l *Launcher) removeService(serviceName string) error {
+	containerId, ok := l.container(serviceName)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	err := l.client.StopContainer(ctx, containerId, stopTimeout)
+	if err != nil && !IsNotFound(err) {
+		fmt.Printf("unable to stop service %q: %v\n", serviceName, err)
+	}
+	err = l.client.RemoveContainer(ctx, containerId)
+	if err != nil && !IsNotFound(err) {
+		return fmt.Errorf("unable to remove service %q: %v", serviceName, err)
+	}
+	l.mutex.Lock()
+	delete(l.containers, serviceName)
+	l.mutex.Unlock()
+	return nil
+}
+
+// Shutdown interrupts launch in progress and removes all services. It can be called from other goroutine
+func (l *Launcher) Shutdown() error {
+	l.mutex.Lock()
+	l.shutdownRequested = true
+	if l.cancelContext != nil {
+		l.cancelContext()
+		l.cancelContext = nil
+	}
+	l.mutex.Unlock()
+
+	l.launchMutex.Lock()
+	defer l.launchMutex.Unlock()
+	err := l.removeAll()
+	// launcher can be used again after shutdown
+	l.mutex.Lock()
+	l.shutdownRequested = false
+	l.mutex.Unlock()
+	return err
+}
+
+// removeAll removes all services and network. Should be called with locked launchMutex
+func (l *Launcher) removeAll() error {
+	for serviceName := range l.containersSnapshot() {
+		err := l.removeService(serviceName)
+		if err != nil {
+			return err
+		}
+	}
+	if l.cancelLogs != nil {
+		l.cancelLogs()
+		l.cancelLogs = nil
+	}
+	l.logsWaitGroup.Wait()
+	l.launchedConfig = nil
+	l.lastWritedFiles = nil
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if l.networkId != "" {
+		err := l.client.RemoveNetwork(ctx, l.networkId)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+		l.networkId = ""
+	}
+	for image := range l.builtImages {
+		err := l.client.RemoveImage(ctx, image)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+		delete(l.builtImages, image)
+	}
+	return nil
+}
+
+func (l *Launcher) isShutdownRequested() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.shutdownRequested
+}
+
+// launchContext cancels context of previous launch and returns context of new one. It returns false if shutdown is requested
+func (l *Launcher) launchContext() (context.Context, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.shutdownRequested {
+		return nil, false
+	}
+	if l.cancelContext != nil {
+		l.cancelContext()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancelContext = cancel
+	return ctx, true
+}
+
+func (l *Launcher) container(serviceName string) (string, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	containerId, ok := l.containers[serviceName]
+	return containerId, ok
+}
+
+func (l *Launcher) setContainer(serviceName string, containerId string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.containers == nil {
+		l.containers = make(map[string]string)
+	}
+	l.containers[serviceName] = containerId
+}
+
+func (l *Launcher) containersSnapshot() map[string]string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	res := make(map[string]string, len(l.containers))
+	for serviceName, containerId := range l.containers {
+		res[serviceName] = containerId
+	}
+	return res
+}
+
+func (l *Launcher) ApplicationsUrls() map[string]string {
+	return l.applicationsUrls
+}
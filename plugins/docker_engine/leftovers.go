@@ -0,0 +1,115 @@
+package docker_engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+const (
+	// projectLabel is unique for every run
+	projectLabel = "integration_framework.project"
+	// ownerLabel and pidLabel define process of framework which created resource
+	ownerLabel = "integration_framework.owner"
+	pidLabel   = "integration_framework.pid"
+)
+
+// owner is host and process of framework. Resources of runs with the same host and finished process are left from killed runs
+type owner struct {
+	host string
+	pid  int
+}
+
+func newOwner() owner {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return owner{
+		host: host,
+		pid:  os.Getpid(),
+	}
+}
+
+// labels returns labels of every resource of run
+func (l *Launcher) labels() map[string]string {
+	return map[string]string{
+		projectLabel: l.projectName,
+		ownerLabel:   l.owner.host,
+		pidLabel:     strconv.Itoa(l.owner.pid),
+	}
+}
+
+// isLeftover checks that resource was created by run of this host which process is finished
+func (l *Launcher) isLeftover(labels map[string]string) bool {
+	if labels[ownerLabel] != l.owner.host || labels[projectLabel] == l.projectName {
+		return false
+	}
+	pid, err := strconv.Atoi(labels[pidLabel])
+	if err != nil {
+		return false
+	}
+	return !isProcessAlive(pid)
+}
+
+func isProcessAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// signal 0 only checks existence of process, process of other user exists too
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || err == syscall.EPERM
+}
+
+// removeLeftovers removes containers, networks and images of previous runs that were not removed (for example if process was killed).
+// Resources of runs which are still running on this host or on other hosts using the same docker are kept
+func (l *Launcher) removeLeftovers() error {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	label := ownerLabel + "=" + l.owner.host
+	containers, err := l.client.ListContainers(ctx, label)
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if !l.isLeftover(container.Labels) {
+			continue
+		}
+		err = l.client.RemoveContainer(ctx, container.Id)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+	}
+	networks, err := l.client.ListNetworks(ctx, label)
+	if err != nil {
+		return err
+	}
+	for _, network := range networks {
+		if !l.isLeftover(network.Labels) {
+			continue
+		}
+		err = l.client.RemoveNetwork(ctx, network.Id)
+		if err != nil && !IsNotFound(err) {
+			return err
+		}
+	}
+	images, err := l.client.ListImages(ctx, label)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		if !l.isLeftover(image.Labels) {
+			continue
+		}
+		for _, tag := range image.RepoTags {
+			err = l.client.RemoveImage(ctx, tag)
+			if err != nil && !IsNotFound(err) {
+				return fmt.Errorf("unable to remove image left from previous run: %v", err)
+			}
+		}
+	}
+	return nil
+}
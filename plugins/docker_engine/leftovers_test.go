@@ -0,0 +1,66 @@
+package docker_engine
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+)
+
+func TestRemoveLeftovers(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	ctx := context.Background()
+	launcher := NewLauncher(os.TempDir(), client, nil)
+
+	// pid of finished process
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Skipf("unable to run process: %v", err)
+	}
+	deadPid := strconv.Itoa(cmd.Process.Pid)
+	alivePid := strconv.Itoa(os.Getpid())
+	host := launcher.owner.host
+
+	resources := map[string]map[string]string{
+		// killed run on this host
+		"killed": {projectLabel: "killed", ownerLabel: host, pidLabel: deadPid},
+		// run in progress on this host
+		"running": {projectLabel: "running", ownerLabel: host, pidLabel: alivePid},
+		// run of other host using the same docker
+		"other_host": {projectLabel: "other_host", ownerLabel: "other", pidLabel: deadPid},
+		// current run
+		"current": launcher.labels(),
+	}
+	for name, labels := range resources {
+		if _, err := client.CreateContainer(ctx, name, ContainerConfig{Image: name, Labels: labels}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.CreateNetwork(ctx, name, labels); err != nil {
+			t.Fatal(err)
+		}
+		fake.images[name] = ImageSummary{Id: "sha256:" + name, RepoTags: []string{name}, Labels: labels}
+	}
+
+	err := launcher.removeLeftovers()
+	if err != nil {
+		t.Fatalf("unable to remove leftovers: %v", err)
+	}
+
+	for name := range resources {
+		removed := name == "killed"
+		containerFound := false
+		for _, container := range fake.containers {
+			containerFound = containerFound || container.name == name
+		}
+		networkFound := false
+		for _, network := range fake.networks {
+			networkFound = networkFound || network.Name == name
+		}
+		_, imageFound := fake.images[name]
+		if containerFound == removed || networkFound == removed || imageFound == removed {
+			t.Errorf("expected resources of %q removed: %v, found container %v, network %v, image %v", name, removed, containerFound, networkFound, imageFound)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package docker_engine
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+)
+
+// FollowLogs streams stdout and stderr of container to w.
+// It returns when container stops or ctx is cancelled
+func (c *Client) FollowLogs(ctx context.Context, id string, w io.Writer) error {
+	resp, err := c.do(ctx, "GET", "/containers/"+url.PathEscape(id)+"/logs", url.Values{
+		"follow": {"1"},
+		"stdout": {"1"},
+		"stderr": {"1"},
+	}, nil, "")
+	if err != nil {
+		return fmt.Errorf("unable to get logs of container %q: %v", id, err)
+	}
+	defer resp.Body.Close()
+
+	err = demultiplexLogs(resp.Body, w)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("unable to read logs of container %q: %v", id, err)
+	}
+	return nil
+}
+
+// demultiplexLogs copies payload of frames of multiplexed stream (used for containers without tty) to w.
+// Every frame has header of 8 bytes: stream type, 3 zero bytes and big endian uint32 size of payload
+func demultiplexLogs(r io.Reader, w io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		_, err := io.ReadFull(r, header)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		size := binary.BigEndian.Uint32(header[4:])
+		_, err = io.CopyN(w, r, int64(size))
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package docker_engine
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestFollowLogs(t *testing.T) {
+	fake, client := newFakeDocker(t)
+	defer fake.server.Close()
+	ctx := context.Background()
+	id, err := client.CreateContainer(ctx, "app", ContainerConfig{Image: "app"})
+	if err != nil {
+		t.Fatalf("unable to create container: %v", err)
+	}
+	fake.logs = []fakeLogFrame{
+		{stream: 1, payload: "started\n"},
+		{stream: 2, payload: "warning: "},
+		{stream: 2, payload: "deprecated option\n"},
+		{stream: 1, payload: ""},
+		{stream: 1, payload: "stopped\n"},
+	}
+
+	var output bytes.Buffer
+	err = client.FollowLogs(ctx, id, &output)
+	if err != nil {
+		t.Fatalf("unable to follow logs: %v", err)
+	}
+	expected := "started\nwarning: deprecated option\nstopped\n"
+	if output.String() != expected {
+		t.Errorf("expected logs %q, got %q", expected, output.String())
+	}
+
+	err = client.FollowLogs(ctx, "missing", &output)
+	if err == nil {
+		t.Errorf("expected error for logs of missing container")
+	}
+}
+
+func TestDemultiplexLogsTruncatedFrame(t *testing.T) {
+	stream := []byte{1, 0, 0, 0, 0, 0, 0, 10, 'a', 'b'}
+	var output bytes.Buffer
+	err := demultiplexLogs(bytes.NewReader(stream), &output)
+	if err == nil {
+		t.Errorf("expected error for truncated frame, got output %q", output.String())
+	}
+}
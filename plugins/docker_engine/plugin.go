@@ -0,0 +1,16 @@
+package docker_engine
+
+import (
+	"fmt"
+	"integration_framework/plugins"
+)
+
+func init() {
+	plugins.DefineLauncher("docker engine", func(tmpDirectory string, logs *plugins.RunLogs) (plugins.ILauncher, error) {
+		client, err := NewClientFromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("unable to create docker client: %v", err)
+		}
+		return NewLauncher(tmpDirectory, client, logs), nil
+	})
+}
@@ -0,0 +1,80 @@
+package docker_engine
+
+import (
+	"fmt"
+	"strings"
+)
+
+// splitShellWords splits command like posix shell (and docker-compose) does: `sh -c "echo a b"` -> [sh -c echo a b].
+// Quotes and backslashes are supported, variables and other shell syntax are not expanded
+func splitShellWords(command string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	// word is started by quotes even if it is empty, like `""`
+	inWord := false
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case r == '\\':
+			inWord = true
+			if i+1 < len(runes) {
+				i++
+				// escaped newline continues line
+				if runes[i] != '\n' {
+					word.WriteRune(runes[i])
+				}
+			}
+		case r == '\'':
+			inWord = true
+			end := indexRune(runes, i+1, '\'')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated single quote in %q", command)
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			i = end
+		case r == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					break
+				}
+				// inside of double quotes backslash escapes only special characters
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\\\"$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated double quote in %q", command)
+			}
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
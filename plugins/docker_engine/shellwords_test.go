@@ -0,0 +1,44 @@
+package docker_engine
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected []string
+	}{
+		{``, nil},
+		{`   `, nil},
+		{`app serve --port 8080`, []string{"app", "serve", "--port", "8080"}},
+		{"app\tserve\n --debug", []string{"app", "serve", "--debug"}},
+		{`sh -c "echo a b"`, []string{"sh", "-c", "echo a b"}},
+		{`sh -c 'echo "a b"'`, []string{"sh", "-c", `echo "a b"`}},
+		{`echo "a \"quoted\" \$HOME \n"`, []string{"echo", `a "quoted" $HOME \n`}},
+		{`echo a\ b c\\d`, []string{"echo", "a b", `c\d`}},
+		{`echo "" ''`, []string{"echo", "", ""}},
+		{`echo pre"fix"'ed'`, []string{"echo", "prefixed"}},
+		{`echo 'a\b'`, []string{"echo", `a\b`}},
+	}
+	for _, testCase := range testCases {
+		actual, err := splitShellWords(testCase.command)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", testCase.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, testCase.expected) {
+			t.Errorf("%q: expected %q, actual %q", testCase.command, testCase.expected, actual)
+		}
+	}
+}
+
+func TestSplitShellWordsUnterminatedQuotes(t *testing.T) {
+	for _, command := range []string{`sh -c "echo`, `sh -c 'echo`} {
+		_, err := splitShellWords(command)
+		if err == nil {
+			t.Errorf("%q: expected error", command)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"integration_framework/plugins"
+	"net"
+	"net/url"
+)
+
+// IServiceWithExternalEndpoint is implemented by services which can work with already running instance instead of launched one
+type IServiceWithExternalEndpoint interface {
+	UseExternalEndpoint(endpoint *Endpoint) error
+	CheckReachable(ctx context.Context) error
+}
+
+// Endpoint is address of already running service (like DSN, url or path to directory)
+type Endpoint struct {
+	Address          string
+	AllowDestructive bool
+}
+
+// CheckDestructiveAllowed returns error if destructive preparer can not be applied to service with this endpoint.
+// Services launched by framework has no external endpoint (it is nil), so everything is allowed for them
+func (e *Endpoint) CheckDestructiveAllowed(preparerName string) error {
+	if e == nil || e.AllowDestructive {
+		return nil
+	}
+	return fmt.Errorf("destructive preparer %q refused for external service. set `external.allow_destructive: true` to allow it", preparerName)
+}
+
+// IServiceWithDestructivePreparers is implemented by services which preparers can remove or change data of external service
+type IServiceWithDestructivePreparers interface {
+	ExternalEndpoint() *Endpoint
+}
+
+// GuardPreparer refuses destructive prepares before any prepare of preparer is applied, if service uses external endpoint which does not allow them
+func GuardPreparer(service plugins.IService, preparer plugins.IServicePreparer) plugins.IServicePreparer {
+	serviceWithEndpoint, ok := service.(IServiceWithDestructivePreparers)
+	if !ok {
+		return preparer
+	}
+	destructivePreparer, ok := preparer.(plugins.IDestructivePreparer)
+	if !ok {
+		return preparer
+	}
+	return &guardedPreparer{
+		service:  serviceWithEndpoint,
+		preparer: destructivePreparer,
+	}
+}
+
+type guardedPreparer struct {
+	service  IServiceWithDestructivePreparers
+	preparer plugins.IDestructivePreparer
+}
+
+func (p guardedPreparer) PrepareService(variables map[string]interface{}) error {
+	for _, prepareName := range p.preparer.DestructivePrepares() {
+		err := p.service.ExternalEndpoint().CheckDestructiveAllowed(prepareName)
+		if err != nil {
+			return err
+		}
+	}
+	return p.preparer.PrepareService(variables)
+}
+
+// CheckTcpReachable checks that tcp connection to address like `host:port` can be established
+func CheckTcpReachable(ctx context.Context, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return fmt.Errorf("%s is not reachable: %v", address, err)
+	}
+	return conn.Close()
+}
+
+var defaultPorts = map[string]string{
+	"http":       "80",
+	"https":      "443",
+	"postgres":   "5432",
+	"postgresql": "5432",
+	"smtp":       "25",
+}
+
+// CheckUrlReachable checks that host of url is reachable by tcp. Default port of scheme used if url contains no port
+func CheckUrlReachable(ctx context.Context, rawUrl string) error {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("unable to parse url: %v", err)
+	}
+	port := parsedUrl.Port()
+	if port == "" {
+		port = defaultPorts[parsedUrl.Scheme]
+	}
+	if port == "" {
+		return fmt.Errorf("port not specified in url and there is no default port for scheme %q", parsedUrl.Scheme)
+	}
+	return CheckTcpReachable(ctx, net.JoinHostPort(parsedUrl.Hostname(), port))
+}
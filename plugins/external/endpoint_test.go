@@ -0,0 +1,93 @@
+package external
+
+import (
+	"integration_framework/plugins"
+	"strings"
+	"testing"
+)
+
+type fakeService struct {
+	plugins.IService
+	endpoint *Endpoint
+}
+
+func (s fakeService) ExternalEndpoint() *Endpoint {
+	return s.endpoint
+}
+
+type fakePreparer struct {
+	destructive []string
+	prepared    int
+}
+
+func (p *fakePreparer) PrepareService(variables map[string]interface{}) error {
+	p.prepared++
+	return nil
+}
+
+func (p *fakePreparer) DestructivePrepares() []string {
+	return p.destructive
+}
+
+func TestGuardPreparer(t *testing.T) {
+	cases := []struct {
+		name        string
+		endpoint    *Endpoint
+		destructive []string
+		err         string
+	}{
+		{
+			name:        "launched service",
+			endpoint:    nil,
+			destructive: []string{"clear", "exec"},
+		},
+		{
+			name:        "external service without destructive prepares",
+			endpoint:    &Endpoint{Address: "postgres://db"},
+			destructive: nil,
+		},
+		{
+			name:        "clear refused",
+			endpoint:    &Endpoint{Address: "postgres://db"},
+			destructive: []string{"clear"},
+			err:         `destructive preparer "clear" refused`,
+		},
+		{
+			name:        "exec refused",
+			endpoint:    &Endpoint{Address: "postgres://db"},
+			destructive: []string{"exec"},
+			err:         `destructive preparer "exec" refused`,
+		},
+		{
+			name:        "destructive allowed",
+			endpoint:    &Endpoint{Address: "postgres://db", AllowDestructive: true},
+			destructive: []string{"clear", "exec"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			preparer := &fakePreparer{destructive: c.destructive}
+			err := GuardPreparer(fakeService{endpoint: c.endpoint}, preparer).PrepareService(nil)
+			if c.err == "" {
+				if err != nil || preparer.prepared != 1 {
+					t.Errorf("expected service prepared, got %d prepares, %v", preparer.prepared, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("expected error %q, got %v", c.err, err)
+			}
+			if preparer.prepared != 0 {
+				t.Errorf("expected nothing prepared after refuse, got %d prepares", preparer.prepared)
+			}
+		})
+	}
+}
+
+func TestGuardPreparerOfServiceWithoutDestructivePreparers(t *testing.T) {
+	preparer := &fakePreparer{destructive: []string{"clear"}}
+	guarded := GuardPreparer(struct{ plugins.IService }{}, preparer)
+	if guarded != preparer {
+		t.Errorf("expected preparer of service without external endpoint not wrapped, got %#v", guarded)
+	}
+}
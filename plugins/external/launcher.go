@@ -0,0 +1,138 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"integration_framework/application_config"
+	"integration_framework/plugins"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const reachabilityTimeout = 10 * time.Second
+
+var envNameRegexp = regexp.MustCompile(`[^A-Z0-9]+`)
+
+func NewLauncher() *Launcher {
+	return &Launcher{}
+}
+
+// Launcher starts nothing. It uses already running services which endpoints are defined in config or environment
+type Launcher struct {
+	applicationsUrls map[string]string
+}
+
+func (l *Launcher) ConfigUpdated(config *application_config.Config, services map[string]plugins.IService) error {
+	externalConfig := config.External
+	if externalConfig == nil {
+		externalConfig = &application_config.ExternalConfig{}
+	}
+	allowDestructive := externalConfig.AllowDestructive
+	if value := os.Getenv("EXTERNAL_ALLOW_DESTRUCTIVE"); value != "" {
+		var err error
+		allowDestructive, err = strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("unable to parse EXTERNAL_ALLOW_DESTRUCTIVE: %v", err)
+		}
+	}
+
+	var servicesNames []string
+	for serviceName := range services {
+		servicesNames = append(servicesNames, serviceName)
+	}
+	sort.Strings(servicesNames)
+
+	ctx, cancel := context.WithTimeout(context.Background(), reachabilityTimeout)
+	defer cancel()
+	for _, serviceName := range servicesNames {
+		service, ok := services[serviceName].(IServiceWithExternalEndpoint)
+		if !ok {
+			return fmt.Errorf("service %q doesnt support external endpoints", serviceName)
+		}
+		address := externalConfig.Services[serviceName]
+		envName := serviceEnvName(serviceName)
+		if address == "" {
+			address = os.Getenv(envName)
+		}
+		if address == "" {
+			return fmt.Errorf("endpoint of service %q not defined in external.services or %s", serviceName, envName)
+		}
+		err := service.UseExternalEndpoint(&Endpoint{
+			Address:          address,
+			AllowDestructive: allowDestructive,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to use external endpoint for service %q: %v", serviceName, err)
+		}
+		err = service.CheckReachable(ctx)
+		if err != nil {
+			return fmt.Errorf("service %q is not reachable: %v", serviceName, err)
+		}
+	}
+
+	l.applicationsUrls = make(map[string]string)
+	for _, applicationName := range config.ApplicationsNames() {
+		applicationUrl, err := externalApplicationUrl(externalConfig, applicationName, config.Applications[applicationName])
+		if err != nil {
+			return err
+		}
+		err = CheckUrlReachable(ctx, applicationUrl)
+		if err != nil {
+			return fmt.Errorf("application %q is not reachable: %v", applicationName, err)
+		}
+		l.applicationsUrls[applicationName] = applicationUrl
+	}
+	return nil
+}
+
+// externalApplicationUrl returns url of application from config or environment.
+// if it is not defined then scheme and host of request_defaults.url are used
+func externalApplicationUrl(externalConfig *application_config.ExternalConfig, applicationName string, application *application_config.ApplicationConfig) (string, error) {
+	applicationUrl := externalConfig.Applications[applicationName]
+	envName := applicationEnvName(applicationName)
+	if applicationUrl == "" {
+		applicationUrl = os.Getenv(envName)
+	}
+	if applicationUrl == "" && applicationName == application_config.DefaultApplicationName {
+		applicationUrl = externalConfig.ApplicationUrl
+		envName = "EXTERNAL_APPLICATION_URL"
+		if applicationUrl == "" {
+			applicationUrl = os.Getenv(envName)
+		}
+	}
+	if applicationUrl == "" {
+		// request_defaults.url can contain application url only if it is not templated
+		if strings.Contains(application.RequestDefaults.Url, "{{") {
+			return "", fmt.Errorf("url of application %q not defined in external config or %s", applicationName, envName)
+		}
+		parsedUrl, err := url.Parse(application.RequestDefaults.Url)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse request_defaults.url of application %q: %v", applicationName, err)
+		}
+		applicationUrl = parsedUrl.Scheme + "://" + parsedUrl.Host
+	}
+	return strings.TrimSuffix(applicationUrl, "/"), nil
+}
+
+func (l *Launcher) ApplicationsUrls() map[string]string {
+	return l.applicationsUrls
+}
+
+func (l *Launcher) Shutdown() error {
+	return nil
+}
+
+// serviceEnvName returns name of environment variable with endpoint of service, like `EXTERNAL_SERVICE_MAIN_DB` for service `main-db`
+func serviceEnvName(serviceName string) string {
+	return "EXTERNAL_SERVICE_" + strings.Trim(envNameRegexp.ReplaceAllString(strings.ToUpper(serviceName), "_"), "_")
+}
+
+// applicationEnvName returns name of environment variable with url of application, like `EXTERNAL_APPLICATION_URL_API_GATEWAY` for application `api-gateway`
+func applicationEnvName(applicationName string) string {
+	return "EXTERNAL_APPLICATION_URL_" + strings.Trim(envNameRegexp.ReplaceAllString(strings.ToUpper(applicationName), "_"), "_")
+}
@@ -0,0 +1,11 @@
+package external
+
+import (
+	"integration_framework/plugins"
+)
+
+func init() {
+	plugins.DefineLauncher("external", func(tmpDirectory string, logs *plugins.RunLogs) (plugins.ILauncher, error) {
+		return NewLauncher(), nil
+	})
+}
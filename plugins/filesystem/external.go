@@ -0,0 +1,34 @@
+package filesystem
+
+import (
+	"context"
+	"fmt"
+	"integration_framework/plugins/external"
+	"os"
+	"path/filepath"
+)
+
+// UseExternalEndpoint uses directory containing directories of mounts as endpoint
+func (s *Service) UseExternalEndpoint(endpoint *external.Endpoint) error {
+	s.externalEndpoint = endpoint
+	s.mountsRoot = endpoint.Address
+	return nil
+}
+
+func (s *Service) ExternalEndpoint() *external.Endpoint {
+	return s.externalEndpoint
+}
+
+func (s *Service) CheckReachable(ctx context.Context) error {
+	for _, mount := range s.mounts {
+		pathToMount := filepath.Join(s.mountsRoot, mount.Name)
+		info, err := os.Stat(pathToMount)
+		if err != nil {
+			return fmt.Errorf("unable to stat mount %q: %v", pathToMount, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("mount %q is not a directory", pathToMount)
+		}
+	}
+	return nil
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func init() {
-	plugins.DefineService("filesystem", func(serviceName string, port int, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
+	plugins.DefineService("filesystem", func(serviceName string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
 		paramsMounts, ok := params["mounts"].([]interface{})
 		if !ok {
 			return nil, fmt.Errorf("mounts should be list, but it is %T (%#v)", params["mounts"], params["mounts"])
@@ -7,7 +7,7 @@ import (
 )
 
 type IPrepare interface {
-	Prepare(mountsRoot string, mounts []Mount) error
+	Prepare(mountsRoot string, mounts []Mount, variables map[string]interface{}) error
 }
 
 func (s *Service) Preparer(param interface{}) (plugins.IServicePreparer, error) {
@@ -57,12 +57,23 @@ type Preparer struct {
 	prepares []IPrepare
 }
 
-func (ppc Preparer) PrepareService() error {
+func (ppc Preparer) PrepareService(variables map[string]interface{}) error {
 	for i, prepare := range ppc.prepares {
-		err := prepare.Prepare(ppc.service.mountsRoot, ppc.service.mounts)
+		err := prepare.Prepare(ppc.service.mountsRoot, ppc.service.mounts, variables)
 		if err != nil {
 			return fmt.Errorf("unable to prepare filesystem %d: %v", i, err)
 		}
 	}
 	return nil
 }
+
+// DestructivePrepares returns names of prepares refused for external service unless allowed
+func (ppc Preparer) DestructivePrepares() []string {
+	var names []string
+	for _, prepare := range ppc.prepares {
+		if _, ok := prepare.(*ClearPrepare); ok {
+			names = append(names, "clear")
+		}
+	}
+	return names
+}
@@ -13,7 +13,7 @@ func NewClearPrepare() *ClearPrepare {
 type ClearPrepare struct {
 }
 
-func (pp ClearPrepare) Prepare(mountsRoot string, mounts []Mount) error {
+func (pp ClearPrepare) Prepare(mountsRoot string, mounts []Mount, variables map[string]interface{}) error {
 	for _, mount := range mounts {
 		path := filepath.Join(mountsRoot, mount.Name)
 		d, err := os.Open(path)
@@ -2,6 +2,7 @@ package filesystem
 
 import (
 	"fmt"
+	"integration_framework/helper"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -19,15 +20,23 @@ type FilePrepare struct {
 	content  string
 }
 
-func (pp FilePrepare) Prepare(mountsRoot string, mounts []Mount) error {
-	pathToFile := filepath.Join(mountsRoot, pp.filename)
-	err := os.MkdirAll(filepath.Dir(pathToFile), os.ModePerm)
+func (pp FilePrepare) Prepare(mountsRoot string, mounts []Mount, variables map[string]interface{}) error {
+	filename, err := helper.ApplyInterpolation(pp.filename, variables)
+	if err != nil {
+		return fmt.Errorf("unable to interpolate %q: %v", pp.filename, err)
+	}
+	content, err := helper.ApplyInterpolation(pp.content, variables)
+	if err != nil {
+		return fmt.Errorf("unable to interpolate content of file %q: %v", filename, err)
+	}
+	pathToFile := filepath.Join(mountsRoot, filename)
+	err = os.MkdirAll(filepath.Dir(pathToFile), os.ModePerm)
 	if err != nil {
 		return fmt.Errorf("unable to create directories for file %q: %v", pathToFile, err)
 	}
-	fmt.Printf(".. create file %q with content %q\n", pp.filename, pp.content)
+	fmt.Printf(".. create file %q with content %q\n", filename, content)
 	// TODO единообразно логгировать работу всех preparer-ов и checker-ов чтобы по логу тестов можно было понять что упало и почему (баг внутри приложения или integration_framework)
-	err = ioutil.WriteFile(pathToFile, []byte(pp.content), os.ModePerm)
+	err = ioutil.WriteFile(pathToFile, []byte(content), os.ModePerm)
 	if err != nil {
 		return fmt.Errorf("unable to write file %q: %v", pathToFile, err)
 	}
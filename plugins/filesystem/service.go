@@ -1,7 +1,8 @@
 package filesystem
 
 import (
-	"context"
+	"integration_framework/plugins"
+	"integration_framework/plugins/external"
 )
 
 type Mount struct {
@@ -13,6 +14,8 @@ type Service struct {
 	name       string
 	mountsRoot string
 	mounts     []Mount
+	// defined if service is not launched by framework
+	externalEndpoint *external.Endpoint
 }
 
 func NewService(name string, mounts []Mount) *Service {
@@ -26,7 +29,7 @@ func (s *Service) Start() error {
 	return nil
 }
 
-func (s Service) WaitForPortAvailable(ctx context.Context) error {
+func (s *Service) ReadinessProbe() plugins.IProbe {
 	return nil
 }
 
@@ -91,15 +91,19 @@ func (r *GraphqlRequester) applyDefaults() {
 	}
 }
 
-func (r *GraphqlRequester) MakeRequest() (responseBody []byte, statusCode int, err error) {
+func (r *GraphqlRequester) MakeRequest(variables map[string]interface{}) (responseBody []byte, statusCode int, err error) {
 	r.applyDefaults()
+	url, err := helper.ApplyInterpolation(r.url, variables)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to interpolate url %q: %v", r.url, err)
+	}
 	payload, err := json.Marshal(map[string]interface{}{
 		"query": r.query,
 	})
 	if err != nil {
 		return nil, 0, fmt.Errorf("unable to marshal payload: %v", err)
 	}
-	request, err := http.NewRequest(r.method, r.url, bytes.NewReader(payload))
+	request, err := http.NewRequest(r.method, url, bytes.NewReader(payload))
 	if err != nil {
 		return nil, 0, fmt.Errorf("unable to create http request: %v", err)
 	}
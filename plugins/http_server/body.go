@@ -0,0 +1,193 @@
+package http_server
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+)
+
+// FnUnmarshal unmarshals body of call to value comparable with body defined in config. Content type is recorded header of call, it contains params like boundary
+type FnUnmarshal = func(body []byte, contentType string) (interface{}, error)
+
+// unmarshalerByContentType returns unmarshaler for media type of content type
+func unmarshalerByContentType(contentType string) (FnUnmarshal, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse content type %q: %v", contentType, err)
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return unmarshalJson, nil
+	case mediaType == "application/x-www-form-urlencoded":
+		return unmarshalForm, nil
+	case mediaType == "multipart/form-data":
+		return unmarshalMultipart, nil
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return unmarshalXml, nil
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/octet-stream":
+		return unmarshalRaw, nil
+	default:
+		return nil, fmt.Errorf("content type %q is not supported", contentType)
+	}
+}
+
+func unmarshalJson(body []byte, contentType string) (interface{}, error) {
+	var res interface{}
+	err := json.Unmarshal(body, &res)
+	return res, err
+}
+
+// unmarshalForm returns map of fields. Single value is stored as string, multiple values as list
+func unmarshalForm(body []byte, contentType string) (interface{}, error) {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	return flattenValues(values), nil
+}
+
+// unmarshalMultipart returns map of fields. Value of field is string or description of file like `{filename: a.txt, content_type: text/plain, size: 1, content: a, md5: ..., sha256: ...}`
+func unmarshalMultipart(body []byte, contentType string) (interface{}, error) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse content type %q: %v", contentType, err)
+	}
+	if params["boundary"] == "" {
+		return nil, fmt.Errorf("boundary not defined in content type %q", contentType)
+	}
+	res := make(map[string]interface{})
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read part: %v", err)
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read part %q: %v", part.FormName(), err)
+		}
+		var value interface{} = string(content)
+		if part.FileName() != "" {
+			file := describeContent(content)
+			file["filename"] = part.FileName()
+			file["content_type"] = part.Header.Get("Content-Type")
+			value = file
+		}
+		appendValue(res, part.FormName(), value)
+	}
+}
+
+// unmarshalXml returns map like `{root: {"@attribute": value, child: text, "#text": text}}`. Repeated elements are stored as list, namespaces are ignored
+func unmarshalXml(body []byte, contentType string) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("root element not found")
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		value, err := unmarshalXmlElement(decoder, start)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			start.Name.Local: value,
+		}, nil
+	}
+}
+
+// unmarshalXmlElement returns text of element without attributes and children or map otherwise
+func unmarshalXmlElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	res := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		res["@"+attr.Name.Local] = attr.Value
+	}
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read element %q: %v", start.Name.Local, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := unmarshalXmlElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			appendValue(res, t.Name.Local, child)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			trimmedText := strings.TrimSpace(text.String())
+			if len(res) == 0 {
+				return trimmedText, nil
+			}
+			if trimmedText != "" {
+				res["#text"] = trimmedText
+			}
+			return res, nil
+		}
+	}
+}
+
+// appendValue stores value by key. Values of repeated key are stored as list
+func appendValue(m map[string]interface{}, key string, value interface{}) {
+	existing, ok := m[key]
+	if !ok {
+		m[key] = value
+		return
+	}
+	list, ok := existing.([]interface{})
+	if !ok {
+		list = []interface{}{existing}
+	}
+	m[key] = append(list, value)
+}
+
+// rawBody is body of text or binary call. It is compared as string or as description of content if map is expected
+type rawBody []byte
+
+func unmarshalRaw(body []byte, contentType string) (interface{}, error) {
+	return rawBody(body), nil
+}
+
+func (b rawBody) comparableWith(expected interface{}) interface{} {
+	if _, ok := expected.(map[string]interface{}); ok {
+		return describeContent(b)
+	}
+	return string(b)
+}
+
+// describeContent returns content with its size and hashes to check binary content like `{size: 4, sha256: ...}`
+func describeContent(content []byte) map[string]interface{} {
+	md5Sum := md5.Sum(content)
+	sha256Sum := sha256.Sum256(content)
+	return map[string]interface{}{
+		"size":    len(content),
+		"content": string(content),
+		"md5":     hex.EncodeToString(md5Sum[:]),
+		"sha256":  hex.EncodeToString(sha256Sum[:]),
+	}
+}
@@ -0,0 +1,198 @@
+package http_server
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalerByContentType(t *testing.T) {
+	tests := []struct {
+		contentType   string
+		expectedBody  interface{}
+		expectedError string
+	}{
+		{contentType: "application/json; charset=utf-8", expectedBody: map[string]interface{}{"a": "1"}},
+		{contentType: "application/problem+json", expectedBody: map[string]interface{}{"a": "1"}},
+		{contentType: "application/x-www-form-urlencoded", expectedBody: map[string]interface{}{`{"a":"1"}`: ""}},
+		{contentType: "text/plain", expectedBody: rawBody(`{"a":"1"}`)},
+		{contentType: "application/octet-stream", expectedBody: rawBody(`{"a":"1"}`)},
+		{contentType: "image/png", expectedError: `content type "image/png" is not supported`},
+		{contentType: "text/", expectedError: `unable to parse content type "text/"`},
+	}
+	for _, test := range tests {
+		t.Run(test.contentType, func(t *testing.T) {
+			unmarshal, err := unmarshalerByContentType(test.contentType)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to get unmarshaler: %v", err)
+			}
+			body, err := unmarshal([]byte(`{"a":"1"}`), test.contentType)
+			if err != nil {
+				t.Fatalf("unable to unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(body, test.expectedBody) {
+				t.Errorf("expected %#v, got %#v", test.expectedBody, body)
+			}
+		})
+	}
+}
+
+func TestUnmarshalForm(t *testing.T) {
+	body, err := unmarshalForm([]byte("name=alice&tag=a&tag=b&empty=&encoded=a%20b%2Bc"), "application/x-www-form-urlencoded")
+	if err != nil {
+		t.Fatalf("unable to unmarshal: %v", err)
+	}
+	expected := map[string]interface{}{
+		"name":    "alice",
+		"tag":     []interface{}{"a", "b"},
+		"empty":   "",
+		"encoded": "a b+c",
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("expected %#v, got %#v", expected, body)
+	}
+	_, err = unmarshalForm([]byte("name=%zz"), "application/x-www-form-urlencoded")
+	if err == nil {
+		t.Errorf("expected error of invalid form")
+	}
+}
+
+func TestUnmarshalMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	for _, field := range [][2]string{{"name", "alice"}, {"tag", "a"}, {"tag", "b"}} {
+		err := writer.WriteField(field[0], field[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="a.txt"`)
+	header.Set("Content-Type", "text/plain")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("abc"))
+	err = writer.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := unmarshalMultipart(buf.Bytes(), writer.FormDataContentType())
+	if err != nil {
+		t.Fatalf("unable to unmarshal: %v", err)
+	}
+	expected := map[string]interface{}{
+		"name": "alice",
+		"tag":  []interface{}{"a", "b"},
+		"avatar": map[string]interface{}{
+			"filename":     "a.txt",
+			"content_type": "text/plain",
+			"size":         3,
+			"content":      "abc",
+			"md5":          "900150983cd24fb0d6963f7d28e17f72",
+			"sha256":       "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		},
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("expected %#v, got %#v", expected, body)
+	}
+
+	_, err = unmarshalMultipart(buf.Bytes(), "multipart/form-data")
+	if err == nil || !strings.Contains(err.Error(), "boundary not defined") {
+		t.Errorf("expected error of missing boundary, got %v", err)
+	}
+	_, err = unmarshalMultipart(buf.Bytes()[:buf.Len()/2], writer.FormDataContentType())
+	if err == nil {
+		t.Errorf("expected error of truncated body")
+	}
+}
+
+func TestUnmarshalXml(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expected      interface{}
+		expectedError string
+	}{
+		{
+			name:     "text",
+			body:     `<?xml version="1.0"?><name> alice </name>`,
+			expected: map[string]interface{}{"name": "alice"},
+		},
+		{
+			name: "attributes and repeated elements",
+			body: `<order id="1" xmlns="urn:orders" xmlns:x="urn:x">
+				<item sku="a">first</item>
+				<item sku="b"/>
+				<x:comment>fast</x:comment>
+			</order>`,
+			expected: map[string]interface{}{
+				"order": map[string]interface{}{
+					"@id": "1",
+					"item": []interface{}{
+						map[string]interface{}{"@sku": "a", "#text": "first"},
+						map[string]interface{}{"@sku": "b"},
+					},
+					"comment": "fast",
+				},
+			},
+		},
+		{
+			name:     "mixed content",
+			body:     `<p>hello <b>world</b></p>`,
+			expected: map[string]interface{}{"p": map[string]interface{}{"b": "world", "#text": "hello"}},
+		},
+		{
+			name:          "empty",
+			body:          `<!-- comment -->`,
+			expectedError: "root element not found",
+		},
+		{
+			name:          "unclosed",
+			body:          `<order><item>`,
+			expectedError: `unable to read element "item"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := unmarshalXml([]byte(test.body), "application/xml")
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(body, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, body)
+			}
+		})
+	}
+}
+
+func TestRawBodyComparableWith(t *testing.T) {
+	body := rawBody("abc")
+	if res := body.comparableWith("abc"); res != "abc" {
+		t.Errorf("expected string body, got %#v", res)
+	}
+	described, ok := body.comparableWith(map[string]interface{}{"size": 3}).(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected description of body")
+	}
+	if described["size"] != 3 || described["content"] != "abc" || described["md5"] != "900150983cd24fb0d6963f7d28e17f72" {
+		t.Errorf("unexpected description %#v", described)
+	}
+}
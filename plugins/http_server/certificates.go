@@ -0,0 +1,171 @@
+package http_server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+const certificatesValidity = 365 * 24 * time.Hour
+
+// certificateAuthority is generated once per run of framework. It signs certificates of host names intercepted by http mocks
+type certificateAuthority struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPem []byte
+
+	mutex sync.Mutex
+	// certificates are generated once to not change configs of mocks, by host name
+	hostCertificates map[string]hostCertificate
+}
+
+type hostCertificate struct {
+	certPem []byte
+	keyPem  []byte
+}
+
+var (
+	caOnce sync.Once
+	ca     *certificateAuthority
+	caErr  error
+)
+
+func getCertificateAuthority() (*certificateAuthority, error) {
+	caOnce.Do(func() {
+		ca, caErr = newCertificateAuthority()
+	})
+	return ca, caErr
+}
+
+func newCertificateAuthority() (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate key of certificate authority: %v", err)
+	}
+	template, err := certificateTemplate()
+	if err != nil {
+		return nil, err
+	}
+	template.Subject = pkix.Name{
+		CommonName:   "integration_framework CA",
+		Organization: []string{"integration_framework"},
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	certDer, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create certificate of certificate authority: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDer)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse certificate of certificate authority: %v", err)
+	}
+	return &certificateAuthority{
+		cert:             cert,
+		key:              key,
+		certPem:          pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer}),
+		hostCertificates: make(map[string]hostCertificate),
+	}, nil
+}
+
+func certificateTemplate() (*x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate serial number of certificate: %v", err)
+	}
+	// certificate is valid a bit before now to not depend on clock of containers
+	notBefore := time.Now().Add(-time.Hour)
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(certificatesValidity),
+	}, nil
+}
+
+// hostCertificate returns certificate of host name signed by certificate authority
+func (ca *certificateAuthority) hostCertificate(hostname string) (hostCertificate, error) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+	if certificate, ok := ca.hostCertificates[hostname]; ok {
+		return certificate, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return hostCertificate{}, fmt.Errorf("unable to generate key: %v", err)
+	}
+	template, err := certificateTemplate()
+	if err != nil {
+		return hostCertificate{}, err
+	}
+	template.Subject = pkix.Name{
+		CommonName: hostname,
+	}
+	template.DNSNames = []string{hostname}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	certDer, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return hostCertificate{}, fmt.Errorf("unable to create certificate: %v", err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return hostCertificate{}, fmt.Errorf("unable to marshal key: %v", err)
+	}
+	certificate := hostCertificate{
+		certPem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDer}),
+		keyPem:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+	}
+	ca.hostCertificates[hostname] = certificate
+	return certificate, nil
+}
+
+// hashedName returns name of certificate of certificate authority in directory of trusted certificates like `c_rehash` does.
+// OpenSSL looks up certificates in directories from SSL_CERT_DIR by hash of subject
+func (ca *certificateAuthority) hashedName() (string, error) {
+	var subject pkix.RDNSequence
+	_, err := asn1.Unmarshal(ca.cert.RawSubject, &subject)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse subject of certificate authority: %v", err)
+	}
+	// canonical encoding of subject is sequence of its sets without wrapping sequence, string values are lowercased utf8 strings
+	var canonicalSubject []byte
+	for _, rdn := range subject {
+		var attributes []byte
+		for _, attribute := range rdn {
+			value, ok := attribute.Value.(string)
+			if !ok {
+				return "", fmt.Errorf("unsupported value of attribute %v of subject: %#v", attribute.Type, attribute.Value)
+			}
+			attributeBytes, err := asn1.Marshal(struct {
+				Type  asn1.ObjectIdentifier
+				Value asn1.RawValue
+			}{
+				Type:  attribute.Type,
+				Value: asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(strings.ToLower(strings.Join(strings.Fields(value), " ")))},
+			})
+			if err != nil {
+				return "", fmt.Errorf("unable to marshal attribute of subject: %v", err)
+			}
+			attributes = append(attributes, attributeBytes...)
+		}
+		rdnBytes, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: attributes})
+		if err != nil {
+			return "", fmt.Errorf("unable to marshal subject: %v", err)
+		}
+		canonicalSubject = append(canonicalSubject, rdnBytes...)
+	}
+	hash := sha1.Sum(canonicalSubject)
+	return fmt.Sprintf("%08x.0", binary.LittleEndian.Uint32(hash[:4])), nil
+}
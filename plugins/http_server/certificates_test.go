@@ -0,0 +1,77 @@
+package http_server
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHostCertificate(t *testing.T) {
+	ca, err := newCertificateAuthority()
+	if err != nil {
+		t.Fatalf("unable to create certificate authority: %v", err)
+	}
+	certificate, err := ca.hostCertificate("api.partner.com")
+	if err != nil {
+		t.Fatalf("unable to create certificate: %v", err)
+	}
+	block, _ := pem.Decode(certificate.certPem)
+	if block == nil {
+		t.Fatalf("certificate is not pem: %s", certificate.certPem)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("unable to parse certificate: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.certPem)
+	_, err = cert.Verify(x509.VerifyOptions{DNSName: "api.partner.com", Roots: roots})
+	if err != nil {
+		t.Errorf("certificate is not trusted: %v", err)
+	}
+	sameCertificate, err := ca.hostCertificate("api.partner.com")
+	if err != nil {
+		t.Fatalf("unable to get certificate: %v", err)
+	}
+	if string(sameCertificate.certPem) != string(certificate.certPem) {
+		t.Errorf("expected certificate of host name is generated once")
+	}
+}
+
+func TestHashedName(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl is not installed")
+	}
+	ca, err := newCertificateAuthority()
+	if err != nil {
+		t.Fatalf("unable to create certificate authority: %v", err)
+	}
+	hashedName, err := ca.hashedName()
+	if err != nil {
+		t.Fatalf("unable to get hashed name: %v", err)
+	}
+	directory, err := ioutil.TempDir("", "certificates")
+	if err != nil {
+		t.Fatalf("unable to create directory: %v", err)
+	}
+	defer os.RemoveAll(directory)
+	pathToCa := filepath.Join(directory, "ca.crt")
+	err = ioutil.WriteFile(pathToCa, ca.certPem, 0644)
+	if err != nil {
+		t.Fatalf("unable to write certificate: %v", err)
+	}
+	output, err := exec.Command(openssl, "x509", "-noout", "-subject_hash", "-in", pathToCa).Output()
+	if err != nil {
+		t.Fatalf("unable to get hash of subject by openssl: %v", err)
+	}
+	expected := strings.TrimSpace(string(output)) + ".0"
+	if hashedName != expected {
+		t.Errorf("expected hashed name %q, got %q", expected, hashedName)
+	}
+}
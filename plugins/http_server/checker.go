@@ -1,26 +1,16 @@
 package http_server
 
 import (
-	"encoding/json"
 	"fmt"
 	"integration_framework/helper"
 	"integration_framework/plugins"
+	"integration_framework/testing"
 )
 
 type ICheck interface {
 	Check(serviceUrl string, variables map[string]interface{}) error
 }
 
-type FnUnmarshal = func(data []byte, dst interface{}) error
-
-type CheckCall struct {
-	// unmarshal is method based on body content type to unmarshal string body to be comparable with body defined in config
-	unmarshal FnUnmarshal
-
-	Route string
-	Body  interface{}
-}
-
 func (s *Service) Checker(checkConfig interface{}) (plugins.IServiceChecker, error) {
 	checkYaml, ok := helper.IsYamlMap(checkConfig)
 	if !ok {
@@ -31,36 +21,15 @@ func (s *Service) Checker(checkConfig interface{}) (plugins.IServiceChecker, err
 	for action, value := range check {
 		switch action {
 		case "calls":
-			callsArr, ok := value.([]interface{})
-			if !ok {
-				return nil, fmt.Errorf("calls should be array")
+			if testing.IsSnapshotDefinition(value) {
+				checks = append(checks, NewCallsSnapshotCheck(value))
+				break
 			}
-			var calls []CheckCall
-			for _, callsDefinitionInterface := range callsArr {
-				callDefinitionMap, ok := callsDefinitionInterface.(map[string]interface{})
-				if !ok {
-					return nil, fmt.Errorf("calls definition should be map")
-				}
-				for route, callDefinitionInterface := range callDefinitionMap {
-					callDefinition, ok := callDefinitionInterface.(map[string]interface{})
-					if !ok {
-						return nil, fmt.Errorf("call definition should be map")
-					}
-					var unmarshal FnUnmarshal
-					switch callDefinition["content_type"] {
-					case "application/json":
-						unmarshal = json.Unmarshal
-					default:
-						return nil, fmt.Errorf("content_type for body should be defined")
-					}
-					calls = append(calls, CheckCall{
-						unmarshal: unmarshal,
-						Route:     route,
-						Body:      callDefinition["body"],
-					})
-				}
+			callsCheck, err := newCallsCheck(value)
+			if err != nil {
+				return nil, err
 			}
-			checks = append(checks, NewCallsCheck(calls))
+			checks = append(checks, callsCheck)
 		default:
 			return nil, fmt.Errorf("invalid http check action %q", action)
 		}
@@ -78,7 +47,7 @@ type CheckConfig struct {
 
 func (hcc CheckConfig) CheckService(saveResult plugins.FnResultSaver, variables map[string]interface{}) error {
 	for i, check := range hcc.checks {
-		err := check.Check(fmt.Sprintf("http://localhost:%d/", hcc.service.port), variables)
+		err := check.Check(hcc.service.Url(), variables)
 		if err != nil {
 			return fmt.Errorf("unable to check http %d: %v", i, err)
 		}
@@ -3,63 +3,282 @@ package http_server
 import (
 	"encoding/json"
 	"fmt"
-	"integration_framework/helper"
-	"integration_framework/testing"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
-func NewCallsCheck(calls []CheckCall) *CallsCheck {
+func NewCallsCheck(calls []CheckCall, ordered bool) *CallsCheck {
 	return &CallsCheck{
-		calls: calls,
+		calls:   calls,
+		ordered: ordered,
 	}
 }
 
+// CallsCheck checks that every expected call pattern matched required count of calls and there are no other calls
 type CallsCheck struct {
 	calls []CheckCall
+	// actual calls should be in the same order as patterns
+	ordered bool
+}
+
+// newCallsCheck creates check from list of calls (ordered) or from map with key `ordered` or `unordered` containing list of calls
+func newCallsCheck(value interface{}) (*CallsCheck, error) {
+	ordered := true
+	if valueMap, ok := value.(map[string]interface{}); ok {
+		if len(valueMap) != 1 {
+			return nil, fmt.Errorf("calls should contain one of keys ordered or unordered")
+		}
+		for key, list := range valueMap {
+			switch key {
+			case "ordered":
+			case "unordered":
+				ordered = false
+			default:
+				return nil, fmt.Errorf("calls should contain one of keys ordered or unordered, but it contains %q", key)
+			}
+			value = list
+		}
+	}
+	callsArr, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("calls should be array")
+	}
+	var calls []CheckCall
+	for _, callsDefinitionInterface := range callsArr {
+		callDefinitionMap, ok := callsDefinitionInterface.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("calls definition should be map")
+		}
+		for route, callDefinitionInterface := range callDefinitionMap {
+			call, err := newCheckCall(route, callDefinitionInterface)
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, *call)
+		}
+	}
+	return NewCallsCheck(calls, ordered), nil
 }
 
 type ActualCall struct {
-	Route string `json:"route"`
-	Body  string `json:"body"`
+	Route   string      `json:"route"`
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Query   url.Values  `json:"query"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body"`
 }
 
-func (c CallsCheck) Check(serviceUrl string, variables map[string]interface{}) error {
+func getActualCalls(serviceUrl string) ([]ActualCall, error) {
 	resp, err := http.Get(serviceUrl + "__calls")
 	if err != nil {
-		return fmt.Errorf("unable to send request: %v", err)
+		return nil, fmt.Errorf("unable to send request: %v", err)
 	}
 	defer resp.Body.Close()
 	respBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("unable to read response body: %v", err)
+		return nil, fmt.Errorf("unable to read response body: %v", err)
 	}
 	var actualCalls []ActualCall
 	err = json.Unmarshal(respBody, &actualCalls)
 	if err != nil {
-		return fmt.Errorf("unable to unmarshal response body: %v", err)
+		return nil, fmt.Errorf("unable to unmarshal response body: %v", err)
 	}
-	if len(actualCalls) != len(c.calls) {
-		return fmt.Errorf("different calls count: actual is %d, expected is %d", len(actualCalls), len(c.calls))
+	return actualCalls, nil
+}
+
+func (c CallsCheck) Check(serviceUrl string, variables map[string]interface{}) error {
+	actualCalls, err := getActualCalls(serviceUrl)
+	if err != nil {
+		return err
 	}
-	for i, check := range c.calls {
-		actualCall := actualCalls[i]
-		var parsedActualBody interface{}
-		err := check.unmarshal([]byte(actualCall.Body), &parsedActualBody)
+	expectedCalls := make([]expectedCall, len(c.calls))
+	for i, call := range c.calls {
+		expected, err := call.prepare(variables)
 		if err != nil {
-			return fmt.Errorf("check #%d failed: unable to parse actual call body: %v", i, err)
+			return fmt.Errorf("unable to prepare call #%d %q: %v", i, call.Route, err)
 		}
-		if actualCall.Route != check.Route {
-			return fmt.Errorf("check #%d failed: route not matched. expcted %q, actual %q", i, check.Route, actualCall.Route)
+		expectedCalls[i] = *expected
+	}
+	var matched []int
+	var unexpected []int
+	if c.ordered {
+		matched, unexpected = matchOrdered(expectedCalls, actualCalls)
+	} else {
+		matched, unexpected = matchUnordered(expectedCalls, actualCalls)
+	}
+
+	var mismatches []string
+	for i, expected := range expectedCalls {
+		if matched[i] >= expected.min {
+			continue
 		}
-		checkBody, err := helper.ApplyInterpolationForObject(check.Body, variables)
-		if err != nil {
-			return fmt.Errorf("unable to apply interpolation: %v", err)
+		description := fmt.Sprintf("  #%d %s: expected %s, matched %d", i, expected.Route, expected.describeCount(), matched[i])
+		reason := mismatchReason(expected, actualCalls, unexpected)
+		if reason != nil {
+			description += fmt.Sprintf(" (%v)", reason)
 		}
-		err = testing.IsEqual(parsedActualBody, checkBody)
-		if err != nil {
-			return fmt.Errorf("check #%d failed: body not matched: %v", i, err)
+		mismatches = append(mismatches, description)
+	}
+	if len(mismatches) != 0 {
+		mismatches = append([]string{"expected calls not found:"}, mismatches...)
+	}
+	if len(unexpected) != 0 {
+		mismatches = append(mismatches, "unexpected calls:")
+		for _, i := range unexpected {
+			mismatches = append(mismatches, fmt.Sprintf("  #%d %s", i, describeActualCall(actualCalls[i])))
+		}
+	}
+	if len(mismatches) != 0 {
+		return fmt.Errorf("calls not matched:\n%s", strings.Join(mismatches, "\n"))
+	}
+	return nil
+}
+
+// matchOrdered matches calls by patterns in order of patterns: every pattern matches following calls, count of them should be between its min and max.
+// If calls can't be split between patterns this way, every pattern takes following calls until first call not matched by it or until its max count,
+// and calls left are unexpected. Returns count of matched calls for every pattern and indexes of unexpected calls
+func matchOrdered(expectedCalls []expectedCall, actualCalls []ActualCall) (matched []int, unexpected []int) {
+	matches := matchesOfPatterns(expectedCalls, actualCalls)
+	matched, ok := splitOrdered(expectedCalls, matches, len(actualCalls))
+	if ok {
+		return matched, nil
+	}
+	matched = make([]int, len(expectedCalls))
+	position := 0
+	for i, expected := range expectedCalls {
+		for position < len(actualCalls) && matched[i] != expected.max && matches[i][position] {
+			matched[i]++
+			position++
+		}
+	}
+	for ; position < len(actualCalls); position++ {
+		unexpected = append(unexpected, position)
+	}
+	return matched, unexpected
+}
+
+// splitOrdered splits calls to consecutive groups matched by patterns with counts between min and max of patterns. Returns sizes of groups
+func splitOrdered(expectedCalls []expectedCall, matches [][]bool, callsCount int) ([]int, bool) {
+	// groups[i][j] is size of group of pattern i-1 if first i patterns match first j calls, or -1 if they can't match them
+	groups := make([][]int, len(expectedCalls)+1)
+	for i := range groups {
+		groups[i] = make([]int, callsCount+1)
+		for j := range groups[i] {
+			groups[i][j] = -1
+		}
+	}
+	groups[0][0] = 0
+	for i, expected := range expectedCalls {
+		for j := 0; j <= callsCount; j++ {
+			if groups[i][j] == -1 {
+				continue
+			}
+			for size := 0; j+size <= callsCount && (expected.max == unlimited || size <= expected.max); size++ {
+				if size > 0 && !matches[i][j+size-1] {
+					break
+				}
+				if size >= expected.min && groups[i+1][j+size] == -1 {
+					groups[i+1][j+size] = size
+				}
+			}
+		}
+	}
+	if groups[len(expectedCalls)][callsCount] == -1 {
+		return nil, false
+	}
+	matched := make([]int, len(expectedCalls))
+	for i, j := len(expectedCalls), callsCount; i > 0; i-- {
+		matched[i-1] = groups[i][j]
+		j -= groups[i][j]
+	}
+	return matched, true
+}
+
+// matchUnordered assigns calls to patterns matching them, so patterns match at least their min count of calls if it is possible,
+// and then as many calls as possible without exceeding max count. Calls are reassigned by augmenting paths, so broad pattern doesn't take call needed by narrow one
+func matchUnordered(expectedCalls []expectedCall, actualCalls []ActualCall) (matched []int, unexpected []int) {
+	matches := matchesOfPatterns(expectedCalls, actualCalls)
+	assigned := make([][]int, len(expectedCalls))
+	capacities := make([]int, len(expectedCalls))
+	for i, expected := range expectedCalls {
+		capacities[i] = expected.min
+	}
+	isAssigned := make([]bool, len(actualCalls))
+	assign := func() {
+		for j := range actualCalls {
+			if !isAssigned[j] {
+				isAssigned[j] = augmentCall(j, matches, assigned, capacities, make([]bool, len(expectedCalls)))
+			}
+		}
+	}
+	// min counts are satisfied first, assigned calls are kept assigned when capacities are increased up to max counts
+	assign()
+	for i, expected := range expectedCalls {
+		capacities[i] = expected.max
+		if expected.max == unlimited {
+			capacities[i] = len(actualCalls)
+		}
+	}
+	assign()
+	matched = make([]int, len(expectedCalls))
+	for i := range assigned {
+		matched[i] = len(assigned[i])
+	}
+	for j := range actualCalls {
+		if !isAssigned[j] {
+			unexpected = append(unexpected, j)
+		}
+	}
+	return matched, unexpected
+}
+
+// augmentCall assigns call to pattern matching it. If pattern is full, one of its calls is reassigned to other pattern
+func augmentCall(call int, matches [][]bool, assigned [][]int, capacities []int, visited []bool) bool {
+	for i := range assigned {
+		if visited[i] || !matches[i][call] {
+			continue
+		}
+		visited[i] = true
+		if len(assigned[i]) < capacities[i] {
+			assigned[i] = append(assigned[i], call)
+			return true
+		}
+		for k, other := range assigned[i] {
+			if augmentCall(other, matches, assigned, capacities, visited) {
+				assigned[i][k] = call
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesOfPatterns returns whether pattern i matches call j
+func matchesOfPatterns(expectedCalls []expectedCall, actualCalls []ActualCall) [][]bool {
+	matches := make([][]bool, len(expectedCalls))
+	for i, expected := range expectedCalls {
+		matches[i] = make([]bool, len(actualCalls))
+		for j, actual := range actualCalls {
+			matches[i][j] = expected.match(actual) == nil
+		}
+	}
+	return matches
+}
+
+// mismatchReason returns why unexpected call with the same route is not matched by pattern
+func mismatchReason(expected expectedCall, actualCalls []ActualCall, unexpected []int) error {
+	for _, i := range unexpected {
+		if !expected.matchRoute(actualCalls[i]) {
+			continue
+		}
+		err := expected.match(actualCalls[i])
+		if err == nil {
+			return fmt.Errorf("call #%d is matched, but it is out of order", i)
 		}
+		return fmt.Errorf("call #%d %v", i, err)
 	}
 	return nil
 }
@@ -0,0 +1,269 @@
+package http_server
+
+import (
+	"fmt"
+	"integration_framework/helper"
+	"integration_framework/testing"
+	"net/http"
+	"strings"
+)
+
+// unlimited is max count of calls matched by pattern with `at_least` only
+const unlimited = -1
+
+// CheckCall is pattern of expected call like `POST /path: {method: POST, query: {}, headers: {}, body: {}, times: 1}`
+type CheckCall struct {
+	// unmarshal is method based on declared body content type to unmarshal string body to be comparable with body defined in config. If it is not defined, recorded content type of call is used
+	unmarshal FnUnmarshal
+
+	Route   string
+	Method  string
+	Query   map[string]interface{}
+	Headers map[string]interface{}
+	Body    interface{}
+	// count of calls matched by pattern should be between min and max
+	min int
+	max int
+}
+
+func newCheckCall(route string, definitionInterface interface{}) (*CheckCall, error) {
+	definition, ok := definitionInterface.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("call definition should be map")
+	}
+	call := CheckCall{
+		Route: route,
+		min:   1,
+		max:   1,
+	}
+	for key, value := range definition {
+		var ok bool
+		switch key {
+		case "content_type", "times", "at_least", "at_most":
+			continue
+		case "body":
+			call.Body = value
+			ok = true
+		case "method":
+			call.Method, ok = value.(string)
+			call.Method = strings.ToUpper(call.Method)
+		case "query":
+			call.Query, ok = value.(map[string]interface{})
+		case "headers":
+			var headers map[string]interface{}
+			headers, ok = value.(map[string]interface{})
+			// headers are recorded with canonical names
+			call.Headers = make(map[string]interface{}, len(headers))
+			for name, headerValue := range headers {
+				call.Headers[http.CanonicalHeaderKey(name)] = headerValue
+			}
+		default:
+			return nil, fmt.Errorf("unknown field %q of call %q", key, route)
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid %s of call %q: %T (%#v)", key, route, value, value)
+		}
+	}
+	if contentType, ok := definition["content_type"]; ok {
+		contentTypeStr, ok := contentType.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid content_type of call %q: %T (%#v)", route, contentType, contentType)
+		}
+		var err error
+		call.unmarshal, err = unmarshalerByContentType(contentTypeStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content_type of call %q: %v", route, err)
+		}
+	}
+	err := call.parseCount(definition)
+	if err != nil {
+		return nil, fmt.Errorf("invalid count of call %q: %v", route, err)
+	}
+	return &call, nil
+}
+
+// parseCount parses `times` or `at_least` and `at_most`. Call is expected exactly once by default
+func (c *CheckCall) parseCount(definition map[string]interface{}) error {
+	times, err := parseOptionalCount(definition, "times")
+	if err != nil {
+		return err
+	}
+	atLeast, err := parseOptionalCount(definition, "at_least")
+	if err != nil {
+		return err
+	}
+	atMost, err := parseOptionalCount(definition, "at_most")
+	if err != nil {
+		return err
+	}
+	if times != nil {
+		if atLeast != nil || atMost != nil {
+			return fmt.Errorf("times can not be used with at_least or at_most")
+		}
+		c.min, c.max = *times, *times
+		return nil
+	}
+	if atLeast != nil || atMost != nil {
+		c.min, c.max = 0, unlimited
+	}
+	if atLeast != nil {
+		c.min = *atLeast
+	}
+	if atMost != nil {
+		c.max = *atMost
+		if c.max < c.min {
+			return fmt.Errorf("at_most is less than at_least")
+		}
+	}
+	return nil
+}
+
+func parseOptionalCount(definition map[string]interface{}, key string) (*int, error) {
+	if definition[key] == nil {
+		return nil, nil
+	}
+	count, ok := definition[key].(int)
+	if !ok || count < 0 {
+		return nil, fmt.Errorf("%s should be non-negative integer, but it is %T (%#v)", key, definition[key], definition[key])
+	}
+	return &count, nil
+}
+
+func (c CheckCall) describeCount() string {
+	switch {
+	case c.min == c.max:
+		return fmt.Sprintf("%d call(s)", c.min)
+	case c.max == unlimited:
+		return fmt.Sprintf("at least %d call(s)", c.min)
+	case c.min == 0:
+		return fmt.Sprintf("at most %d call(s)", c.max)
+	default:
+		return fmt.Sprintf("from %d to %d call(s)", c.min, c.max)
+	}
+}
+
+// expectedCall is pattern of call with applied interpolation and converters
+type expectedCall struct {
+	CheckCall
+	query   interface{}
+	headers interface{}
+	body    interface{}
+}
+
+func (c CheckCall) prepare(variables map[string]interface{}) (*expectedCall, error) {
+	expected := expectedCall{
+		CheckCall: c,
+	}
+	var err error
+	expected.query, err = prepareExpectedValue(c.Query, variables)
+	if err != nil {
+		return nil, fmt.Errorf("query: %v", err)
+	}
+	expected.headers, err = prepareExpectedValue(c.Headers, variables)
+	if err != nil {
+		return nil, fmt.Errorf("headers: %v", err)
+	}
+	expected.body, err = prepareExpectedValue(c.Body, variables)
+	if err != nil {
+		return nil, fmt.Errorf("body: %v", err)
+	}
+	return &expected, nil
+}
+
+func prepareExpectedValue(value interface{}, variables map[string]interface{}) (interface{}, error) {
+	interpolated, err := helper.ApplyInterpolationForObject(value, variables)
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply interpolation: %v", err)
+	}
+	converted, err := testing.ApplyConverters(interpolated, variables)
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply converters: %v", err)
+	}
+	return converted, nil
+}
+
+// matchRoute checks route of call. Route of pattern can be equal to route of mock or to method and path of call
+func (c expectedCall) matchRoute(actual ActualCall) bool {
+	return c.Route == actual.Route || c.Route == actual.Method+" "+actual.Path || c.Route == actual.Path
+}
+
+// match returns reason why actual call is not matched by pattern
+func (c expectedCall) match(actual ActualCall) error {
+	if !c.matchRoute(actual) {
+		return fmt.Errorf("route not matched. expected %q, actual %q", c.Route, actual.Route)
+	}
+	if c.Method != "" && c.Method != actual.Method {
+		return fmt.Errorf("method not matched. expected %q, actual %q", c.Method, actual.Method)
+	}
+	if c.Query != nil {
+		err := testing.IsEqual(flattenValues(actual.Query), c.query)
+		if err != nil {
+			return fmt.Errorf("query not matched: %v", err)
+		}
+	}
+	if c.Headers != nil {
+		err := testing.IsEqual(flattenValues(actual.Headers), c.headers)
+		if err != nil {
+			return fmt.Errorf("headers not matched: %v", err)
+		}
+	}
+	if c.Body != nil {
+		parsedActualBody, err := c.unmarshalBody(actual)
+		if err != nil {
+			return fmt.Errorf("unable to parse actual call body: %v", err)
+		}
+		if raw, ok := parsedActualBody.(rawBody); ok {
+			parsedActualBody = raw.comparableWith(c.body)
+		}
+		err = testing.IsEqual(parsedActualBody, c.body)
+		if err != nil {
+			return fmt.Errorf("body not matched: %v", err)
+		}
+	}
+	return nil
+}
+
+func (c expectedCall) unmarshalBody(actual ActualCall) (interface{}, error) {
+	contentType := actual.Headers.Get("Content-Type")
+	unmarshal := c.unmarshal
+	if unmarshal == nil {
+		if contentType == "" {
+			return nil, fmt.Errorf("content_type is not defined and call has no Content-Type header")
+		}
+		var err error
+		unmarshal, err = unmarshalerByContentType(contentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return unmarshal([]byte(actual.Body), contentType)
+}
+
+// flattenValues converts query or headers to map comparable with config. Single value is stored as string, multiple values as list
+func flattenValues(values map[string][]string) map[string]interface{} {
+	res := make(map[string]interface{}, len(values))
+	for name, list := range values {
+		if len(list) == 1 {
+			res[name] = list[0]
+			continue
+		}
+		items := make([]interface{}, len(list))
+		for i, item := range list {
+			items[i] = item
+		}
+		res[name] = items
+	}
+	return res
+}
+
+// describeActualCall describes call in list of unexpected calls
+func describeActualCall(actual ActualCall) string {
+	description := actual.Method + " " + actual.Path
+	if len(actual.Query) != 0 {
+		description += "?" + actual.Query.Encode()
+	}
+	if actual.Body != "" {
+		description += fmt.Sprintf(" body %q", actual.Body)
+	}
+	return description
+}
@@ -0,0 +1,245 @@
+package http_server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testExpectedCalls(t *testing.T, definitions []map[string]interface{}) []expectedCall {
+	var expectedCalls []expectedCall
+	for _, definition := range definitions {
+		for route, callDefinition := range definition {
+			call, err := newCheckCall(route, callDefinition)
+			if err != nil {
+				t.Fatalf("unable to create call %q: %v", route, err)
+			}
+			expected, err := call.prepare(nil)
+			if err != nil {
+				t.Fatalf("unable to prepare call %q: %v", route, err)
+			}
+			expectedCalls = append(expectedCalls, *expected)
+		}
+	}
+	return expectedCalls
+}
+
+func testActualCall(method, path string, query url.Values) ActualCall {
+	return ActualCall{
+		Route:  method + " " + path,
+		Method: method,
+		Path:   path,
+		Query:  query,
+	}
+}
+
+var (
+	callA         = testActualCall("GET", "/a", nil)
+	callB         = testActualCall("GET", "/b", nil)
+	callX         = testActualCall("GET", "/x", nil)
+	callXQuery    = testActualCall("GET", "/x", url.Values{"a": {"1"}})
+	patternA      = map[string]interface{}{"GET /a": map[string]interface{}{}}
+	patternB      = map[string]interface{}{"GET /b": map[string]interface{}{}}
+	patternX      = map[string]interface{}{"GET /x": map[string]interface{}{"times": 1}}
+	patternXQuery = map[string]interface{}{"GET /x": map[string]interface{}{"times": 1, "query": map[string]interface{}{"a": "1"}}}
+)
+
+func countPattern(pattern map[string]interface{}, count map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(pattern))
+	for route := range pattern {
+		res[route] = count
+	}
+	return res
+}
+
+func TestMatchCalls(t *testing.T) {
+	cases := []struct {
+		name               string
+		ordered            bool
+		patterns           []map[string]interface{}
+		calls              []ActualCall
+		expectedMatched    []int
+		expectedUnexpected []int
+	}{
+		{
+			name:            "unordered narrow pattern after broad one",
+			patterns:        []map[string]interface{}{patternX, patternXQuery},
+			calls:           []ActualCall{callXQuery, callX},
+			expectedMatched: []int{1, 1},
+		},
+		{
+			name:            "unordered calls in any order",
+			patterns:        []map[string]interface{}{patternA, patternB},
+			calls:           []ActualCall{callB, callA},
+			expectedMatched: []int{1, 1},
+		},
+		{
+			name:               "unordered extra call",
+			patterns:           []map[string]interface{}{patternA},
+			calls:              []ActualCall{callA, callA, callB},
+			expectedMatched:    []int{1},
+			expectedUnexpected: []int{1, 2},
+		},
+		{
+			name:               "unordered missing call",
+			patterns:           []map[string]interface{}{patternA, patternXQuery},
+			calls:              []ActualCall{callX, callA},
+			expectedMatched:    []int{1, 0},
+			expectedUnexpected: []int{0},
+		},
+		{
+			name: "unordered min satisfied before max",
+			patterns: []map[string]interface{}{
+				countPattern(patternX, map[string]interface{}{"at_most": 2}),
+				countPattern(patternX, map[string]interface{}{"at_least": 1}),
+			},
+			calls:           []ActualCall{callX, callX},
+			expectedMatched: []int{1, 1},
+		},
+		{
+			name:            "ordered repeated pattern",
+			ordered:         true,
+			patterns:        []map[string]interface{}{countPattern(patternA, map[string]interface{}{"at_least": 1}), patternB, patternA},
+			calls:           []ActualCall{callA, callB, callA},
+			expectedMatched: []int{1, 1, 1},
+		},
+		{
+			name:            "ordered at least shares calls with following pattern",
+			ordered:         true,
+			patterns:        []map[string]interface{}{countPattern(patternA, map[string]interface{}{"at_least": 1}), patternA},
+			calls:           []ActualCall{callA, callA, callA},
+			expectedMatched: []int{2, 1},
+		},
+		{
+			name:            "ordered at most",
+			ordered:         true,
+			patterns:        []map[string]interface{}{countPattern(patternA, map[string]interface{}{"at_most": 2}), patternB},
+			calls:           []ActualCall{callB},
+			expectedMatched: []int{0, 1},
+		},
+		{
+			name:            "ordered times",
+			ordered:         true,
+			patterns:        []map[string]interface{}{countPattern(patternA, map[string]interface{}{"times": 2}), patternB},
+			calls:           []ActualCall{callA, callA, callB},
+			expectedMatched: []int{2, 1},
+		},
+		{
+			name:               "ordered pattern stops at first call not matched",
+			ordered:            true,
+			patterns:           []map[string]interface{}{patternA, patternB},
+			calls:              []ActualCall{callB, callA},
+			expectedMatched:    []int{0, 1},
+			expectedUnexpected: []int{1},
+		},
+		{
+			name:               "ordered too many calls",
+			ordered:            true,
+			patterns:           []map[string]interface{}{countPattern(patternA, map[string]interface{}{"at_most": 1})},
+			calls:              []ActualCall{callA, callA},
+			expectedMatched:    []int{1},
+			expectedUnexpected: []int{1},
+		},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			expectedCalls := testExpectedCalls(t, testCase.patterns)
+			var matched, unexpected []int
+			if testCase.ordered {
+				matched, unexpected = matchOrdered(expectedCalls, testCase.calls)
+			} else {
+				matched, unexpected = matchUnordered(expectedCalls, testCase.calls)
+			}
+			if !reflect.DeepEqual(matched, testCase.expectedMatched) {
+				t.Errorf("expected matched %v, actual %v", testCase.expectedMatched, matched)
+			}
+			if !reflect.DeepEqual(unexpected, testCase.expectedUnexpected) {
+				t.Errorf("expected unexpected %v, actual %v", testCase.expectedUnexpected, unexpected)
+			}
+		})
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	cases := []struct {
+		name          string
+		definition    map[string]interface{}
+		expectedMin   int
+		expectedMax   int
+		expectedError string
+	}{
+		{name: "default", definition: map[string]interface{}{}, expectedMin: 1, expectedMax: 1},
+		{name: "times", definition: map[string]interface{}{"times": 3}, expectedMin: 3, expectedMax: 3},
+		{name: "at least", definition: map[string]interface{}{"at_least": 2}, expectedMin: 2, expectedMax: unlimited},
+		{name: "at most", definition: map[string]interface{}{"at_most": 2}, expectedMin: 0, expectedMax: 2},
+		{name: "range", definition: map[string]interface{}{"at_least": 1, "at_most": 2}, expectedMin: 1, expectedMax: 2},
+		{name: "times with at least", definition: map[string]interface{}{"times": 1, "at_least": 1}, expectedError: "times can not be used with at_least or at_most"},
+		{name: "at most less than at least", definition: map[string]interface{}{"at_least": 2, "at_most": 1}, expectedError: "at_most is less than at_least"},
+		{name: "negative", definition: map[string]interface{}{"times": -1}, expectedError: "times should be non-negative integer"},
+	}
+	for _, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			call, err := newCheckCall("GET /a", testCase.definition)
+			if testCase.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+					t.Fatalf("expected error %q, actual %v", testCase.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to create call: %v", err)
+			}
+			if call.min != testCase.expectedMin || call.max != testCase.expectedMax {
+				t.Errorf("expected count from %d to %d, actual from %d to %d", testCase.expectedMin, testCase.expectedMax, call.min, call.max)
+			}
+		})
+	}
+}
+
+func TestCallsCheckMismatchReport(t *testing.T) {
+	actualCalls := []ActualCall{callB, callXQuery}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/__calls" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(actualCalls)
+	}))
+	defer server.Close()
+
+	check, err := newCallsCheck(map[string]interface{}{
+		"unordered": []interface{}{
+			countPattern(patternA, map[string]interface{}{"at_least": 1}),
+			map[string]interface{}{"GET /x": map[string]interface{}{"query": map[string]interface{}{"a": "2"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to create check: %v", err)
+	}
+	err = check.Check(server.URL+"/", nil)
+	if err == nil {
+		t.Fatalf("expected calls not matched")
+	}
+	expectedLines := []string{
+		"calls not matched:",
+		"expected calls not found:",
+		"  #0 GET /a: expected at least 1 call(s), matched 0",
+		"  #1 GET /x: expected 1 call(s), matched 0 (call #1 query not matched: ",
+		"unexpected calls:",
+		"  #0 GET /b",
+		"  #1 GET /x?a=1",
+	}
+	// reason of mismatch is followed by unexpected calls in order
+	report := err.Error()
+	for _, expectedLine := range expectedLines {
+		index := strings.Index(report, expectedLine)
+		if index == -1 {
+			t.Fatalf("expected report contains %q in order, actual:\n%s", expectedLine, err)
+		}
+		report = report[index+len(expectedLine):]
+	}
+}
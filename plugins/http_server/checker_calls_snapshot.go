@@ -0,0 +1,66 @@
+package http_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"integration_framework/helper"
+	"integration_framework/testing"
+)
+
+func NewCallsSnapshotCheck(snapshot interface{}) *CallsSnapshotCheck {
+	return &CallsSnapshotCheck{
+		snapshot: snapshot,
+	}
+}
+
+type CallsSnapshotCheck struct {
+	snapshot interface{}
+}
+
+func (c CallsSnapshotCheck) Check(serviceUrl string, variables map[string]interface{}) error {
+	actualCalls, err := getActualCalls(serviceUrl)
+	if err != nil {
+		return err
+	}
+	actual := make([]interface{}, len(actualCalls))
+	for i, actualCall := range actualCalls {
+		actual[i] = map[string]interface{}{
+			"route": actualCall.Route,
+			"body":  snapshotBody(actualCall),
+		}
+	}
+	snapshot, err := helper.ApplyInterpolationForObject(c.snapshot, variables)
+	if err != nil {
+		return fmt.Errorf("unable to apply interpolation: %v", err)
+	}
+	expected, err := testing.ApplyConverters(snapshot, variables)
+	if err != nil {
+		return fmt.Errorf("unable to apply converters: %v", err)
+	}
+	err = testing.IsEqual(actual, expected)
+	if err != nil {
+		return fmt.Errorf("calls not matched: %v", err)
+	}
+	return nil
+}
+
+// snapshotBody returns body parsed if it is json or has structured content type to make snapshots readable and allow to ignore paths inside body
+func snapshotBody(actualCall ActualCall) interface{} {
+	var parsedBody interface{}
+	if json.Unmarshal([]byte(actualCall.Body), &parsedBody) == nil {
+		return parsedBody
+	}
+	contentType := actualCall.Headers.Get("Content-Type")
+	if contentType == "" {
+		return actualCall.Body
+	}
+	unmarshal, err := unmarshalerByContentType(contentType)
+	if err != nil {
+		return actualCall.Body
+	}
+	parsedBody, err = unmarshal([]byte(actualCall.Body), contentType)
+	if _, ok := parsedBody.(rawBody); ok || err != nil {
+		return actualCall.Body
+	}
+	return parsedBody
+}
@@ -0,0 +1,59 @@
+package http_server
+
+import (
+	"fmt"
+	"integration_framework/plugins"
+	"integration_framework/plugins/http_server/openapi"
+	"strings"
+)
+
+func NewOpenapiChecker(service *Service) *OpenapiChecker {
+	return &OpenapiChecker{
+		service: service,
+	}
+}
+
+// OpenapiChecker checks that calls received during test are valid requests of OpenAPI spec of service
+type OpenapiChecker struct {
+	service *Service
+	// count of calls before request of test. Calls are not reset between tests if test doesn't reset them
+	callsBefore int
+}
+
+func (c *OpenapiChecker) BeforeRequest(variables map[string]interface{}) error {
+	actualCalls, err := getActualCalls(c.service.Url())
+	if err != nil {
+		return err
+	}
+	c.callsBefore = len(actualCalls)
+	return nil
+}
+
+func (c *OpenapiChecker) CheckService(saveResult plugins.FnResultSaver, variables map[string]interface{}) error {
+	actualCalls, err := getActualCalls(c.service.Url())
+	if err != nil {
+		return err
+	}
+	first := c.callsBefore
+	if len(actualCalls) < first {
+		// calls were reset after BeforeRequest
+		first = 0
+	}
+	var violations []string
+	for i, actualCall := range actualCalls[first:] {
+		callViolations := c.service.spec.ValidateRequest(openapi.Request{
+			Method:  actualCall.Method,
+			Path:    actualCall.Path,
+			Query:   actualCall.Query,
+			Headers: actualCall.Headers,
+			Body:    []byte(actualCall.Body),
+		})
+		for _, violation := range callViolations {
+			violations = append(violations, fmt.Sprintf("  call #%d %s %s: %s", first+i, actualCall.Method, actualCall.Path, violation))
+		}
+	}
+	if len(violations) != 0 {
+		return fmt.Errorf("calls of http %q violate openapi spec:\n%s", c.service.name, strings.Join(violations, "\n"))
+	}
+	return nil
+}
@@ -4,40 +4,68 @@ import (
 	"encoding/json"
 	"fmt"
 	"integration_framework/helper"
+	"integration_framework/http_mock"
 	"integration_framework/plugins/docker_compose"
-	"path/filepath"
 )
 
-func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, configs *docker_compose.ServiceConfigs, err error) {
-	dockerComposeServiceName = "http_" + serviceName
+// defaultImage is built by `make http_mock_image`
+const defaultImage = "integration_framework/http_mock"
+
+func (s *Service) GenerateDockerComposeConfig(allocator *docker_compose.Allocator, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, err error) {
+	dockerComposeServiceName = allocator.ServiceName("http", serviceName)
 	servicePort := "8080"
-	simpleServerConfig := make(map[string]interface{})
+	s.port, err = allocator.HostPort(dockerComposeServiceName, 8080)
+	if err != nil {
+		return "", nil, err
+	}
+	mockConfig := make(map[string]interface{})
+	// routes defined in params override routes generated from spec
+	routes := make(map[string]interface{}, len(s.specRoutes))
+	for route, response := range s.specRoutes {
+		routes[route] = response
+	}
 	m, ok := helper.IsYamlMap(s.params["routes"])
 	if ok {
-		simpleServerConfig["routes"] = m.ToMap()
+		for route, response := range m.ToMap() {
+			routes[route] = response
+		}
 	}
-	simpleServerConfig["service_name"] = serviceName
-	simpleServerConfig["response_content_type"] = s.params["response_content_type"]
-	initialServerConfig, ok := helper.IsYamlMap(s.params["config"])
+	if len(routes) != 0 {
+		mockConfig["routes"] = routes
+	}
+	mockConfig["service_name"] = serviceName
+	mockConfig["response_content_type"] = s.params["response_content_type"]
+	initialMockConfig, ok := helper.IsYamlMap(s.params["config"])
 	if ok {
-		simpleServerConfig["config"] = initialServerConfig.ToMap()
+		mockConfig["config"] = initialMockConfig.ToMap()
 	}
-	simpleServerConfigBytes, err := json.Marshal(simpleServerConfig)
+	fixturesVolume, err := s.fillProxyConfig(mockConfig)
 	if err != nil {
-		return "", nil, nil, fmt.Errorf("unable to marshal simple server %s config: %v", serviceName, err)
+		return "", nil, fmt.Errorf("invalid config of http mock %s: %v", serviceName, err)
 	}
-	serviceConfigFilename := dockerComposeServiceName + ".simple-server.conf"
-	configs = &docker_compose.ServiceConfigs{
-		Files: map[string][]byte{
-			serviceConfigFilename: simpleServerConfigBytes,
-		},
+	err = s.fillCertificatesConfig(mockConfig)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to generate certificates of http mock %s: %v", serviceName, err)
+	}
+	mockConfigBytes, err := json.Marshal(mockConfig)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to marshal http mock %s config: %v", serviceName, err)
+	}
+	// config is checked here to not wait for failed start of mock
+	_, err = http_mock.ParseConfig(mockConfigBytes)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid config of http mock %s: %v", serviceName, err)
+	}
+	s.serverConfig = mockConfigBytes
+	pathToServiceConfig := allocator.ConfigFile(dockerComposeServiceName, "http-mock.json", mockConfigBytes)
+	image, ok := s.params["image"].(string)
+	if !ok {
+		image = defaultImage
 	}
 	service := docker_compose.DockerComposeService{
-		Build: docker_compose.DockerComposeServiceBuild{
-			Context: "/home/aliksend/Documents/simple_server", // TODO use simple_server docker image instead of build directory
-		},
+		Image: image,
 		Volumes: []string{
-			fmt.Sprintf("%s:%s:ro", filepath.Join(tmpDirectory, serviceConfigFilename), "/config.json"),
+			fmt.Sprintf("%s:%s:ro", pathToServiceConfig, "/config.json"),
 		},
 		Restart: "on-failure",
 		Environment: map[string]string{
@@ -47,6 +75,16 @@ func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName s
 			fmt.Sprintf("%d:%s", s.port, servicePort),
 		},
 	}
+	if !ok {
+		service.SetLocalImage("make http_mock_image")
+	}
+	if fixturesVolume != "" {
+		service.Volumes = append(service.Volumes, fixturesVolume)
+	}
+	err = s.interceptHostnames(allocator, dockerComposeServiceName, &service, applicationService)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to intercept host names by http mock %s: %v", serviceName, err)
+	}
 	applicationService.AddDependency(dockerComposeServiceName, "tcp", 8080) // http checker will check that `/` returns smth like 200 and fails if it returns 404
 	if s.env.EnvStr != "" {
 		applicationService.Environment[s.env.EnvStr] = fmt.Sprintf("http://%s:%s/", dockerComposeServiceName, servicePort)
@@ -57,8 +95,8 @@ func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName s
 			"port":   servicePort,
 		})
 		if err != nil {
-			return "", nil, nil, fmt.Errorf("unable to fill environment for http %q: %v", serviceName, err)
+			return "", nil, fmt.Errorf("unable to fill environment for http %q: %v", serviceName, err)
 		}
 	}
-	return dockerComposeServiceName, &service, configs, nil
+	return dockerComposeServiceName, &service, nil
 }
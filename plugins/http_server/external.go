@@ -0,0 +1,20 @@
+package http_server
+
+import (
+	"context"
+	"integration_framework/plugins/external"
+	"strings"
+)
+
+// UseExternalEndpoint uses url of http mock (like `http://host:port/`) as endpoint
+func (s *Service) UseExternalEndpoint(endpoint *external.Endpoint) error {
+	if !strings.HasSuffix(endpoint.Address, "/") {
+		endpoint.Address += "/"
+	}
+	s.externalEndpoint = endpoint
+	return nil
+}
+
+func (s *Service) CheckReachable(ctx context.Context) error {
+	return external.CheckUrlReachable(ctx, s.externalEndpoint.Address)
+}
@@ -0,0 +1,89 @@
+package http_server
+
+import (
+	"fmt"
+	"integration_framework/http_mock"
+	"integration_framework/plugins/docker_compose"
+	"regexp"
+	"strings"
+)
+
+const (
+	// https is served on standard port to intercept urls like `https://api.partner.com/path`
+	tlsPort = "443"
+	// certificate of certificate authority is added to bundle of image if application runs `update-ca-certificates`
+	caContainerPath = "/usr/local/share/ca-certificates/integration_framework.crt"
+	// directory with certificate of certificate authority, it is trusted along with certificates of image by SSL_CERT_DIR
+	caDirectoryContainerPath = "/etc/ssl/integration_framework"
+	// directory of trusted certificates of debian, ubuntu and alpine based images
+	systemCertificatesDirectory = "/etc/ssl/certs"
+)
+
+var hostnameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+func parseHostnames(value interface{}) ([]string, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("hostnames should be list of host names, but it is %T (%#v)", value, value)
+	}
+	hostnames := make([]string, len(list))
+	for i, item := range list {
+		hostname, ok := item.(string)
+		if !ok || !hostnameRegexp.MatchString(strings.ToLower(hostname)) {
+			return nil, fmt.Errorf("hostnames #%d should be host name like `api.example.com`, but it is %T (%#v)", i, item, item)
+		}
+		hostnames[i] = strings.ToLower(hostname)
+	}
+	return hostnames, nil
+}
+
+// fillCertificatesConfig fills certificates of host names served by mock
+func (s *Service) fillCertificatesConfig(mockConfig map[string]interface{}) error {
+	if len(s.hostnames) == 0 {
+		return nil
+	}
+	ca, err := getCertificateAuthority()
+	if err != nil {
+		return err
+	}
+	certificates := make([]http_mock.Certificate, len(s.hostnames))
+	for i, hostname := range s.hostnames {
+		certificate, err := ca.hostCertificate(hostname)
+		if err != nil {
+			return fmt.Errorf("unable to generate certificate of %q: %v", hostname, err)
+		}
+		certificates[i] = http_mock.Certificate{
+			Cert: string(certificate.certPem),
+			Key:  string(certificate.keyPem),
+		}
+	}
+	mockConfig["certificates"] = certificates
+	return nil
+}
+
+// interceptHostnames resolves host names to mock, serves https on them and makes application trust certificates of mock
+func (s *Service) interceptHostnames(allocator *docker_compose.Allocator, dockerComposeServiceName string, service *docker_compose.DockerComposeService, applicationService *docker_compose.DockerComposeService) error {
+	if len(s.hostnames) == 0 {
+		return nil
+	}
+	ca, err := getCertificateAuthority()
+	if err != nil {
+		return err
+	}
+	service.AddNetworkAliases(s.hostnames...)
+	service.Environment["TLS_PORT"] = tlsPort
+
+	hashedName, err := ca.hashedName()
+	if err != nil {
+		return err
+	}
+	pathToCa := allocator.ConfigFile(dockerComposeServiceName, "ca.crt", ca.certPem)
+	// volumes are the same for every http mock, so they are replaced if several mocks intercept host names.
+	// trusted certificates of image are kept, so application trusts both mocks and real services
+	applicationService.AddVolume(fmt.Sprintf("%s:%s:ro", pathToCa, caContainerPath))
+	applicationService.AddVolume(fmt.Sprintf("%s:%s/%s:ro", pathToCa, caDirectoryContainerPath, hashedName))
+	// go and openssl based runtimes look up certificates in both directories
+	applicationService.SetDefaultEnvironment("SSL_CERT_DIR", systemCertificatesDirectory+":"+caDirectoryContainerPath)
+	applicationService.SetDefaultEnvironment("NODE_EXTRA_CA_CERTS", caContainerPath)
+	return nil
+}
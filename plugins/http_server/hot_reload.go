@@ -0,0 +1,28 @@
+package http_server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// HotReload replaces whole config of launched mock (including routes) without restarting it
+func (s *Service) HotReload() error {
+	if s.externalEndpoint != nil {
+		return fmt.Errorf("external http server %q can not be reloaded", s.name)
+	}
+	req, err := http.NewRequest(http.MethodPut, s.Url()+"__config", bytes.NewReader(s.serverConfig))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	return fmt.Errorf("unsuccessfull status code: %d", resp.StatusCode)
+}
@@ -0,0 +1,56 @@
+package http_server
+
+import (
+	"context"
+	"fmt"
+	"integration_framework/http_mock"
+	"net"
+	"net/http"
+	"time"
+)
+
+const stopTimeout = 10 * time.Second
+
+// StartInProcess starts mock inside of framework process on port allocated for service. It is used by local launcher instead of container
+func (s *Service) StartInProcess() error {
+	if len(s.hostnames) != 0 {
+		// host names can be resolved to mock only in network of containers
+		return fmt.Errorf("http mock %q intercepts host names, so it can not be started in process", s.name)
+	}
+	config, err := http_mock.ParseConfig(s.serverConfig)
+	if err != nil {
+		return fmt.Errorf("invalid config of http mock: %v", err)
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("unable to listen port %d: %v", s.port, err)
+	}
+	s.mock = http_mock.NewServer(*config)
+	s.server = &http.Server{
+		Handler: s.mock,
+	}
+	go func(server *http.Server) {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			fmt.Printf("http mock %q stopped: %v\n", s.name, err)
+		}
+	}(s.server)
+	return nil
+}
+
+func (s *Service) StopInProcess() error {
+	if s.server == nil {
+		return nil
+	}
+	// delayed requests and timeouts are interrupted to not wait them in shutdown
+	s.mock.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to stop http mock %q: %v", s.name, err)
+	}
+	s.server = nil
+	s.mock = nil
+	return nil
+}
@@ -0,0 +1,44 @@
+package http_server
+
+import (
+	"fmt"
+	"integration_framework/plugins"
+	"integration_framework/plugins/http_server/openapi"
+)
+
+// loadSpec loads OpenAPI spec and generates example responses for every operation of spec
+func (s *Service) loadSpec(pathToSpec string) error {
+	spec, err := openapi.Load(pathToSpec)
+	if err != nil {
+		return fmt.Errorf("unable to load openapi spec %q: %v", pathToSpec, err)
+	}
+	examples, err := spec.ExampleResponses()
+	if err != nil {
+		return fmt.Errorf("unable to generate responses from openapi spec %q: %v", pathToSpec, err)
+	}
+	s.spec = spec
+	s.specRoutes = make(map[string]interface{}, len(examples))
+	for _, example := range examples {
+		response := map[string]interface{}{
+			"status": example.Status,
+		}
+		if example.Body != nil {
+			response["body"] = example.Body
+		}
+		if example.ContentType != "" {
+			response["headers"] = map[string]interface{}{
+				"Content-Type": example.ContentType,
+			}
+		}
+		s.specRoutes[example.Route] = response
+	}
+	return nil
+}
+
+// DefaultChecker validates calls of every test against OpenAPI spec
+func (s *Service) DefaultChecker() plugins.IServiceChecker {
+	if s.spec == nil {
+		return nil
+	}
+	return NewOpenapiChecker(s)
+}
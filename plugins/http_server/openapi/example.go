@@ -0,0 +1,222 @@
+package openapi
+
+import (
+	"fmt"
+	"integration_framework/helper"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxDepth limits references and nesting of schemas to not loop on recursive schemas
+const maxDepth = 10
+
+// ExampleResponse is response of operation generated from examples or schemas of spec
+type ExampleResponse struct {
+	// route of mock like `GET /users/{id}`
+	Route       string
+	Status      int
+	ContentType string
+	// nil if response has no json content
+	Body interface{}
+}
+
+// ExampleResponses returns responses for every operation of spec
+func (s *Spec) ExampleResponses() ([]ExampleResponse, error) {
+	var res []ExampleResponse
+	for _, path := range s.sortedPaths() {
+		operations := s.Paths[path].operations()
+		methods := make([]string, 0, len(operations))
+		for method := range operations {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			route := method + " " + path
+			response, err := s.exampleResponse(operations[method])
+			if err != nil {
+				return nil, fmt.Errorf("unable to generate response of %s: %v", route, err)
+			}
+			response.Route = route
+			res = append(res, *response)
+		}
+	}
+	return res, nil
+}
+
+// exampleResponse generates successful response of operation. Lowest 2xx code is used, then `default` and then any other code
+func (s *Spec) exampleResponse(operation *Operation) (*ExampleResponse, error) {
+	codes := make([]string, 0, len(operation.Responses))
+	for code := range operation.Responses {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return responseCodePriority(codes[i]) < responseCodePriority(codes[j]) || (responseCodePriority(codes[i]) == responseCodePriority(codes[j]) && codes[i] < codes[j])
+	})
+	res := ExampleResponse{
+		Status: http.StatusOK,
+	}
+	if len(codes) == 0 {
+		return &res, nil
+	}
+	status, err := strconv.Atoi(strings.Replace(strings.ToUpper(codes[0]), "XX", "00", 1))
+	if err == nil {
+		res.Status = status
+	}
+	response, err := s.resolveResponse(operation.Responses[codes[0]])
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		return &res, nil
+	}
+	contentType, mediaType, ok := jsonMediaType(response.Content)
+	if !ok {
+		return &res, nil
+	}
+	res.ContentType = contentType
+	res.Body, err = s.exampleOfMediaType(mediaType)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func responseCodePriority(code string) int {
+	switch {
+	case strings.HasPrefix(code, "2"):
+		return 0
+	case code == "default":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (s *Spec) exampleOfMediaType(mediaType *MediaType) (interface{}, error) {
+	if mediaType.Example != nil {
+		return helper.YamlValueToJsonValue(mediaType.Example), nil
+	}
+	if len(mediaType.Examples) != 0 {
+		names := make([]string, 0, len(mediaType.Examples))
+		for name := range mediaType.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return helper.YamlValueToJsonValue(mediaType.Examples[names[0]].Value), nil
+	}
+	return s.exampleOfSchema(mediaType.Schema, nil)
+}
+
+// exampleOfSchema returns example, default or first value of enum of schema or generates value by type of schema.
+// References already used in parents of schema are generated as null to not expand recursive schemas
+func (s *Spec) exampleOfSchema(schema *Schema, parentRefs []string) (interface{}, error) {
+	if schema != nil && schema.Ref != "" {
+		for _, ref := range parentRefs {
+			if ref == schema.Ref {
+				return nil, nil
+			}
+		}
+		parentRefs = append(parentRefs[:len(parentRefs):len(parentRefs)], schema.Ref)
+	}
+	if len(parentRefs) == maxDepth {
+		return nil, nil
+	}
+	schema, err := s.resolveSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case schema == nil:
+		return nil, nil
+	case schema.Example != nil:
+		return helper.YamlValueToJsonValue(schema.Example), nil
+	case schema.Default != nil:
+		return helper.YamlValueToJsonValue(schema.Default), nil
+	case len(schema.Enum) != 0:
+		return helper.YamlValueToJsonValue(schema.Enum[0]), nil
+	case len(schema.AllOf) != 0:
+		res := make(map[string]interface{})
+		for _, item := range schema.AllOf {
+			example, err := s.exampleOfSchema(item, parentRefs)
+			if err != nil {
+				return nil, err
+			}
+			if exampleMap, ok := example.(map[string]interface{}); ok {
+				for key, value := range exampleMap {
+					res[key] = value
+				}
+			}
+		}
+		return res, nil
+	case len(schema.OneOf) != 0:
+		return s.exampleOfSchema(schema.OneOf[0], parentRefs)
+	case len(schema.AnyOf) != 0:
+		return s.exampleOfSchema(schema.AnyOf[0], parentRefs)
+	}
+	switch schema.Type {
+	case "object", "":
+		if schema.Type == "" && len(schema.Properties) == 0 {
+			return nil, nil
+		}
+		res := make(map[string]interface{}, len(schema.Properties))
+		for name, property := range schema.Properties {
+			example, err := s.exampleOfSchema(property, parentRefs)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			// recursive properties are omitted
+			if example != nil || isRequired(schema, name) {
+				res[name] = example
+			}
+		}
+		return res, nil
+	case "array":
+		item, err := s.exampleOfSchema(schema.Items, parentRefs)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			return []interface{}{}, nil
+		}
+		return []interface{}{item}, nil
+	case "string":
+		return exampleOfString(schema.Format), nil
+	case "integer", "number":
+		if schema.Minimum != nil {
+			return *schema.Minimum, nil
+		}
+		return 0, nil
+	case "boolean":
+		return true, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", schema.Type)
+	}
+}
+
+func isRequired(schema *Schema, property string) bool {
+	for _, name := range schema.Required {
+		if name == property {
+			return true
+		}
+	}
+	return false
+}
+
+func exampleOfString(format string) string {
+	switch format {
+	case "date-time":
+		return "2020-01-01T00:00:00Z"
+	case "date":
+		return "2020-01-01"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "email":
+		return "user@example.com"
+	case "uri":
+		return "http://example.com"
+	default:
+		return "string"
+	}
+}
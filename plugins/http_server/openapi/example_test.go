@@ -0,0 +1,41 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExampleResponses(t *testing.T) {
+	spec := loadTestSpec(t)
+	responses, err := spec.ExampleResponses()
+	if err != nil {
+		t.Fatalf("unable to generate responses: %v", err)
+	}
+	// recursive reference to user is generated as null, because it is required
+	user := map[string]interface{}{
+		"id":         float64(1),
+		"name":       "string",
+		"email":      "user@example.com",
+		"birthday":   "2020-01-01",
+		"tags":       []interface{}{"string"},
+		"status":     "active",
+		"created_at": "2020-01-01T00:00:00Z",
+		"friend":     nil,
+		"nickname":   "anonymous",
+	}
+	expected := []ExampleResponse{
+		{Route: "GET /users", Status: 200, ContentType: "application/json", Body: []interface{}{user}},
+		{Route: "POST /users", Status: 201, ContentType: "application/json", Body: map[string]interface{}{"id": 1, "name": "alice"}},
+		{Route: "DELETE /users/{id}", Status: 204},
+		{Route: "GET /users/{id}", Status: 200, ContentType: "application/json", Body: user},
+		{Route: "GET /users/{id}/friends", Status: 200, ContentType: "application/json", Body: []interface{}{map[string]interface{}{"id": 2}}},
+	}
+	if len(responses) != len(expected) {
+		t.Fatalf("expected %d responses, got %#v", len(expected), responses)
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(responses[i], expected[i]) {
+			t.Errorf("expected response %#v, got %#v", expected[i], responses[i])
+		}
+	}
+}
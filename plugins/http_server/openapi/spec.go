@@ -0,0 +1,295 @@
+// Package openapi implements subset of OpenAPI 3 used by http mocks: examples of responses and validation of requests.
+// Only local references like `#/components/schemas/Name` are supported, servers are ignored
+package openapi
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+type Spec struct {
+	OpenAPI    string               `yaml:"openapi"`
+	Paths      map[string]*PathItem `yaml:"paths"`
+	Components Components           `yaml:"components"`
+}
+
+type Components struct {
+	Schemas       map[string]*Schema      `yaml:"schemas"`
+	Parameters    map[string]*Parameter   `yaml:"parameters"`
+	RequestBodies map[string]*RequestBody `yaml:"requestBodies"`
+	Responses     map[string]*Response    `yaml:"responses"`
+}
+
+type PathItem struct {
+	Parameters []*Parameter `yaml:"parameters"`
+	Get        *Operation   `yaml:"get"`
+	Put        *Operation   `yaml:"put"`
+	Post       *Operation   `yaml:"post"`
+	Delete     *Operation   `yaml:"delete"`
+	Options    *Operation   `yaml:"options"`
+	Head       *Operation   `yaml:"head"`
+	Patch      *Operation   `yaml:"patch"`
+}
+
+type Operation struct {
+	Parameters  []*Parameter         `yaml:"parameters"`
+	RequestBody *RequestBody         `yaml:"requestBody"`
+	Responses   map[string]*Response `yaml:"responses"`
+}
+
+type Parameter struct {
+	Ref      string  `yaml:"$ref"`
+	Name     string  `yaml:"name"`
+	In       string  `yaml:"in"`
+	Required bool    `yaml:"required"`
+	Schema   *Schema `yaml:"schema"`
+}
+
+type RequestBody struct {
+	Ref      string                `yaml:"$ref"`
+	Required bool                  `yaml:"required"`
+	Content  map[string]*MediaType `yaml:"content"`
+}
+
+type Response struct {
+	Ref     string                `yaml:"$ref"`
+	Content map[string]*MediaType `yaml:"content"`
+}
+
+type MediaType struct {
+	Schema   *Schema             `yaml:"schema"`
+	Example  interface{}         `yaml:"example"`
+	Examples map[string]*Example `yaml:"examples"`
+}
+
+type Example struct {
+	Value interface{} `yaml:"value"`
+}
+
+type Schema struct {
+	Ref        string             `yaml:"$ref"`
+	Type       string             `yaml:"type"`
+	Format     string             `yaml:"format"`
+	Nullable   bool               `yaml:"nullable"`
+	Enum       []interface{}      `yaml:"enum"`
+	Example    interface{}        `yaml:"example"`
+	Default    interface{}        `yaml:"default"`
+	Properties map[string]*Schema `yaml:"properties"`
+	Required   []string           `yaml:"required"`
+	// only `false` is checked
+	AdditionalProperties interface{} `yaml:"additionalProperties"`
+	Items                *Schema     `yaml:"items"`
+	AllOf                []*Schema   `yaml:"allOf"`
+	OneOf                []*Schema   `yaml:"oneOf"`
+	AnyOf                []*Schema   `yaml:"anyOf"`
+	Minimum              *float64    `yaml:"minimum"`
+	Maximum              *float64    `yaml:"maximum"`
+	MinLength            *int        `yaml:"minLength"`
+	MaxLength            *int        `yaml:"maxLength"`
+	MinItems             *int        `yaml:"minItems"`
+	MaxItems             *int        `yaml:"maxItems"`
+	Pattern              string      `yaml:"pattern"`
+}
+
+func Load(path string) (*Spec, error) {
+	specBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read spec: %v", err)
+	}
+	var spec Spec
+	err = yaml.Unmarshal(specBytes, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal spec: %v", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("only OpenAPI 3 is supported, but version of spec is %q", spec.OpenAPI)
+	}
+	return &spec, nil
+}
+
+func (p PathItem) operations() map[string]*Operation {
+	operations := make(map[string]*Operation)
+	addOperation(operations, "GET", p.Get)
+	addOperation(operations, "PUT", p.Put)
+	addOperation(operations, "POST", p.Post)
+	addOperation(operations, "DELETE", p.Delete)
+	addOperation(operations, "OPTIONS", p.Options)
+	addOperation(operations, "HEAD", p.Head)
+	addOperation(operations, "PATCH", p.Patch)
+	return operations
+}
+
+func addOperation(operations map[string]*Operation, method string, operation *Operation) {
+	if operation != nil {
+		operations[method] = operation
+	}
+}
+
+// sortedPaths returns paths of spec. Paths without params are first to match them before templated ones
+func (s *Spec) sortedPaths() []string {
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		iTemplated := strings.Contains(paths[i], "{")
+		jTemplated := strings.Contains(paths[j], "{")
+		if iTemplated != jTemplated {
+			return jTemplated
+		}
+		return paths[i] < paths[j]
+	})
+	return paths
+}
+
+// findOperation returns operation for method and path of request and params of path
+func (s *Spec) findOperation(method string, path string) (*PathItem, *Operation, map[string]string, bool) {
+	for _, specPath := range s.sortedPaths() {
+		params, ok := matchPath(specPath, path)
+		if !ok {
+			continue
+		}
+		pathItem := s.Paths[specPath]
+		operation, ok := pathItem.operations()[method]
+		if ok {
+			return pathItem, operation, params, true
+		}
+	}
+	return nil, nil, nil, false
+}
+
+// matchPath matches path by template like `/users/{id}`
+func matchPath(template string, path string) (map[string]string, bool) {
+	templateSegments := strings.Split(template, "/")
+	pathSegments := strings.Split(path, "/")
+	if len(templateSegments) != len(pathSegments) {
+		return nil, false
+	}
+	params := make(map[string]string)
+	for i, segment := range templateSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			params[segment[1:len(segment)-1]] = pathSegments[i]
+			continue
+		}
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// refName returns name of component from reference like `#/components/schemas/Name`
+func refName(ref string, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("reference %q is not supported, only references to %s are supported", ref, prefix)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+func (s *Spec) resolveSchema(schema *Schema) (*Schema, error) {
+	for i := 0; schema != nil && schema.Ref != ""; i++ {
+		if i == maxDepth {
+			return nil, fmt.Errorf("too deep references")
+		}
+		name, err := refName(schema.Ref, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		resolved, ok := s.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("schema %q not found", name)
+		}
+		schema = resolved
+	}
+	return schema, nil
+}
+
+func (s *Spec) resolveParameter(parameter *Parameter) (*Parameter, error) {
+	if parameter.Ref == "" {
+		return parameter, nil
+	}
+	name, err := refName(parameter.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := s.Components.Parameters[name]
+	if !ok {
+		return nil, fmt.Errorf("parameter %q not found", name)
+	}
+	return resolved, nil
+}
+
+func (s *Spec) resolveRequestBody(requestBody *RequestBody) (*RequestBody, error) {
+	if requestBody == nil || requestBody.Ref == "" {
+		return requestBody, nil
+	}
+	name, err := refName(requestBody.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := s.Components.RequestBodies[name]
+	if !ok {
+		return nil, fmt.Errorf("request body %q not found", name)
+	}
+	return resolved, nil
+}
+
+func (s *Spec) resolveResponse(response *Response) (*Response, error) {
+	if response == nil || response.Ref == "" {
+		return response, nil
+	}
+	name, err := refName(response.Ref, "responses")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := s.Components.Responses[name]
+	if !ok {
+		return nil, fmt.Errorf("response %q not found", name)
+	}
+	return resolved, nil
+}
+
+// parameters returns parameters of operation. Parameters of operation override parameters of path with the same name and location
+func (s *Spec) parameters(pathItem *PathItem, operation *Operation) ([]*Parameter, error) {
+	var res []*Parameter
+	indexes := make(map[string]int)
+	for _, list := range [][]*Parameter{pathItem.Parameters, operation.Parameters} {
+		for _, parameter := range list {
+			resolved, err := s.resolveParameter(parameter)
+			if err != nil {
+				return nil, err
+			}
+			key := resolved.In + " " + resolved.Name
+			if i, ok := indexes[key]; ok {
+				res[i] = resolved
+				continue
+			}
+			indexes[key] = len(res)
+			res = append(res, resolved)
+		}
+	}
+	return res, nil
+}
+
+// jsonMediaType returns json media type of content
+func jsonMediaType(content map[string]*MediaType) (string, *MediaType, bool) {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	for _, mediaType := range mediaTypes {
+		if isJson(mediaType) {
+			return mediaType, content[mediaType], true
+		}
+	}
+	return "", nil, false
+}
+
+func isJson(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
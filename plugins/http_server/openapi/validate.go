@@ -0,0 +1,374 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"integration_framework/helper"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request is request received by mock
+type Request struct {
+	Method  string
+	Path    string
+	Query   url.Values
+	Headers http.Header
+	Body    []byte
+}
+
+// ValidateRequest returns violations of spec by request: unknown operation, missing or invalid parameters and invalid body
+func (s *Spec) ValidateRequest(r Request) []string {
+	pathItem, operation, pathParams, ok := s.findOperation(r.Method, r.Path)
+	if !ok {
+		return []string{fmt.Sprintf("operation %s %s not defined in spec", r.Method, r.Path)}
+	}
+	var violations []string
+	parameters, err := s.parameters(pathItem, operation)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid spec: %v", err)}
+	}
+	for _, parameter := range parameters {
+		var values []string
+		switch parameter.In {
+		case "path":
+			values = []string{pathParams[parameter.Name]}
+		case "query":
+			values = r.Query[parameter.Name]
+		case "header":
+			values = r.Headers[http.CanonicalHeaderKey(parameter.Name)]
+		default:
+			continue
+		}
+		description := fmt.Sprintf("%s parameter %q", parameter.In, parameter.Name)
+		if len(values) == 0 {
+			if parameter.Required {
+				violations = append(violations, fmt.Sprintf("%s is required", description))
+			}
+			continue
+		}
+		violations = append(violations, s.validateParameter(parameter.Schema, values, description)...)
+	}
+	requestBody, err := s.resolveRequestBody(operation.RequestBody)
+	if err != nil {
+		return append(violations, fmt.Sprintf("invalid spec: %v", err))
+	}
+	return append(violations, s.validateBody(requestBody, r)...)
+}
+
+// validateParameter validates string values of parameter converted to types of schema
+func (s *Spec) validateParameter(schema *Schema, values []string, description string) []string {
+	schema, err := s.resolveSchema(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid spec: %v", err)}
+	}
+	if schema == nil {
+		return nil
+	}
+	if schema.Type != "array" {
+		return s.validateValue(schema, convertString(schema, values[0]), description, 0)
+	}
+	items, err := s.resolveSchema(schema.Items)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid spec: %v", err)}
+	}
+	// arrays are passed as repeated parameters or as comma separated values
+	if len(values) == 1 {
+		values = strings.Split(values[0], ",")
+	}
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = convertString(items, value)
+	}
+	return s.validateValue(schema, list, description, 0)
+}
+
+// convertString converts value of parameter to type of schema. Value is kept as is if it can't be converted
+func convertString(schema *Schema, value string) interface{} {
+	if schema == nil {
+		return value
+	}
+	switch schema.Type {
+	case "integer", "number":
+		number, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return number
+		}
+	case "boolean":
+		boolean, err := strconv.ParseBool(value)
+		if err == nil {
+			return boolean
+		}
+	}
+	return value
+}
+
+func (s *Spec) validateBody(requestBody *RequestBody, r Request) []string {
+	if requestBody == nil {
+		return nil
+	}
+	if len(r.Body) == 0 {
+		if requestBody.Required {
+			return []string{"body is required"}
+		}
+		return nil
+	}
+	contentType := r.Headers.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid content type %q: %v", contentType, err)}
+	}
+	content, ok := findContent(requestBody.Content, mediaType)
+	if !ok {
+		return []string{fmt.Sprintf("content type %q is not allowed", mediaType)}
+	}
+	if content == nil || content.Schema == nil {
+		return nil
+	}
+	switch {
+	case isJson(mediaType):
+		var body interface{}
+		err := json.Unmarshal(r.Body, &body)
+		if err != nil {
+			return []string{fmt.Sprintf("invalid json body: %v", err)}
+		}
+		return s.validateValue(content.Schema, body, "body", 0)
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(r.Body))
+		if err != nil {
+			return []string{fmt.Sprintf("invalid form body: %v", err)}
+		}
+		return s.validateForm(content.Schema, values)
+	default:
+		// only json and forms are validated by schema
+		return nil
+	}
+}
+
+// findContent returns media type of content matching media type of request. Ranges like `application/*` are supported
+func findContent(content map[string]*MediaType, mediaType string) (*MediaType, bool) {
+	if mediaTypeContent, ok := content[mediaType]; ok {
+		return mediaTypeContent, true
+	}
+	if mediaTypeContent, ok := content[strings.SplitN(mediaType, "/", 2)[0]+"/*"]; ok {
+		return mediaTypeContent, true
+	}
+	mediaTypeContent, ok := content["*/*"]
+	return mediaTypeContent, ok
+}
+
+// validateForm validates fields of form converted to types of properties of schema
+func (s *Spec) validateForm(schema *Schema, values url.Values) []string {
+	schema, err := s.resolveSchema(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid spec: %v", err)}
+	}
+	form := make(map[string]interface{}, len(values))
+	for name, list := range values {
+		property, err := s.resolveSchema(schema.Properties[name])
+		if err != nil {
+			return []string{fmt.Sprintf("invalid spec: %v", err)}
+		}
+		if property != nil && property.Type == "array" {
+			items, err := s.resolveSchema(property.Items)
+			if err != nil {
+				return []string{fmt.Sprintf("invalid spec: %v", err)}
+			}
+			converted := make([]interface{}, len(list))
+			for i, value := range list {
+				converted[i] = convertString(items, value)
+			}
+			form[name] = converted
+			continue
+		}
+		form[name] = convertString(property, list[0])
+	}
+	return s.validateValue(schema, form, "body", 0)
+}
+
+// validateValue returns violations of schema by value. Value is unmarshaled json
+func (s *Spec) validateValue(schema *Schema, value interface{}, path string, depth int) []string {
+	if depth == maxDepth {
+		return nil
+	}
+	schema, err := s.resolveSchema(schema)
+	if err != nil {
+		return []string{fmt.Sprintf("invalid spec: %v", err)}
+	}
+	if schema == nil {
+		return nil
+	}
+	if value == nil {
+		if schema.Nullable || schema.Type == "" {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s should not be null", path)}
+	}
+	var violations []string
+	for _, item := range schema.AllOf {
+		violations = append(violations, s.validateValue(item, value, path, depth+1)...)
+	}
+	if len(schema.OneOf) != 0 && s.countMatchedSchemas(schema.OneOf, value, path, depth) != 1 {
+		violations = append(violations, fmt.Sprintf("%s should match exactly one schema of oneOf", path))
+	}
+	if len(schema.AnyOf) != 0 && s.countMatchedSchemas(schema.AnyOf, value, path, depth) == 0 {
+		violations = append(violations, fmt.Sprintf("%s should match at least one schema of anyOf", path))
+	}
+	if len(schema.Enum) != 0 && !inEnum(schema.Enum, value) {
+		violations = append(violations, fmt.Sprintf("%s should be one of %v, but it is %v", path, schema.Enum, value))
+	}
+	switch schema.Type {
+	case "":
+		// any type
+	case "object":
+		violations = append(violations, s.validateObject(schema, value, path, depth)...)
+	case "array":
+		violations = append(violations, s.validateArray(schema, value, path, depth)...)
+	case "string":
+		violations = append(violations, validateString(schema, value, path)...)
+	case "integer", "number":
+		violations = append(violations, validateNumber(schema, value, path)...)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			violations = append(violations, typeViolation(path, "boolean", value))
+		}
+	default:
+		violations = append(violations, fmt.Sprintf("invalid spec: unknown type %q of %s", schema.Type, path))
+	}
+	return violations
+}
+
+func (s *Spec) countMatchedSchemas(schemas []*Schema, value interface{}, path string, depth int) int {
+	matched := 0
+	for _, item := range schemas {
+		if len(s.validateValue(item, value, path, depth+1)) == 0 {
+			matched++
+		}
+	}
+	return matched
+}
+
+func (s *Spec) validateObject(schema *Schema, value interface{}, path string, depth int) []string {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return []string{typeViolation(path, "object", value)}
+	}
+	var violations []string
+	for _, name := range schema.Required {
+		if _, ok := object[name]; !ok {
+			violations = append(violations, fmt.Sprintf("%s.%s is required", path, name))
+		}
+	}
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, ok := schema.Properties[name]
+		if !ok {
+			if additionalProperties, ok := schema.AdditionalProperties.(bool); ok && !additionalProperties {
+				violations = append(violations, fmt.Sprintf("%s.%s is not allowed", path, name))
+			}
+			continue
+		}
+		violations = append(violations, s.validateValue(property, object[name], path+"."+name, depth+1)...)
+	}
+	return violations
+}
+
+func (s *Spec) validateArray(schema *Schema, value interface{}, path string, depth int) []string {
+	array, ok := value.([]interface{})
+	if !ok {
+		return []string{typeViolation(path, "array", value)}
+	}
+	var violations []string
+	if schema.MinItems != nil && len(array) < *schema.MinItems {
+		violations = append(violations, fmt.Sprintf("%s should contain at least %d items", path, *schema.MinItems))
+	}
+	if schema.MaxItems != nil && len(array) > *schema.MaxItems {
+		violations = append(violations, fmt.Sprintf("%s should contain at most %d items", path, *schema.MaxItems))
+	}
+	for i, item := range array {
+		violations = append(violations, s.validateValue(schema.Items, item, fmt.Sprintf("%s[%d]", path, i), depth+1)...)
+	}
+	return violations
+}
+
+func validateString(schema *Schema, value interface{}, path string) []string {
+	str, ok := value.(string)
+	if !ok {
+		return []string{typeViolation(path, "string", value)}
+	}
+	var violations []string
+	length := len([]rune(str))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		violations = append(violations, fmt.Sprintf("%s should be at least %d characters long", path, *schema.MinLength))
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		violations = append(violations, fmt.Sprintf("%s should be at most %d characters long", path, *schema.MaxLength))
+	}
+	if schema.Pattern != "" {
+		re, err := regexp.Compile(schema.Pattern)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("invalid spec: invalid pattern of %s: %v", path, err))
+		} else if !re.MatchString(str) {
+			violations = append(violations, fmt.Sprintf("%s should match pattern %q, but it is %q", path, schema.Pattern, str))
+		}
+	}
+	switch schema.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			violations = append(violations, fmt.Sprintf("%s should be date-time, but it is %q", path, str))
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", str); err != nil {
+			violations = append(violations, fmt.Sprintf("%s should be date, but it is %q", path, str))
+		}
+	}
+	return violations
+}
+
+func validateNumber(schema *Schema, value interface{}, path string) []string {
+	number, ok := value.(float64)
+	if !ok {
+		return []string{typeViolation(path, schema.Type, value)}
+	}
+	if schema.Type == "integer" && number != math.Trunc(number) {
+		return []string{typeViolation(path, "integer", value)}
+	}
+	var violations []string
+	if schema.Minimum != nil && number < *schema.Minimum {
+		violations = append(violations, fmt.Sprintf("%s should be at least %v, but it is %v", path, *schema.Minimum, number))
+	}
+	if schema.Maximum != nil && number > *schema.Maximum {
+		violations = append(violations, fmt.Sprintf("%s should be at most %v, but it is %v", path, *schema.Maximum, number))
+	}
+	return violations
+}
+
+func typeViolation(path string, expectedType string, value interface{}) string {
+	return fmt.Sprintf("%s should be %s, but it is %#v", path, expectedType, value)
+}
+
+// inEnum compares value with values of enum. Numbers of spec are compared with numbers of json as float64
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, item := range enum {
+		item = helper.YamlValueToJsonValue(item)
+		if i, ok := item.(int); ok {
+			item = float64(i)
+		}
+		if reflect.DeepEqual(item, value) {
+			return true
+		}
+	}
+	return false
+}
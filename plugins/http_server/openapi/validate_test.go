@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func loadTestSpec(t *testing.T) *Spec {
+	spec, err := Load("testdata/spec.yaml")
+	if err != nil {
+		t.Fatalf("unable to load spec: %v", err)
+	}
+	return spec
+}
+
+func TestValidateRequest(t *testing.T) {
+	spec := loadTestSpec(t)
+	jsonHeaders := http.Header{
+		"Content-Type": {"application/json; charset=utf-8"},
+		"X-Request-Id": {"00000000-0000-0000-0000-000000000000"},
+	}
+	tests := []struct {
+		name     string
+		request  Request
+		expected []string
+	}{
+		{
+			name:    "valid query",
+			request: Request{Method: "GET", Path: "/users", Query: url.Values{"limit": {"10"}, "status": {"active,blocked"}}},
+		},
+		{
+			name:     "unknown operation",
+			request:  Request{Method: "PATCH", Path: "/users"},
+			expected: []string{"operation PATCH /users not defined in spec"},
+		},
+		{
+			name:    "invalid query",
+			request: Request{Method: "GET", Path: "/users", Query: url.Values{"limit": {"0"}, "status": {"active", "deleted"}}},
+			expected: []string{
+				`query parameter "limit" should be at least 1, but it is 0`,
+				`query parameter "status"[1] should be one of [active blocked], but it is deleted`,
+			},
+		},
+		{
+			name:     "invalid path parameter",
+			request:  Request{Method: "GET", Path: "/users/abc"},
+			expected: []string{`path parameter "id" should be integer, but it is "abc"`},
+		},
+		{
+			name:    "valid json body",
+			request: Request{Method: "POST", Path: "/users", Headers: jsonHeaders, Body: []byte(`{"name": "alice", "birthday": "2000-01-31", "tags": ["a"]}`)},
+		},
+		{
+			name:    "invalid json body",
+			request: Request{Method: "POST", Path: "/users", Headers: jsonHeaders, Body: []byte(`{"email": "alice", "birthday": "31.01.2000", "tags": ["a", "b", 3], "age": 20}`)},
+			expected: []string{
+				"body.name is required",
+				"body.age is not allowed",
+				`body.birthday should be date, but it is "31.01.2000"`,
+				`body.email should match pattern "@", but it is "alice"`,
+				"body.tags should contain at most 2 items",
+				"body.tags[2] should be string, but it is 3",
+			},
+		},
+		{
+			name:     "missing header and body",
+			request:  Request{Method: "POST", Path: "/users", Headers: http.Header{}},
+			expected: []string{`header parameter "X-Request-Id" is required`, "body is required"},
+		},
+		{
+			name:    "form body",
+			request: Request{Method: "POST", Path: "/users", Headers: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}, "X-Request-Id": {"1"}}, Body: []byte("name=&tags=a&tags=b")},
+			expected: []string{
+				"body.name should be at least 1 characters long",
+			},
+		},
+		{
+			name:     "not allowed content type",
+			request:  Request{Method: "POST", Path: "/users", Headers: http.Header{"Content-Type": {"text/plain"}, "X-Request-Id": {"1"}}, Body: []byte("alice")},
+			expected: []string{`content type "text/plain" is not allowed`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			violations := spec.ValidateRequest(test.request)
+			if !reflect.DeepEqual(violations, test.expected) {
+				t.Errorf("expected violations %#v, got %#v", test.expected, violations)
+			}
+		})
+	}
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func init() {
-	plugins.DefineService("http", func(name string, port int, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
-		return NewService(name, port, env, params), nil
+	plugins.DefineService("http", func(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
+		return NewService(name, env, params)
 	})
 }
@@ -7,7 +7,7 @@ import (
 )
 
 type IPrepare interface {
-	Prepare(serviceUrl string) error
+	Prepare(serviceUrl string, variables map[string]interface{}) error
 }
 
 func (s *Service) Preparer(params interface{}) (plugins.IServicePreparer, error) {
@@ -49,9 +49,9 @@ type PrepareConfig struct {
 	prepares []IPrepare
 }
 
-func (hpc PrepareConfig) PrepareService() error {
+func (hpc PrepareConfig) PrepareService(variables map[string]interface{}) error {
 	for i, prepare := range hpc.prepares {
-		err := prepare.Prepare(fmt.Sprintf("http://localhost:%d/", hpc.service.port))
+		err := prepare.Prepare(hpc.service.Url(), variables)
 		if err != nil {
 			return fmt.Errorf("unable to prepare http %d: %v", i, err)
 		}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"integration_framework/helper"
 	"io"
+	"io/ioutil"
 	"net/http"
 )
 
@@ -18,10 +20,14 @@ type ConfigPrepare struct {
 	config map[string]interface{}
 }
 
-func (p ConfigPrepare) Prepare(serviceUrl string) error {
+func (p ConfigPrepare) Prepare(serviceUrl string, variables map[string]interface{}) error {
 	var body io.Reader
 	if p.config != nil {
-		configBytes, err := json.Marshal(p.config)
+		config, err := helper.ApplyInterpolationForObject(p.config, variables)
+		if err != nil {
+			return fmt.Errorf("unable to apply interpolation: %v", err)
+		}
+		configBytes, err := json.Marshal(config)
 		if err != nil {
 			return fmt.Errorf("unable to marshal config: %v", err)
 		}
@@ -35,5 +41,6 @@ func (p ConfigPrepare) Prepare(serviceUrl string) error {
 	if resp.StatusCode == http.StatusOK {
 		return nil
 	}
-	return fmt.Errorf("unsuccessfull status code: %d", resp.StatusCode)
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("unsuccessfull status code: %d (%s)", resp.StatusCode, bytes.TrimSpace(respBody))
 }
@@ -12,7 +12,7 @@ func NewResetCallsPrepare() *ResetCallsPrepare {
 type ResetCallsPrepare struct {
 }
 
-func (p ResetCallsPrepare) Prepare(serviceUrl string) error {
+func (p ResetCallsPrepare) Prepare(serviceUrl string, variables map[string]interface{}) error {
 	resp, err := http.Post(serviceUrl+"__reset_calls", "application/json", nil)
 	if err != nil {
 		return fmt.Errorf("unable to send request: %v", err)
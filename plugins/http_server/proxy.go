@@ -0,0 +1,40 @@
+package http_server
+
+import (
+	"fmt"
+	"integration_framework/http_mock"
+	"path/filepath"
+)
+
+// fillProxyConfig fills params of record and replay modes of mock and returns volume of fixtures directory.
+// Directory is mounted to the same path in container to use the same config in container and in process
+func (s *Service) fillProxyConfig(mockConfig map[string]interface{}) (string, error) {
+	if s.params["mode"] == nil {
+		return "", nil
+	}
+	mode, ok := s.params["mode"].(string)
+	if !ok {
+		return "", fmt.Errorf("mode should be string, but it is %T (%#v)", s.params["mode"], s.params["mode"])
+	}
+	fixtures, ok := s.params["fixtures"].(string)
+	if !ok {
+		return "", fmt.Errorf("fixtures should be path to directory of fixtures, but it is %T (%#v)", s.params["fixtures"], s.params["fixtures"])
+	}
+	fixturesDir, err := filepath.Abs(fixtures)
+	if err != nil {
+		return "", fmt.Errorf("unable to get absolute path of fixtures: %v", err)
+	}
+	mockConfig["mode"] = mode
+	mockConfig["upstream"] = s.params["upstream"]
+	mockConfig["fixtures"] = fixturesDir
+	mockConfig["match_by"] = s.params["match_by"]
+	if mode != http_mock.ModeReplay {
+		return fmt.Sprintf("%s:%s", fixturesDir, fixturesDir), nil
+	}
+	// fixtures are checked here to not wait for failed requests to mock
+	_, err = http_mock.LoadFixtures(fixturesDir)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s:ro", fixturesDir, fixturesDir), nil
+}
@@ -0,0 +1,102 @@
+package http_server
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFillProxyConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	corruptDir := filepath.Join(dir, "corrupt")
+	err = os.Mkdir(corruptDir, os.ModePerm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(corruptDir, "0001_GET_users.json"), []byte("not json"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		params         map[string]interface{}
+		expectedVolume string
+		expectedError  string
+	}{
+		{
+			name:   "without mode",
+			params: map[string]interface{}{},
+		},
+		{
+			name:           "record",
+			params:         map[string]interface{}{"mode": "record", "upstream": "https://api.partner.com/", "fixtures": dir, "match_by": []interface{}{"path"}},
+			expectedVolume: dir + ":" + dir,
+		},
+		{
+			name:           "replay",
+			params:         map[string]interface{}{"mode": "replay", "fixtures": dir},
+			expectedVolume: dir + ":" + dir + ":ro",
+		},
+		{
+			name:          "replay of corrupt fixture",
+			params:        map[string]interface{}{"mode": "replay", "fixtures": corruptDir},
+			expectedError: `unable to unmarshal fixture "0001_GET_users.json"`,
+		},
+		{
+			name:          "replay without fixtures",
+			params:        map[string]interface{}{"mode": "replay", "fixtures": filepath.Join(dir, "missing")},
+			expectedError: "unable to read directory of fixtures",
+		},
+		{
+			name:          "mode not string",
+			params:        map[string]interface{}{"mode": true},
+			expectedError: "mode should be string",
+		},
+		{
+			name:          "fixtures not defined",
+			params:        map[string]interface{}{"mode": "record"},
+			expectedError: "fixtures should be path to directory of fixtures",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := Service{params: test.params}
+			mockConfig := map[string]interface{}{}
+			volume, err := service.fillProxyConfig(mockConfig)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to fill config: %v", err)
+			}
+			if volume != test.expectedVolume {
+				t.Errorf("expected volume %q, got %q", test.expectedVolume, volume)
+			}
+			if test.params["mode"] == nil {
+				if len(mockConfig) != 0 {
+					t.Errorf("expected config without proxy params, got %v", mockConfig)
+				}
+				return
+			}
+			for _, key := range []string{"mode", "upstream", "match_by"} {
+				if !reflect.DeepEqual(mockConfig[key], test.params[key]) {
+					t.Errorf("expected %s %v, got %v", key, test.params[key], mockConfig[key])
+				}
+			}
+			if mockConfig["fixtures"] != dir {
+				t.Errorf("expected absolute path of fixtures %q, got %v", dir, mockConfig["fixtures"])
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package http_server
 
 import (
-	"context"
+	"fmt"
 	"integration_framework/application_config"
-	"integration_framework/helper"
-	"time"
+	"integration_framework/http_mock"
+	"integration_framework/plugins"
+	"integration_framework/plugins/external"
+	"integration_framework/plugins/http_server/openapi"
+	"net/http"
 )
 
 type Service struct {
@@ -12,43 +15,59 @@ type Service struct {
 	env    application_config.ServiceDefinitionEnv
 	params map[string]interface{}
 	port   int
+	// config of launched mock, used for hot reload
+	serverConfig []byte
+	// defined if mock is started in process
+	server *http.Server
+	mock   *http_mock.Server
+	// defined if service is not launched by framework
+	externalEndpoint *external.Endpoint
+	// defined if param `openapi` is defined
+	spec *openapi.Spec
+	// routes generated from spec
+	specRoutes map[string]interface{}
+	// host names intercepted by mock
+	hostnames []string
 }
 
-func NewService(name string, port int, env application_config.ServiceDefinitionEnv, params map[string]interface{}) *Service {
-	return &Service{
+func NewService(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (*Service, error) {
+	s := &Service{
 		name:   name,
 		env:    env,
-		port:   port,
 		params: params,
 	}
+	if params["openapi"] != nil {
+		pathToSpec, ok := params["openapi"].(string)
+		if !ok {
+			return nil, fmt.Errorf("openapi should be path to spec, but it is %T (%#v)", params["openapi"], params["openapi"])
+		}
+		err := s.loadSpec(pathToSpec)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if params["hostnames"] != nil {
+		var err error
+		s.hostnames, err = parseHostnames(params["hostnames"])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
 }
 
 func (s *Service) Start() error {
 	return nil
 }
 
-func (s Service) WaitForPortAvailable(ctx context.Context) error {
-	for {
-		portAvailable := helper.IsHttpPortAvailable("localhost", s.port)
-		if portAvailable {
-			return nil
-		}
-
-		select {
-		case _, ok := <-ctx.Done():
-			if ok {
-				// value readed
-				return nil
-			} else {
-				// channel closed
-				return nil
-			}
-		default:
-			// chanel is empty
-			// continue checking
-		}
-
-		time.Sleep(5 * time.Second)
+func (s Service) Url() string {
+	if s.externalEndpoint != nil {
+		return s.externalEndpoint.Address
 	}
-	return nil
+	return fmt.Sprintf("http://localhost:%d/", s.port)
+}
+
+// ReadinessProbe checks that mock responds to `__calls` (routes of mock can return any status)
+func (s *Service) ReadinessProbe() plugins.IProbe {
+	return plugins.NewHttpProbe(s.Url()+"__calls", true)
 }
@@ -8,9 +8,12 @@ import (
 type ILauncher interface {
 	ConfigUpdated(config *application_config.Config, services map[string]IService) error
 	Shutdown() error
+	// ApplicationsUrls returns urls of launched applications by name like `http://localhost:8080`
+	ApplicationsUrls() map[string]string
 }
 
-type launchersConstructor func(tmpDirectory string) (ILauncher, error)
+// launchersConstructor creates launcher. Output of launched services and applications should be written to logs
+type launchersConstructor func(tmpDirectory string, logs *RunLogs) (ILauncher, error)
 
 var launchersConstructors map[string]launchersConstructor
 
@@ -26,12 +29,12 @@ func DefineLauncher(name string, constructor launchersConstructor) {
 	launchersConstructors[name] = constructor
 }
 
-func NewLauncher(name string, tmpDirectory string) (ILauncher, error) {
+func NewLauncher(name string, tmpDirectory string, logs *RunLogs) (ILauncher, error) {
 	constructor, ok := launchersConstructors[name]
 	if !ok {
 		return nil, fmt.Errorf("launcher %q not defined", name)
 	}
-	launcher, err := constructor(tmpDirectory)
+	launcher, err := constructor(tmpDirectory, logs)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create launcher: %v", err)
 	}
@@ -0,0 +1,119 @@
+package local
+
+import (
+	"fmt"
+	"integration_framework/plugins/docker_compose"
+	"regexp"
+	"strings"
+)
+
+const localHost = "localhost"
+
+// publishedPorts returns map of container port to host port of docker compose service
+func publishedPorts(service *docker_compose.DockerComposeService) map[string]string {
+	res := make(map[string]string)
+	for _, port := range service.Ports {
+		parts := strings.Split(strings.Split(port, "/")[0], ":")
+		if len(parts) < 2 {
+			continue
+		}
+		res[parts[len(parts)-1]] = parts[len(parts)-2]
+	}
+	return res
+}
+
+// localEnvironment rewrites addresses of services in variables listed in `local_addresses` of application to addresses of their published ports on localhost.
+// Value equal to name of docker compose service is replaced by `localhost`, value containing only port (like `DB_PORT=5432`) is replaced by published port.
+// `service:port` inside of value is replaced by `localhost:published_port`, `service` without port inside of value (like url) is replaced by `localhost:published_port` if service publishes only one port.
+// Paths of volumes mounted into application are replaced by paths on host in all variables
+func localEnvironment(environment map[string]string, addresses map[string]string, services map[string]localService, volumes []string) (map[string]string, error) {
+	res := make(map[string]string, len(environment))
+	for name, value := range environment {
+		res[name] = rewriteVolumePath(value, volumes)
+	}
+	for name, serviceName := range addresses {
+		value, ok := environment[name]
+		if !ok {
+			return nil, fmt.Errorf("variable %q of local address of service %q is not defined in environment of application", name, serviceName)
+		}
+		service, ok := services[serviceName]
+		if !ok {
+			return nil, fmt.Errorf("service %q of local address in variable %q is not defined", serviceName, name)
+		}
+		switch {
+		case value == service.dockerComposeServiceName:
+			res[name] = localHost
+		case regexpPort.MatchString(value):
+			publishedPort, ok := service.ports[value]
+			if !ok {
+				return nil, fmt.Errorf("port %s in variable %q is not published by service %q", value, name, serviceName)
+			}
+			res[name] = publishedPort
+		default:
+			res[name] = rewriteHost(value, service.dockerComposeServiceName, service.ports)
+		}
+	}
+	return res, nil
+}
+
+// localService is docker compose service with its published ports
+type localService struct {
+	dockerComposeServiceName string
+	// container port -> host port
+	ports map[string]string
+}
+
+// localServices returns docker compose services by names of services in config of framework
+func localServices(dockerComposeConfig *docker_compose.DockerComposeConfig) map[string]localService {
+	res := make(map[string]localService, len(dockerComposeConfig.Services))
+	for dockerComposeServiceName, service := range dockerComposeConfig.Services {
+		res[dockerComposeConfig.ServiceName(dockerComposeServiceName)] = localService{
+			dockerComposeServiceName: dockerComposeServiceName,
+			ports:                    publishedPorts(service),
+		}
+	}
+	return res
+}
+
+var (
+	regexpPort = regexp.MustCompile(`^\d+$`)
+	// host names with optional port, host name is whole word of value
+	regexpHost = regexp.MustCompile(`([\w.\-]+)(:(\d+))?`)
+)
+
+// rewriteHost replaces addresses of docker compose service in value by address on localhost
+func rewriteHost(value string, dockerComposeServiceName string, ports map[string]string) string {
+	return regexpHost.ReplaceAllStringFunc(value, func(address string) string {
+		match := regexpHost.FindStringSubmatch(address)
+		if match[1] != dockerComposeServiceName {
+			return address
+		}
+		if match[3] != "" {
+			port := match[3]
+			if publishedPort, ok := ports[port]; ok {
+				port = publishedPort
+			}
+			return localHost + ":" + port
+		}
+		if len(ports) == 1 {
+			for _, publishedPort := range ports {
+				return localHost + ":" + publishedPort
+			}
+		}
+		return localHost
+	})
+}
+
+func rewriteVolumePath(value string, volumes []string) string {
+	for _, volume := range volumes {
+		parts := strings.Split(volume, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		hostPath, containerPath := parts[0], parts[1]
+		if value == containerPath || strings.HasPrefix(value, containerPath+"/") {
+			return hostPath + strings.TrimPrefix(value, containerPath)
+		}
+	}
+	return value
+}
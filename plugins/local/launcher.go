@@ -0,0 +1,392 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"integration_framework/application_config"
+	"integration_framework/plugins"
+	"integration_framework/plugins/docker_compose"
+	"integration_framework/testing"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+const dockerComposeConfigFilename = "docker-compose.generated-local.yml"
+
+func NewLauncher(tmpDirectory string, logs *plugins.RunLogs) *Launcher {
+	return &Launcher{
+		tmpDirectory: tmpDirectory,
+		logs:         logs,
+		allocator:    docker_compose.NewAllocator(tmpDirectory),
+		projectName:  docker_compose.NewProjectName(),
+	}
+}
+
+// Launcher runs application as process on host, backing services are started by docker-compose
+type Launcher struct {
+	tmpDirectory     string
+	logs             *plugins.RunLogs
+	allocator        *docker_compose.Allocator
+	projectName      string
+	applicationsUrls map[string]string
+	// config of running services. it is nil if services are not launched or launch failed
+	launchedConfig  *docker_compose.DockerComposeConfig
+	lastWritedFiles map[string][]byte
+	// services started inside of framework process instead of containers
+	inProcessServices []IServiceInProcess
+	// docker-compose was started, so project should be shut down
+	launched bool
+	logsCmds []*exec.Cmd
+	// mutex guards fields below, they are used by Shutdown and watcher of sources called from other goroutines
+	mutex             sync.Mutex
+	shutdownRequested bool
+	cancelContext     func()
+
+	// processMutex guards application process and its definition
+	processMutex       sync.Mutex
+	process            *process
+	command            string
+	applicationName    string
+	applicationPath    string
+	environment        map[string]string
+	stopWatchingSource func()
+}
+
+// IServiceInProcess is implemented by services which can be run inside of framework process instead of container
+type IServiceInProcess interface {
+	StartInProcess() error
+	StopInProcess() error
+}
+
+func (l *Launcher) createConfig(config *application_config.Config, applicationName string, services map[string]plugins.IService) (dockerComposeConfig *docker_compose.DockerComposeConfig, diff docker_compose.ConfigDiff, inProcessServices []IServiceInProcess, environment map[string]string, err error) {
+	// application listens port on host by itself, so the port is reserved instead of allocating port to publish
+	localConfig := *config
+	localApplication := *config.Applications[applicationName]
+	localApplication.HostPort = localApplicationPort(&localApplication)
+	localConfig.Applications = map[string]*application_config.ApplicationConfig{applicationName: &localApplication}
+	dockerComposeConfig, files, err := docker_compose.NewDockerComposeConfig(l.allocator, &localConfig, services)
+	if err != nil {
+		return nil, diff, nil, nil, fmt.Errorf("unable to generate docker-compose config: %v", err)
+	}
+	applicationService := dockerComposeConfig.Services[applicationName]
+	delete(dockerComposeConfig.Services, applicationName)
+	// values from environment have priority over values from env files like in docker compose
+	applicationEnvironment, err := docker_compose.ReadEnvFiles(applicationService.EnvFile)
+	if err != nil {
+		return nil, diff, nil, nil, err
+	}
+	for name, value := range applicationService.Environment {
+		applicationEnvironment[name] = value
+	}
+	environment, err = localEnvironment(applicationEnvironment, localApplication.LocalAddresses, localServices(dockerComposeConfig), applicationService.Volumes)
+	if err != nil {
+		return nil, diff, nil, nil, err
+	}
+	// addresses of services started in process are the same as published ports of their containers
+	for dockerComposeServiceName := range dockerComposeConfig.Services {
+		service, ok := services[dockerComposeConfig.ServiceName(dockerComposeServiceName)].(IServiceInProcess)
+		if ok {
+			inProcessServices = append(inProcessServices, service)
+			delete(dockerComposeConfig.Services, dockerComposeServiceName)
+		}
+	}
+
+	if l.lastWritedFiles == nil {
+		l.lastWritedFiles = make(map[string][]byte)
+	}
+	filesChangedServices, err := docker_compose.WriteChangedFiles(l.lastWritedFiles, files)
+	if err != nil {
+		return nil, diff, nil, nil, fmt.Errorf("unable to write services configs: %v", err)
+	}
+	diff, err = docker_compose.DiffConfigs(l.launchedConfig, dockerComposeConfig, services, filesChangedServices)
+	if err != nil {
+		return nil, diff, nil, nil, fmt.Errorf("unable to compare docker compose configs: %v", err)
+	}
+	// changed files are already written, so services can reload them
+	diff.HotReloadServices(dockerComposeConfig, services)
+	if len(diff.Recreate) != 0 || len(diff.Remove) != 0 {
+		dockerComposeBytes, err := yaml.Marshal(dockerComposeConfig)
+		if err != nil {
+			return nil, diff, nil, nil, fmt.Errorf("unable to marshal docker compose config: %v", err)
+		}
+		err = ioutil.WriteFile(filepath.Join(l.tmpDirectory, dockerComposeConfigFilename), dockerComposeBytes, os.ModePerm)
+		if err != nil {
+			return nil, diff, nil, nil, fmt.Errorf("unable to write docker compose config: %v", err)
+		}
+	}
+	return dockerComposeConfig, diff, inProcessServices, environment, nil
+}
+
+func (l *Launcher) ConfigUpdated(config *application_config.Config, services map[string]plugins.IService) error {
+	// only one process is supervised, so several applications can not be launched
+	if len(config.Applications) != 1 {
+		return fmt.Errorf("local launcher supports only one application")
+	}
+	applicationName := config.ApplicationsNames()[0]
+	application := config.Applications[applicationName]
+	if application.Command == "" {
+		return fmt.Errorf("application command should be defined for local launcher")
+	}
+	dockerComposeConfig, diff, inProcessServices, environment, err := l.createConfig(config, applicationName, services)
+	if err != nil {
+		return fmt.Errorf("unable to create config: %v", err)
+	}
+	ctx := l.launchContext()
+
+	if len(diff.Recreate) != 0 || len(diff.Remove) != 0 {
+		err = docker_compose.CheckLocalImages(dockerComposeConfig)
+		if err != nil {
+			return err
+		}
+		err = l.updateServices(diff)
+		if err != nil {
+			// state of services is unknown, so all of them will be recreated next time
+			l.launchedConfig = nil
+			return fmt.Errorf("unable to update services: %v", err)
+		}
+		l.launchedConfig = dockerComposeConfig
+	}
+	err = l.restartInProcessServices(inProcessServices)
+	if err != nil {
+		return err
+	}
+	err = plugins.WaitForServicesReady(ctx, config, services)
+	if err != nil {
+		if l.isShutdownRequested() {
+			return nil
+		}
+		return err
+	}
+
+	// services keep their host ports when recreated, so application is restarted only if its own definition changed
+	applicationChanged := l.command != application.Command || l.applicationPath != application.Path
+	if testing.IsEqual(l.environment, environment) != nil {
+		applicationChanged = true
+	}
+	if applicationChanged || l.process == nil {
+		l.processMutex.Lock()
+		l.command = application.Command
+		l.applicationName = applicationName
+		l.environment = environment
+		err = l.watchApplicationSources(application.Path)
+		if err == nil {
+			err = l.restartApplication()
+		}
+		l.processMutex.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return l.waitForApplication(ctx, config, applicationName)
+}
+
+// updateServices creates or recreates changed services and removes services not defined anymore
+func (l *Launcher) updateServices(diff docker_compose.ConfigDiff) error {
+	fmt.Println("--------------------> launch services")
+	pathToConfig := filepath.Join(l.tmpDirectory, dockerComposeConfigFilename)
+	l.launched = true
+	cmd, err := docker_compose.RunDockerCompose(l.projectName, pathToConfig, docker_compose.UpServicesArgs(diff.Recreate, l.launchedConfig != nil)...)
+	if err != nil {
+		return fmt.Errorf("unable to launch docker compose: %v", err)
+	}
+	err = cmd.Wait()
+	if err != nil {
+		return fmt.Errorf("unable to launch services: %v", err)
+	}
+	// logs of recreated containers are not followed by already running cmds
+	logsCmds, err := docker_compose.FollowServicesLogs(l.projectName, pathToConfig, diff.Recreate, l.logs)
+	l.logsCmds = append(l.logsCmds, logsCmds...)
+	return err
+}
+
+// restartInProcessServices stops services of previous config and starts new ones. Services are recreated on every config update, so they are restarted every time
+func (l *Launcher) restartInProcessServices(inProcessServices []IServiceInProcess) error {
+	err := l.stopInProcessServices()
+	if err != nil {
+		return err
+	}
+	for _, service := range inProcessServices {
+		err := service.StartInProcess()
+		if err != nil {
+			return fmt.Errorf("unable to start service in process: %v", err)
+		}
+		l.inProcessServices = append(l.inProcessServices, service)
+	}
+	return nil
+}
+
+func (l *Launcher) stopInProcessServices() error {
+	for _, service := range l.inProcessServices {
+		err := service.StopInProcess()
+		if err != nil {
+			return fmt.Errorf("unable to stop service in process: %v", err)
+		}
+	}
+	l.inProcessServices = nil
+	return nil
+}
+
+func (l *Launcher) stopServices() error {
+	err := l.stopInProcessServices()
+	if err != nil {
+		return err
+	}
+	docker_compose.StopFollowingLogs(l.logsCmds)
+	l.logsCmds = nil
+	if !l.launched {
+		return nil
+	}
+	shutdownCmd, err := docker_compose.RunDockerCompose(l.projectName, filepath.Join(l.tmpDirectory, dockerComposeConfigFilename), "down")
+	if err != nil {
+		return fmt.Errorf("unable to shutdown services: %v", err)
+	}
+	err = shutdownCmd.Wait()
+	if err != nil {
+		fmt.Printf("unable to wait for shutdown cmd: %v\n", err)
+	}
+	l.launched = false
+	return nil
+}
+
+// watchApplicationSources restarts application when its sources changed. should be called with locked processMutex
+func (l *Launcher) watchApplicationSources(applicationPath string) error {
+	if l.stopWatchingSource != nil && l.applicationPath == applicationPath {
+		return nil
+	}
+	if l.stopWatchingSource != nil {
+		l.stopWatchingSource()
+		l.stopWatchingSource = nil
+	}
+	stop, err := watchSources(applicationPath, l.tmpDirectory, func() {
+		l.processMutex.Lock()
+		defer l.processMutex.Unlock()
+		if l.isShutdownRequested() || l.process == nil {
+			return
+		}
+		fmt.Println("--> application sources changed, restarting")
+		err := l.restartApplication()
+		if err != nil {
+			fmt.Printf("unable to restart application: %v\n", err)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("unable to watch application sources: %v", err)
+	}
+	l.applicationPath = applicationPath
+	l.stopWatchingSource = stop
+	return nil
+}
+
+// restartApplication should be called with locked processMutex
+func (l *Launcher) restartApplication() error {
+	if l.process != nil {
+		err := l.process.stop()
+		if err != nil {
+			return fmt.Errorf("unable to stop application: %v", err)
+		}
+		l.process = nil
+	}
+	fmt.Println("--------------------> launch app")
+	logsWriter, err := l.logs.Writer(l.applicationName)
+	if err != nil {
+		return err
+	}
+	p, err := startProcess(l.command, l.applicationPath, l.environment, logsWriter)
+	if err != nil {
+		return fmt.Errorf("unable to launch application: %v", err)
+	}
+	l.process = p
+	fmt.Println("---> launched")
+	return nil
+}
+
+// localApplicationPort returns port listened by application on host. It is `host_port` if defined or port of application in container
+func localApplicationPort(application *application_config.ApplicationConfig) int {
+	if application.HostPort != 0 {
+		return application.HostPort
+	}
+	return docker_compose.ApplicationContainerPort(application)
+}
+
+func (l *Launcher) waitForApplication(ctx context.Context, config *application_config.Config, applicationName string) error {
+	applicationPort := localApplicationPort(config.Applications[applicationName])
+	l.applicationsUrls = map[string]string{
+		applicationName: fmt.Sprintf("http://localhost:%d", applicationPort),
+	}
+	err := plugins.WaitForApplicationReady(ctx, config, applicationName, fmt.Sprintf("localhost:%d", applicationPort), plugins.ProbeFunc(func(ctx context.Context) error {
+		l.processMutex.Lock()
+		defer l.processMutex.Unlock()
+		if l.process == nil {
+			return plugins.FatalProbeError{Err: fmt.Errorf("application stopped")}
+		}
+		err := l.process.exited()
+		if err != nil {
+			return plugins.FatalProbeError{Err: l.logs.WithTail(applicationName, err)}
+		}
+		return nil
+	}))
+	if err != nil {
+		if l.isShutdownRequested() {
+			return nil
+		}
+		return err
+	}
+	fmt.Println("---> return from launch application")
+	return nil
+}
+
+func (l *Launcher) Shutdown() error {
+	l.mutex.Lock()
+	l.shutdownRequested = true
+	if l.cancelContext != nil {
+		l.cancelContext()
+	}
+	l.mutex.Unlock()
+	l.processMutex.Lock()
+	if l.stopWatchingSource != nil {
+		l.stopWatchingSource()
+		l.stopWatchingSource = nil
+	}
+	if l.process != nil {
+		err := l.process.stop()
+		if err != nil {
+			fmt.Printf("unable to stop application: %v\n", err)
+		}
+		l.process = nil
+	}
+	l.processMutex.Unlock()
+	err := l.stopServices()
+	if err != nil {
+		return fmt.Errorf("unable to stop services: %v", err)
+	}
+	l.launchedConfig = nil
+	l.lastWritedFiles = nil
+	return nil
+}
+
+func (l *Launcher) isShutdownRequested() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.shutdownRequested
+}
+
+// launchContext cancels context of previous launch and returns context of new one
+func (l *Launcher) launchContext() context.Context {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.shutdownRequested = false
+	if l.cancelContext != nil {
+		l.cancelContext()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.cancelContext = cancel
+	return ctx
+}
+
+func (l *Launcher) ApplicationsUrls() map[string]string {
+	return l.applicationsUrls
+}
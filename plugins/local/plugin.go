@@ -0,0 +1,11 @@
+package local
+
+import (
+	"integration_framework/plugins"
+)
+
+func init() {
+	plugins.DefineLauncher("local", func(tmpDirectory string, logs *plugins.RunLogs) (plugins.ILauncher, error) {
+		return NewLauncher(tmpDirectory, logs), nil
+	})
+}
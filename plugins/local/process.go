@@ -0,0 +1,88 @@
+package local
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"syscall"
+	"time"
+)
+
+const stopTimeout = 10 * time.Second
+
+// process is application started on host.
+// It is started in its own process group so children (like binary built by `go run`) are stopped too
+type process struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+// startProcess starts command, its stdout and stderr are written to output
+func startProcess(command string, directory string, environment map[string]string, output io.Writer) (*process, error) {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = directory
+	cmd.Env = os.Environ()
+	var names []string
+	for name := range environment {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", name, environment[name]))
+	}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	err := cmd.Start()
+	if err != nil {
+		return nil, fmt.Errorf("unable to start cmd: %v", err)
+	}
+	p := &process{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// exited returns error if process is not running anymore
+func (p *process) exited() error {
+	select {
+	case <-p.done:
+		if p.err != nil {
+			return fmt.Errorf("application exited: %v", p.err)
+		}
+		return fmt.Errorf("application exited with code 0")
+	default:
+		return nil
+	}
+}
+
+func (p *process) stop() error {
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+	err := syscall.Kill(-p.cmd.Process.Pid, syscall.SIGINT)
+	if err != nil {
+		return fmt.Errorf("unable to interrupt application: %v", err)
+	}
+	select {
+	case <-p.done:
+		return nil
+	case <-time.After(stopTimeout):
+	}
+	err = syscall.Kill(-p.cmd.Process.Pid, syscall.SIGKILL)
+	if err != nil {
+		return fmt.Errorf("unable to kill application: %v", err)
+	}
+	<-p.done
+	return nil
+}
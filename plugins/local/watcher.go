@@ -0,0 +1,85 @@
+package local
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const debounceDuration = 500 * time.Millisecond
+
+// watchSources calls onChange when files in directory (or its subdirectories) changed.
+// Hidden files and directories and directory ignoredDirectory are not watched
+func watchSources(directory string, ignoredDirectory string, onChange func()) (stop func(), err error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create watcher: %v", err)
+	}
+	err = filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if path != directory && (isHidden(path) || path == ignoredDirectory) {
+			return filepath.SkipDir
+		}
+		err = watcher.Add(path)
+		if err != nil {
+			return fmt.Errorf("unable to add directory %q to watcher: %v", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("unable to add sources to watcher: %v", err)
+	}
+
+	stopChan := make(chan struct{})
+	go func() {
+		defer watcher.Close()
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-stopChan:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if isHidden(event.Name) {
+					continue
+				}
+				if event.Op&fsnotify.Create == fsnotify.Create {
+					info, err := os.Stat(event.Name)
+					if err == nil && info.IsDir() && event.Name != ignoredDirectory {
+						err = watcher.Add(event.Name)
+						if err != nil {
+							fmt.Printf("unable to add directory %q to watcher: %v\n", event.Name, err)
+						}
+					}
+				}
+				debounce = time.After(debounceDuration)
+			case <-debounce:
+				debounce = nil
+				onChange()
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Println("watcher error", err)
+			}
+		}
+	}()
+	return func() {
+		close(stopChan)
+	}, nil
+}
+
+func isHidden(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ".")
+}
@@ -0,0 +1,233 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CrashLogLinesCount is count of last lines of logs printed when application or service dies
+const CrashLogLinesCount = 50
+
+// RunLogs stores output of services and applications of current run in files `<tmp directory>/logs/<run>/<name>.log`.
+// Output is printed to stdout too, every line is prefixed with name
+type RunLogs struct {
+	directory string
+	mutex     sync.Mutex
+	files     map[string]*logFile
+}
+
+func NewRunLogs(tmpDirectory string) *RunLogs {
+	return &RunLogs{
+		directory: filepath.Join(tmpDirectory, "logs", time.Now().Format("20060102-150405")),
+		files:     make(map[string]*logFile),
+	}
+}
+
+// Writer returns writer of logs of service or application. Logs of relaunched service are appended to the same file
+func (l *RunLogs) Writer(name string) (io.Writer, error) {
+	return l.file(name)
+}
+
+// Offset returns size of logs of service or application written to the moment
+func (l *RunLogs) Offset(name string) int64 {
+	l.mutex.Lock()
+	file, ok := l.files[name]
+	l.mutex.Unlock()
+	if !ok {
+		return 0
+	}
+	file.mutex.Lock()
+	defer file.mutex.Unlock()
+	return file.size
+}
+
+// Read returns logs of service or application written between offsets
+func (l *RunLogs) Read(name string, from int64, to int64) (string, error) {
+	if to <= from {
+		return "", nil
+	}
+	l.mutex.Lock()
+	file, ok := l.files[name]
+	l.mutex.Unlock()
+	if !ok {
+		return "", nil
+	}
+	res := make([]byte, to-from)
+	_, err := file.readAt(res, from)
+	if err != nil {
+		return "", fmt.Errorf("unable to read logs of %q: %v", name, err)
+	}
+	return string(res), nil
+}
+
+// Tail returns last lines of logs of service or application
+func (l *RunLogs) Tail(name string, linesCount int) (string, error) {
+	logs, err := l.Read(name, 0, l.Offset(name))
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	if len(lines) > linesCount {
+		lines = lines[len(lines)-linesCount:]
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// WithTail appends last lines of logs of service or application to error
+func (l *RunLogs) WithTail(name string, err error) error {
+	tail, tailErr := l.Tail(name, CrashLogLinesCount)
+	if tailErr != nil || tail == "" {
+		return err
+	}
+	return fmt.Errorf("%v\nlast lines of logs of %q:\n%s", err, name, tail)
+}
+
+// StartTest remembers offsets of all logs, so logs written during test can be read
+func (l *RunLogs) StartTest(application string) *TestLogs {
+	l.mutex.Lock()
+	var names []string
+	for name := range l.files {
+		names = append(names, name)
+	}
+	l.mutex.Unlock()
+	offsets := make(map[string]int64, len(names))
+	for _, name := range names {
+		offsets[name] = l.Offset(name)
+	}
+	return &TestLogs{
+		Application: application,
+		logs:        l,
+		offsets:     offsets,
+	}
+}
+
+// Close closes files of logs. Logs written or read after closing reopen their files, offsets of logs are kept
+func (l *RunLogs) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for name, file := range l.files {
+		err := file.close()
+		if err != nil {
+			return fmt.Errorf("unable to close logs of %q: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (l *RunLogs) file(name string) (*logFile, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if file, ok := l.files[name]; ok {
+		return file, nil
+	}
+	err := os.MkdirAll(l.directory, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create logs directory: %v", err)
+	}
+	path := filepath.Join(l.directory, name+".log")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create logs file: %v", err)
+	}
+	file := &logFile{
+		name: name,
+		path: path,
+		file: f,
+	}
+	l.files[name] = file
+	return file, nil
+}
+
+// TestLogs gives access to logs written since test started
+type TestLogs struct {
+	// name of application which receives request of test
+	Application string
+	logs        *RunLogs
+	offsets     map[string]int64
+}
+
+// Read returns logs of service or application written since test started
+func (t *TestLogs) Read(name string) (string, error) {
+	return t.logs.Read(name, t.offsets[name], t.logs.Offset(name))
+}
+
+// IServiceWithTestLogs is implemented by services which check logs written during test
+type IServiceWithTestLogs interface {
+	TestStarted(logs *TestLogs)
+}
+
+// logFile writes logs to file and prints every complete line to stdout
+type logFile struct {
+	name  string
+	path  string
+	mutex sync.Mutex
+	// file is nil if logs are closed
+	file        *os.File
+	size        int64
+	partialLine []byte
+}
+
+// open reopens closed file without truncating it. Should be called with locked mutex
+func (f *logFile) open() error {
+	if f.file != nil {
+		return nil
+	}
+	file, err := os.OpenFile(f.path, os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to reopen logs file: %v", err)
+	}
+	f.file = file
+	return nil
+}
+
+func (f *logFile) close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *logFile) readAt(p []byte, offset int64) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	err := f.open()
+	if err != nil {
+		return 0, err
+	}
+	return f.file.ReadAt(p, offset)
+}
+
+func (f *logFile) Write(p []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	err := f.open()
+	if err != nil {
+		return 0, err
+	}
+	n, err := f.file.WriteAt(p, f.size)
+	f.size += int64(n)
+	if err != nil {
+		return n, err
+	}
+	f.partialLine = append(f.partialLine, p...)
+	for {
+		i := bytes.IndexByte(f.partialLine, '\n')
+		if i == -1 {
+			break
+		}
+		fmt.Printf("%s | %s\n", f.name, f.partialLine[:i])
+		f.partialLine = f.partialLine[i+1:]
+	}
+	f.partialLine = append([]byte(nil), f.partialLine...)
+	return n, nil
+}
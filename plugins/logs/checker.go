@@ -0,0 +1,165 @@
+package logs
+
+import (
+	"fmt"
+	"integration_framework/helper"
+	"integration_framework/plugins"
+	"strings"
+	"time"
+)
+
+const checkInterval = 100 * time.Millisecond
+
+type ICheck interface {
+	Check(lines []string, variables map[string]interface{}) error
+}
+
+func (s *Service) Checker(param interface{}) (plugins.IServiceChecker, error) {
+	configsList, ok := param.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("service check for logs must be list, but it is %T (%#v)", param, param)
+	}
+	var checks []logsCheck
+	for _, configInterface := range configsList {
+		configYaml, ok := helper.IsYamlMap(configInterface)
+		if !ok {
+			return nil, fmt.Errorf("service check for logs must be map, but it is %T (%#v)", configInterface, configInterface)
+		}
+		check, negative, err := newCheck(configYaml.ToMap())
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, logsCheck{
+			check:    check,
+			negative: negative,
+		})
+	}
+
+	return &Checker{
+		service: s,
+		checks:  checks,
+	}, nil
+}
+
+// newCheck creates check from map with one of keys `contains`, `regexp`, `json` (or with prefix `not_` to check that there is no such lines) and optional `count`.
+// Check is negative if it expects no matched lines
+func newCheck(config map[string]interface{}) (check ICheck, negative bool, err error) {
+	count, err := parseCount(config)
+	if err != nil {
+		return nil, false, err
+	}
+	var checks []ICheck
+	for key, value := range config {
+		if key == "count" {
+			continue
+		}
+		checkCount := count
+		if strings.HasPrefix(key, "not_") {
+			if count != nil {
+				return nil, false, fmt.Errorf("count can not be used with %s", key)
+			}
+			checkCount = new(int)
+			key = strings.TrimPrefix(key, "not_")
+		}
+		negative = checkCount != nil && *checkCount == 0
+		switch key {
+		case "contains":
+			substring, ok := value.(string)
+			if !ok {
+				return nil, false, fmt.Errorf("logs contains check should be string, but it is %T (%#v)", value, value)
+			}
+			checks = append(checks, NewContainsCheck(substring, checkCount))
+		case "regexp":
+			expression, ok := value.(string)
+			if !ok {
+				return nil, false, fmt.Errorf("logs regexp check should be string, but it is %T (%#v)", value, value)
+			}
+			checks = append(checks, NewRegexpCheck(expression, checkCount))
+		case "json":
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, false, fmt.Errorf("logs json check should be map, but it is %T (%#v)", value, value)
+			}
+			checks = append(checks, NewJsonCheck(fields, checkCount))
+		default:
+			return nil, false, fmt.Errorf("checker %q not defined for logs", key)
+		}
+	}
+	if len(checks) != 1 {
+		return nil, false, fmt.Errorf("logs check should contain exactly one of contains, regexp or json, but it is %#v", config)
+	}
+	return checks[0], negative, nil
+}
+
+func parseCount(config map[string]interface{}) (*int, error) {
+	if config["count"] == nil {
+		return nil, nil
+	}
+	count, ok := config["count"].(int)
+	if !ok || count < 0 {
+		return nil, fmt.Errorf("count should be non-negative integer, but it is %T (%#v)", config["count"], config["count"])
+	}
+	return &count, nil
+}
+
+// checkCount checks count of matched lines. If count is not defined then at least one line should match
+func checkCount(description string, matched int, count *int) error {
+	if count == nil {
+		if matched == 0 {
+			return fmt.Errorf("there are no lines %s", description)
+		}
+		return nil
+	}
+	if matched != *count {
+		return fmt.Errorf("expected %d line(s) %s, but found %d", *count, description, matched)
+	}
+	return nil
+}
+
+type Checker struct {
+	service *Service
+	checks  []logsCheck
+}
+
+type logsCheck struct {
+	check ICheck
+	// negative check expects no matched lines, so it passes only if there are still no such lines at timeout
+	negative bool
+}
+
+// CheckService repeats checks until timeout because application can write logs after response.
+// Positive checks pass as soon as lines are found. Negative checks pass only if there are still no such lines at timeout,
+// but they fail as soon as lines are found because written lines don't disappear
+func (pcc Checker) CheckService(saveResult plugins.FnResultSaver, variables map[string]interface{}) error {
+	deadline := time.Now().Add(pcc.service.timeout)
+	for {
+		done, err := pcc.check(variables)
+		if done || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(checkInterval)
+	}
+}
+
+// check returns done if result of checks can not change later
+func (pcc Checker) check(variables map[string]interface{}) (done bool, err error) {
+	lines, err := pcc.service.lines()
+	if err != nil {
+		return false, fmt.Errorf("unable to read logs: %v", err)
+	}
+	done = true
+	var firstErr error
+	for i, check := range pcc.checks {
+		err := check.check.Check(lines, variables)
+		if err != nil && check.negative {
+			return true, fmt.Errorf("unable to check logs %d: %v", i, err)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("unable to check logs %d: %v", i, err)
+		}
+		if err != nil || check.negative {
+			done = false
+		}
+	}
+	return done, firstErr
+}
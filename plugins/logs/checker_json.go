@@ -0,0 +1,45 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"integration_framework/helper"
+	"integration_framework/testing"
+)
+
+// JsonCheck checks structured logs. Line matches if it is json object containing expected fields
+type JsonCheck struct {
+	fields map[string]interface{}
+	count  *int
+}
+
+func NewJsonCheck(fields map[string]interface{}, count *int) *JsonCheck {
+	return &JsonCheck{
+		fields: fields,
+		count:  count,
+	}
+}
+
+func (c JsonCheck) Check(lines []string, variables map[string]interface{}) error {
+	interpolatedFields, err := helper.ApplyInterpolationForObject(c.fields, variables)
+	if err != nil {
+		return fmt.Errorf("unable to apply interpolation: %v", err)
+	}
+	expectedFields, err := testing.ApplyConverters(interpolatedFields, variables)
+	if err != nil {
+		return fmt.Errorf("unable to apply converters: %v", err)
+	}
+	matched := 0
+	for _, line := range lines {
+		actualFields := make(map[string]interface{})
+		err := json.Unmarshal([]byte(line), &actualFields)
+		if err != nil {
+			// not structured line
+			continue
+		}
+		if testing.IsEqual(actualFields, expectedFields) == nil {
+			matched++
+		}
+	}
+	return checkCount(fmt.Sprintf("with fields %v", interpolatedFields), matched, c.count)
+}
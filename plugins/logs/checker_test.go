@@ -0,0 +1,120 @@
+package logs
+
+import (
+	"gopkg.in/yaml.v2"
+	"integration_framework/plugins"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+const testTimeout = 300 * time.Millisecond
+
+func newTestService(t *testing.T) (*Service, io.Writer, func()) {
+	tmpDirectory, err := ioutil.TempDir("", "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	runLogs := plugins.NewRunLogs(tmpDirectory)
+	writer, err := runLogs.Writer("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := NewService("logs", "", testTimeout)
+	service.TestStarted(runLogs.StartTest("app"))
+	return service, writer, func() {
+		runLogs.Close()
+		os.RemoveAll(tmpDirectory)
+	}
+}
+
+// checkLogs runs checks defined in yaml like in config of test
+func checkLogs(t *testing.T, service *Service, config string) (error, time.Duration) {
+	var param interface{}
+	err := yaml.Unmarshal([]byte(config), &param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker, err := service.Checker(param)
+	if err != nil {
+		t.Fatalf("unable to create checker: %v", err)
+	}
+	started := time.Now()
+	err = checker.CheckService(func(key string, value interface{}) {}, nil)
+	return err, time.Since(started)
+}
+
+func writeLater(writer io.Writer, line string) {
+	go func() {
+		time.Sleep(testTimeout / 3)
+		writer.Write([]byte(line + "\n"))
+	}()
+}
+
+func TestCheckServicePositiveReturnsWhenFound(t *testing.T) {
+	service, writer, cleanup := newTestService(t)
+	defer cleanup()
+	writeLater(writer, "user created")
+
+	err, duration := checkLogs(t, service, "- contains: user created")
+	if err != nil {
+		t.Fatalf("expected check to pass, got %v", err)
+	}
+	if duration >= testTimeout {
+		t.Errorf("expected check to pass before timeout, it took %v", duration)
+	}
+}
+
+func TestCheckServicePositiveFailsAtTimeout(t *testing.T) {
+	service, _, cleanup := newTestService(t)
+	defer cleanup()
+
+	err, duration := checkLogs(t, service, "- contains: user created")
+	if err == nil {
+		t.Fatalf("expected check to fail")
+	}
+	if duration < testTimeout {
+		t.Errorf("expected check to wait for timeout, it took %v", duration)
+	}
+}
+
+func TestCheckServiceNegativeWaitsForTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{"not_contains", "- not_contains: panic"},
+		{"count 0", "- {regexp: ^panic, count: 0}"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service, writer, cleanup := newTestService(t)
+			defer cleanup()
+			writeLater(writer, "panic: nil pointer dereference")
+
+			err, duration := checkLogs(t, service, test.config)
+			if err == nil {
+				t.Fatalf("expected check to fail for line written after check started")
+			}
+			if duration >= testTimeout {
+				t.Errorf("expected check to fail as soon as line is written, it took %v", duration)
+			}
+		})
+	}
+}
+
+func TestCheckServiceNegativePassesAtTimeout(t *testing.T) {
+	service, writer, cleanup := newTestService(t)
+	defer cleanup()
+	writeLater(writer, "user created")
+
+	err, duration := checkLogs(t, service, "- not_contains: panic")
+	if err != nil {
+		t.Fatalf("expected check to pass, got %v", err)
+	}
+	if duration < testTimeout {
+		t.Errorf("expected check to wait for timeout, it took %v", duration)
+	}
+}
@@ -0,0 +1,56 @@
+package logs
+
+import (
+	"fmt"
+	"integration_framework/helper"
+	"regexp"
+	"strings"
+)
+
+// TextCheck checks lines containing substring or matching regexp
+type TextCheck struct {
+	text     string
+	isRegexp bool
+	count    *int
+}
+
+func NewContainsCheck(substring string, count *int) *TextCheck {
+	return &TextCheck{
+		text:  substring,
+		count: count,
+	}
+}
+
+func NewRegexpCheck(expression string, count *int) *TextCheck {
+	return &TextCheck{
+		text:     expression,
+		isRegexp: true,
+		count:    count,
+	}
+}
+
+func (c TextCheck) Check(lines []string, variables map[string]interface{}) error {
+	text, err := helper.ApplyInterpolation(c.text, variables)
+	if err != nil {
+		return fmt.Errorf("unable to interpolate %q: %v", c.text, err)
+	}
+	match := func(line string) bool {
+		return strings.Contains(line, text)
+	}
+	description := fmt.Sprintf("containing %q", text)
+	if c.isRegexp {
+		re, err := regexp.Compile(text)
+		if err != nil {
+			return fmt.Errorf("unable to compile regexp %q: %v", text, err)
+		}
+		match = re.MatchString
+		description = fmt.Sprintf("matching regexp %q", text)
+	}
+	matched := 0
+	for _, line := range lines {
+		if match(line) {
+			matched++
+		}
+	}
+	return checkCount(description, matched, c.count)
+}
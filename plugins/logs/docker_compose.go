@@ -0,0 +1,10 @@
+package logs
+
+import (
+	"integration_framework/plugins/docker_compose"
+)
+
+func (s *Service) GenerateDockerComposeConfig(allocator *docker_compose.Allocator, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, err error) {
+	// logs are captured by launcher, so there is no docker compose service
+	return "", nil, nil
+}
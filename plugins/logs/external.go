@@ -0,0 +1,63 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"integration_framework/plugins/external"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// UseExternalEndpoint uses path to file of logs written by already running application as endpoint.
+// Logs of applications are not captured by external launcher, so option `application` is not used
+func (s *Service) UseExternalEndpoint(endpoint *external.Endpoint) error {
+	s.externalEndpoint = endpoint
+	return nil
+}
+
+func (s *Service) CheckReachable(ctx context.Context) error {
+	info, err := os.Stat(s.externalEndpoint.Address)
+	if err != nil {
+		return fmt.Errorf("unable to stat logs file: %v", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("logs file %q is a directory", s.externalEndpoint.Address)
+	}
+	return nil
+}
+
+// externalSize returns size of external logs file, so only lines written after it are checked
+func (s *Service) externalSize() (int64, error) {
+	info, err := os.Stat(s.externalEndpoint.Address)
+	if err != nil {
+		return 0, fmt.Errorf("unable to stat logs file: %v", err)
+	}
+	return info.Size(), nil
+}
+
+// readExternal returns contents of external logs file written since offset
+func (s *Service) readExternal(offset int64) (string, error) {
+	file, err := os.Open(s.externalEndpoint.Address)
+	if err != nil {
+		return "", fmt.Errorf("unable to open logs file: %v", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("unable to stat logs file: %v", err)
+	}
+	// file was truncated or rotated during test
+	if info.Size() < offset {
+		offset = 0
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return "", fmt.Errorf("unable to seek logs file: %v", err)
+	}
+	contents, err := ioutil.ReadAll(file)
+	if err != nil {
+		return "", fmt.Errorf("unable to read logs file: %v", err)
+	}
+	return string(contents), nil
+}
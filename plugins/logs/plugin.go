@@ -0,0 +1,36 @@
+package logs
+
+import (
+	"fmt"
+	"integration_framework/application_config"
+	"integration_framework/plugins"
+	"time"
+)
+
+const defaultTimeout = time.Second
+
+func init() {
+	plugins.DefineService("logs", func(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
+		var application string
+		if params["application"] != nil {
+			var ok bool
+			application, ok = params["application"].(string)
+			if !ok {
+				return nil, fmt.Errorf("application should be string, but it is %T (%#v)", params["application"], params["application"])
+			}
+		}
+		timeout := defaultTimeout
+		if params["timeout"] != nil {
+			timeoutStr, ok := params["timeout"].(string)
+			if !ok {
+				return nil, fmt.Errorf("timeout should be string, but it is %T (%#v)", params["timeout"], params["timeout"])
+			}
+			var err error
+			timeout, err = time.ParseDuration(timeoutStr)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse timeout: %v", err)
+			}
+		}
+		return NewService(name, application, timeout), nil
+	})
+}
@@ -0,0 +1,78 @@
+package logs
+
+import (
+	"fmt"
+	"integration_framework/plugins"
+	"integration_framework/plugins/external"
+	"strings"
+	"time"
+)
+
+// Service checks logs written by application during test. It launches nothing
+type Service struct {
+	name string
+	// name of application which logs are checked. If it is empty then application of test is used
+	application string
+	// how long to wait for expected lines, because logs are written asynchronously
+	timeout  time.Duration
+	testLogs *plugins.TestLogs
+	// file of logs of already running application and its size when test started
+	externalEndpoint *external.Endpoint
+	externalOffset   int64
+	externalErr      error
+}
+
+func NewService(name string, application string, timeout time.Duration) *Service {
+	return &Service{
+		name:        name,
+		application: application,
+		timeout:     timeout,
+	}
+}
+
+func (s *Service) Start() error {
+	return nil
+}
+
+func (s *Service) ReadinessProbe() plugins.IProbe {
+	return nil
+}
+
+func (s *Service) TestStarted(testLogs *plugins.TestLogs) {
+	s.testLogs = testLogs
+	if s.externalEndpoint != nil {
+		s.externalOffset, s.externalErr = s.externalSize()
+	}
+}
+
+func (s *Service) Preparer(param interface{}) (plugins.IServicePreparer, error) {
+	return nil, fmt.Errorf("logs service can not be prepared")
+}
+
+// lines returns lines of logs written since test started
+func (s *Service) lines() ([]string, error) {
+	if s.testLogs == nil {
+		return nil, fmt.Errorf("logs of test not available")
+	}
+	var logs string
+	var err error
+	if s.externalEndpoint != nil {
+		if s.externalErr != nil {
+			return nil, s.externalErr
+		}
+		logs, err = s.readExternal(s.externalOffset)
+	} else {
+		application := s.application
+		if application == "" {
+			application = s.testLogs.Application
+		}
+		logs, err = s.testLogs.Read(application)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if logs == "" {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(logs, "\n"), "\n"), nil
+}
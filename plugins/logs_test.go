@@ -0,0 +1,159 @@
+package plugins
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestRunLogs creates logs in temporary directory, it should be removed by test
+func newTestRunLogs(t *testing.T) (*RunLogs, string) {
+	tmpDirectory, err := ioutil.TempDir("", "logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRunLogs(tmpDirectory), tmpDirectory
+}
+
+func writeLogs(t *testing.T, writer io.Writer, logs string) {
+	_, err := writer.Write([]byte(logs))
+	if err != nil {
+		t.Fatalf("unable to write logs: %v", err)
+	}
+}
+
+func checkTestLogs(t *testing.T, testLogs *TestLogs, name string, expected string) {
+	logs, err := testLogs.Read(name)
+	if err != nil {
+		t.Fatalf("unable to read logs of %q: %v", name, err)
+	}
+	if logs != expected {
+		t.Errorf("expected logs of %q since test started %q, got %q", name, expected, logs)
+	}
+}
+
+func TestTestLogs(t *testing.T) {
+	runLogs, tmpDirectory := newTestRunLogs(t)
+	defer os.RemoveAll(tmpDirectory)
+	defer runLogs.Close()
+
+	app, err := runLogs.Writer("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLogs(t, app, "starting\n")
+	first := runLogs.StartTest("app")
+	writeLogs(t, app, "first request\npartial ")
+	// logs of service started during test are read from start
+	db, err := runLogs.Writer("db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLogs(t, db, "query 1\n")
+	second := runLogs.StartTest("app")
+	writeLogs(t, app, "line\nsecond request\n")
+	writeLogs(t, db, "query 2\n")
+	third := runLogs.StartTest("app")
+
+	checkTestLogs(t, first, "app", "first request\npartial line\nsecond request\n")
+	checkTestLogs(t, first, "db", "query 1\nquery 2\n")
+	checkTestLogs(t, second, "app", "line\nsecond request\n")
+	checkTestLogs(t, second, "db", "query 2\n")
+	checkTestLogs(t, third, "app", "")
+	checkTestLogs(t, third, "unknown", "")
+	if third.Application != "app" {
+		t.Errorf("expected application of test, got %q", third.Application)
+	}
+
+	logs, err := runLogs.Read("app", 9, 22)
+	if err != nil || logs != "first request" {
+		t.Errorf("expected logs between offsets, got %q, %v", logs, err)
+	}
+	logs, err = runLogs.Read("app", 22, 9)
+	if err != nil || logs != "" {
+		t.Errorf("expected empty logs of reversed offsets, got %q, %v", logs, err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	runLogs, tmpDirectory := newTestRunLogs(t)
+	defer os.RemoveAll(tmpDirectory)
+	defer runLogs.Close()
+
+	app, err := runLogs.Writer("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLogs(t, app, "1\n2\n3\n4\npartial")
+	tests := []struct {
+		name       string
+		linesCount int
+		expected   string
+	}{
+		{"app", 2, "4\npartial"},
+		{"app", 10, "1\n2\n3\n4\npartial"},
+		{"unknown", 2, ""},
+	}
+	for _, test := range tests {
+		tail, err := runLogs.Tail(test.name, test.linesCount)
+		if err != nil {
+			t.Fatalf("unable to get tail: %v", err)
+		}
+		if tail != test.expected {
+			t.Errorf("expected %d last lines of %q %q, got %q", test.linesCount, test.name, test.expected, tail)
+		}
+	}
+	writeLogs(t, app, "\n")
+	tail, err := runLogs.Tail("app", 1)
+	if err != nil || tail != "partial" {
+		t.Errorf("expected last complete line, got %q, %v", tail, err)
+	}
+}
+
+func TestLogsReopenedAfterClose(t *testing.T) {
+	runLogs, tmpDirectory := newTestRunLogs(t)
+	defer os.RemoveAll(tmpDirectory)
+
+	app, err := runLogs.Writer("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLogs(t, app, "before test\n")
+	first := runLogs.StartTest("app")
+	writeLogs(t, app, "before close\n")
+	err = runLogs.Close()
+	if err != nil {
+		t.Fatalf("unable to close logs: %v", err)
+	}
+	checkTestLogs(t, first, "app", "before close\n")
+
+	// writer of closed logs reopens file
+	writeLogs(t, app, "after close\n")
+	second := runLogs.StartTest("app")
+	// relaunched application appends logs to the same file
+	relaunched, err := runLogs.Writer("app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLogs(t, relaunched, "relaunched\n")
+	checkTestLogs(t, first, "app", "before close\nafter close\nrelaunched\n")
+	checkTestLogs(t, second, "app", "relaunched\n")
+	err = runLogs.Close()
+	if err != nil {
+		t.Fatalf("unable to close logs: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(tmpDirectory, "logs", "*", "app.log"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one file of logs, got %v, %v", files, err)
+	}
+	content, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "before test\nbefore close\nafter close\nrelaunched\n" {
+		t.Errorf("unexpected file of logs %q", content)
+	}
+}
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"fmt"
+	"integration_framework/helper"
+	"integration_framework/plugins"
+)
+
+func (s *Service) Checker(param interface{}) (plugins.IServiceChecker, error) {
+	configsList, ok := param.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("service check for metrics must be list, but it is %T (%#v)", param, param)
+	}
+	var checks []MetricCheck
+	for _, configInterface := range configsList {
+		configYaml, ok := helper.IsYamlMap(configInterface)
+		if !ok {
+			return nil, fmt.Errorf("service check for metrics must be map, but it is %T (%#v)", configInterface, configInterface)
+		}
+		check, err := newMetricCheck(configYaml.ToMap())
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, *check)
+	}
+
+	return &Checker{
+		service: s,
+		checks:  checks,
+	}, nil
+}
+
+// Checker scrapes metrics before request of test to check deltas after request.
+// Checker is shared by runs of parametrized test case, so metrics scraped before request are kept in checker
+// between BeforeRequest and CheckService of one run. It relies on testers being executed sequentially by runner
+type Checker struct {
+	service *Service
+	checks  []MetricCheck
+	// metrics scraped before request of current run, nil if there are no delta checks or run is finished
+	before map[string]*MetricFamily
+}
+
+func (pcc *Checker) BeforeRequest(variables map[string]interface{}) error {
+	pcc.before = nil
+	for _, check := range pcc.checks {
+		if !check.isDelta() {
+			continue
+		}
+		before, err := pcc.service.scrape(variables)
+		if err != nil {
+			return fmt.Errorf("unable to scrape metrics before request: %v", err)
+		}
+		pcc.before = before
+		return nil
+	}
+	return nil
+}
+
+func (pcc *Checker) CheckService(saveResult plugins.FnResultSaver, variables map[string]interface{}) error {
+	before := pcc.before
+	// metrics of this run should not be used by next run
+	pcc.before = nil
+	after, err := pcc.service.scrape(variables)
+	if err != nil {
+		return fmt.Errorf("unable to scrape metrics: %v", err)
+	}
+	for i, check := range pcc.checks {
+		if check.isDelta() && before == nil {
+			return fmt.Errorf("unable to check metrics %d: metrics were not scraped before request", i)
+		}
+		err := check.Check(before, after, variables)
+		if err != nil {
+			return fmt.Errorf("unable to check metrics %d: %v", i, err)
+		}
+	}
+	return nil
+}
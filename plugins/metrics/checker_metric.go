@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"fmt"
+	"integration_framework/helper"
+	"math"
+	"sort"
+	"strings"
+)
+
+const epsilon = 1e-9
+
+// MetricCheck checks sum of values of samples with specified name and labels.
+// Exactly one of value, delta (difference between values after and before request), count and count_delta (for histograms and summaries) is defined
+type MetricCheck struct {
+	name       string
+	labels     map[string]string
+	value      *float64
+	delta      *float64
+	count      *float64
+	countDelta *float64
+}
+
+func newMetricCheck(config map[string]interface{}) (*MetricCheck, error) {
+	name, ok := config["name"].(string)
+	if !ok {
+		return nil, fmt.Errorf("name of metric (string) should be defined for metrics check %#v", config)
+	}
+	check := MetricCheck{
+		name:   name,
+		labels: make(map[string]string),
+	}
+	if config["labels"] != nil {
+		labels, ok := config["labels"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("labels of metric %q should be map, but it is %T (%#v)", name, config["labels"], config["labels"])
+		}
+		for labelName, labelValue := range labels {
+			check.labels[labelName] = fmt.Sprintf("%v", labelValue)
+		}
+	}
+	var err error
+	check.value, err = parseOptionalNumber(config, "value")
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of metric %q: %v", name, err)
+	}
+	check.delta, err = parseOptionalNumber(config, "delta")
+	if err != nil {
+		return nil, fmt.Errorf("invalid delta of metric %q: %v", name, err)
+	}
+	check.count, err = parseOptionalNumber(config, "count")
+	if err != nil {
+		return nil, fmt.Errorf("invalid count of metric %q: %v", name, err)
+	}
+	check.countDelta, err = parseOptionalNumber(config, "count_delta")
+	if err != nil {
+		return nil, fmt.Errorf("invalid count_delta of metric %q: %v", name, err)
+	}
+	expectationsCount := 0
+	for _, expected := range []*float64{check.value, check.delta, check.count, check.countDelta} {
+		if expected != nil {
+			expectationsCount++
+		}
+	}
+	if expectationsCount != 1 {
+		return nil, fmt.Errorf("exactly one of value, delta, count or count_delta should be defined for metric %q", name)
+	}
+	return &check, nil
+}
+
+func parseOptionalNumber(config map[string]interface{}, key string) (*float64, error) {
+	var res float64
+	switch number := config[key].(type) {
+	case nil:
+		return nil, nil
+	case int:
+		res = float64(number)
+	case float64:
+		res = number
+	default:
+		return nil, fmt.Errorf("number expected, but it is %T (%#v)", number, number)
+	}
+	return &res, nil
+}
+
+func (c MetricCheck) isDelta() bool {
+	return c.delta != nil || c.countDelta != nil
+}
+
+func (c MetricCheck) Check(before map[string]*MetricFamily, after map[string]*MetricFamily, variables map[string]interface{}) error {
+	labels := make(map[string]string, len(c.labels))
+	for labelName, labelValue := range c.labels {
+		interpolatedValue, err := helper.ApplyInterpolation(labelValue, variables)
+		if err != nil {
+			return fmt.Errorf("unable to interpolate %q: %v", labelValue, err)
+		}
+		labels[labelName] = interpolatedValue
+	}
+	sampleName := c.name
+	if c.count != nil || c.countDelta != nil {
+		sampleName += "_count"
+	}
+	description := sampleName + formatLabels(labels)
+
+	actual, found := sumSamples(after, sampleName, labels)
+	switch {
+	case c.value != nil:
+		if !found {
+			return fmt.Errorf("metric %s not found", description)
+		}
+		return checkValue(description, "value", actual, *c.value)
+	case c.count != nil:
+		if !found {
+			return fmt.Errorf("metric %s not found", description)
+		}
+		return checkValue(description, "count", actual, *c.count)
+	}
+	// samples not exposed yet are treated like zero, because counters with labels usually appear after first increment
+	previous, _ := sumSamples(before, sampleName, labels)
+	if c.delta != nil {
+		return checkValue(description, "delta", actual-previous, *c.delta)
+	}
+	return checkValue(description, "count delta", actual-previous, *c.countDelta)
+}
+
+func checkValue(description string, kind string, actual float64, expected float64) error {
+	if math.Abs(actual-expected) > epsilon {
+		return fmt.Errorf("invalid %s of metric %s: expected %v, actual %v", kind, description, expected, actual)
+	}
+	return nil
+}
+
+// sumSamples returns sum of values of samples with specified name containing specified labels
+func sumSamples(families map[string]*MetricFamily, sampleName string, labels map[string]string) (sum float64, found bool) {
+	for _, family := range families {
+		for _, sample := range family.Samples {
+			if sample.Name != sampleName || !hasLabels(sample, labels) {
+				continue
+			}
+			sum += sample.Value
+			found = true
+		}
+	}
+	return
+}
+
+func hasLabels(sample Sample, labels map[string]string) bool {
+	for labelName, labelValue := range labels {
+		if sample.Labels[labelName] != labelValue {
+			return false
+		}
+	}
+	return true
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var res []string
+	for labelName, labelValue := range labels {
+		res = append(res, fmt.Sprintf("%s=%q", labelName, labelValue))
+	}
+	sort.Strings(res)
+	return "{" + strings.Join(res, ",") + "}"
+}
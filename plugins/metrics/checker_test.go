@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"integration_framework/plugins"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeExporter exposes counter of requests by user and histogram of their durations
+type fakeExporter struct {
+	mutex     sync.Mutex
+	requests  map[string]int
+	durations []float64
+}
+
+func (e *fakeExporter) request(user string, duration float64) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.requests[user]++
+	e.durations = append(e.durations, duration)
+}
+
+func (e *fakeExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	fmt.Fprintln(w, "# TYPE app_requests_total counter")
+	for user, count := range e.requests {
+		fmt.Fprintf(w, "app_requests_total{user=%q} %d\n", user, count)
+	}
+	fmt.Fprintln(w, "# TYPE app_request_duration_seconds histogram")
+	sum := 0.0
+	fast := 0
+	for _, duration := range e.durations {
+		sum += duration
+		if duration <= 0.1 {
+			fast++
+		}
+	}
+	fmt.Fprintf(w, "app_request_duration_seconds_bucket{le=\"0.1\"} %d\n", fast)
+	fmt.Fprintf(w, "app_request_duration_seconds_bucket{le=\"+Inf\"} %d\n", len(e.durations))
+	fmt.Fprintf(w, "app_request_duration_seconds_sum %v\n", sum)
+	fmt.Fprintf(w, "app_request_duration_seconds_count %d\n", len(e.durations))
+}
+
+func newTestChecker(t *testing.T, url string, config string) *Checker {
+	var param interface{}
+	err := yaml.Unmarshal([]byte(config), &param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checker, err := NewService("metrics", url).Checker(param)
+	if err != nil {
+		t.Fatalf("unable to create checker: %v", err)
+	}
+	return checker.(*Checker)
+}
+
+func TestCheckerDeltas(t *testing.T) {
+	exporter := &fakeExporter{requests: map[string]int{"admin": 5}}
+	server := httptest.NewServer(exporter)
+	defer server.Close()
+	variables := map[string]interface{}{
+		"params": map[string]interface{}{"user": "alice"},
+	}
+	noopSaver := plugins.FnResultSaver(func(key string, value interface{}) {})
+
+	tests := []struct {
+		name     string
+		checks   string
+		requests func()
+		err      string
+	}{
+		{
+			name: "delta of counter with interpolated labels",
+			checks: `
+- {name: app_requests_total, labels: {user: "{{ .params.user }}"}, delta: 2}
+- {name: app_requests_total, labels: {user: admin}, delta: 0}
+- {name: app_request_duration_seconds, count_delta: 2}`,
+			requests: func() {
+				exporter.request("alice", 0.05)
+				exporter.request("alice", 0.5)
+			},
+		},
+		{
+			name:   "delta of sum of samples",
+			checks: `[{name: app_requests_total, delta: 3}]`,
+			requests: func() {
+				exporter.request("alice", 0.05)
+				exporter.request("admin", 0.05)
+				exporter.request("bob", 0.05)
+			},
+		},
+		{
+			name:   "delta of bucket",
+			checks: `[{name: app_request_duration_seconds_bucket, labels: {le: "0.1"}, delta: 1}]`,
+			requests: func() {
+				exporter.request("alice", 0.05)
+				exporter.request("alice", 0.5)
+			},
+		},
+		{
+			name:     "unexpected delta",
+			checks:   `[{name: app_requests_total, labels: {user: admin}, delta: 1}]`,
+			requests: func() {},
+			err:      `invalid delta of metric app_requests_total{user="admin"}: expected 1, actual 0`,
+		},
+		{
+			name:   "value after request",
+			checks: `[{name: app_requests_total, labels: {user: admin}, value: 6}]`,
+			requests: func() {
+				exporter.request("admin", 0.05)
+			},
+		},
+		{
+			name:     "metric not found",
+			checks:   `[{name: app_errors_total, value: 0}]`,
+			requests: func() {},
+			err:      "metric app_errors_total not found",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exporter.mutex.Lock()
+			exporter.requests = map[string]int{"admin": 5}
+			exporter.durations = nil
+			exporter.mutex.Unlock()
+			checker := newTestChecker(t, server.URL, test.checks)
+
+			err := checker.BeforeRequest(variables)
+			if err != nil {
+				t.Fatalf("unable to scrape metrics before request: %v", err)
+			}
+			test.requests()
+			err = checker.CheckService(noopSaver, variables)
+			if test.err == "" && err != nil {
+				t.Errorf("expected check to pass, got %v", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckerDeltaRequiresScrapeBeforeRequest(t *testing.T) {
+	exporter := &fakeExporter{requests: map[string]int{}}
+	server := httptest.NewServer(exporter)
+	defer server.Close()
+	checker := newTestChecker(t, server.URL, `[{name: app_requests_total, delta: 1}]`)
+	noopSaver := plugins.FnResultSaver(func(key string, value interface{}) {})
+
+	err := checker.BeforeRequest(nil)
+	if err != nil {
+		t.Fatalf("unable to scrape metrics before request: %v", err)
+	}
+	exporter.request("alice", 0.05)
+	err = checker.CheckService(noopSaver, nil)
+	if err != nil {
+		t.Fatalf("expected check to pass, got %v", err)
+	}
+
+	// metrics scraped before previous run are not reused
+	exporter.request("alice", 0.05)
+	err = checker.CheckService(noopSaver, nil)
+	if err == nil || !strings.Contains(err.Error(), "not scraped before request") {
+		t.Errorf("expected error for delta without scrape before request, got %v", err)
+	}
+}
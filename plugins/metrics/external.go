@@ -0,0 +1,17 @@
+package metrics
+
+import (
+	"context"
+	"integration_framework/plugins/external"
+)
+
+// UseExternalEndpoint uses url of metrics of already running application (like `http://host:port/metrics`) as endpoint.
+// It replaces url from config, because running application often exposes metrics on other host or port than its api
+func (s *Service) UseExternalEndpoint(endpoint *external.Endpoint) error {
+	s.externalEndpoint = endpoint
+	return nil
+}
+
+func (s *Service) CheckReachable(ctx context.Context) error {
+	return external.CheckUrlReachable(ctx, s.externalEndpoint.Address)
+}
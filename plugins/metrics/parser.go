@@ -0,0 +1,185 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Sample is one line of Prometheus text format like `http_requests_total{method="post"} 1027`
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// MetricFamily is group of samples described by `# TYPE` line. Histograms and summaries contain samples `_bucket`, `_sum` and `_count` too
+type MetricFamily struct {
+	Name    string
+	Type    string
+	Help    string
+	Samples []Sample
+}
+
+// ParseMetrics parses Prometheus text exposition format (https://prometheus.io/docs/instrumenting/exposition_formats/)
+func ParseMetrics(r io.Reader) (map[string]*MetricFamily, error) {
+	families := make(map[string]*MetricFamily)
+	getFamily := func(name string) *MetricFamily {
+		family, ok := families[name]
+		if !ok {
+			family = &MetricFamily{
+				Name: name,
+				Type: "untyped",
+			}
+			families[name] = family
+		}
+		return family
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(strings.TrimSpace(strings.TrimPrefix(line, "#")), " ", 3)
+			if len(fields) < 2 {
+				continue
+			}
+			switch fields[0] {
+			case "TYPE":
+				if len(fields) != 3 {
+					return nil, fmt.Errorf("line %d: type of metric %q not defined", lineNumber, fields[1])
+				}
+				getFamily(fields[1]).Type = fields[2]
+			case "HELP":
+				if len(fields) == 3 {
+					getFamily(fields[1]).Help = fields[2]
+				}
+			}
+			continue
+		}
+		sample, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		family := getFamily(familyName(families, sample.Name))
+		family.Samples = append(family.Samples, *sample)
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read metrics: %v", err)
+	}
+	return families, nil
+}
+
+// familyName returns name of family of sample. Samples of histograms and summaries have suffixes
+func familyName(families map[string]*MetricFamily, sampleName string) string {
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		if !strings.HasSuffix(sampleName, suffix) {
+			continue
+		}
+		family, ok := families[strings.TrimSuffix(sampleName, suffix)]
+		if ok && (family.Type == "histogram" || family.Type == "summary") {
+			return family.Name
+		}
+	}
+	return sampleName
+}
+
+func parseSample(line string) (*Sample, error) {
+	sample := Sample{
+		Labels: make(map[string]string),
+	}
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd == -1 {
+		return nil, fmt.Errorf("value of sample not defined")
+	}
+	sample.Name = line[:nameEnd]
+	if sample.Name == "" {
+		return nil, fmt.Errorf("name of sample not defined")
+	}
+	rest := line[nameEnd:]
+	if rest[0] == '{' {
+		var err error
+		rest, err = parseLabels(rest[1:], sample.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse labels of %q: %v", sample.Name, err)
+		}
+	}
+	// value can be followed by timestamp
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return nil, fmt.Errorf("invalid value of %q: %q", sample.Name, rest)
+	}
+	// ParseFloat accepts special values `+Inf`, `-Inf` and `NaN`
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value of %q: %v", sample.Name, err)
+	}
+	sample.Value = value
+	return &sample, nil
+}
+
+// parseLabels parses labels like `a="1",b="2"}` and returns rest of line after closing brace
+func parseLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return "", fmt.Errorf("closing brace not found")
+		}
+		if s[0] == '}' {
+			return s[1:], nil
+		}
+		equalIndex := strings.IndexByte(s, '=')
+		if equalIndex == -1 {
+			return "", fmt.Errorf("value of label not found")
+		}
+		name := strings.TrimSpace(s[:equalIndex])
+		s = strings.TrimLeft(s[equalIndex+1:], " \t")
+		if s == "" || s[0] != '"' {
+			return "", fmt.Errorf("value of label %q should be quoted", name)
+		}
+		value, rest, err := parseLabelValue(s[1:])
+		if err != nil {
+			return "", fmt.Errorf("invalid value of label %q: %v", name, err)
+		}
+		labels[name] = value
+		s = strings.TrimLeft(rest, " \t")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		}
+	}
+}
+
+// parseLabelValue parses escaped value until closing quote and returns rest of line after quote
+func parseLabelValue(s string) (string, string, error) {
+	var value strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return value.String(), s[i+1:], nil
+		case '\\':
+			i++
+			if i == len(s) {
+				return "", "", fmt.Errorf("unfinished escape sequence")
+			}
+			switch s[i] {
+			case 'n':
+				value.WriteByte('\n')
+			case '\\', '"':
+				value.WriteByte(s[i])
+			default:
+				return "", "", fmt.Errorf("invalid escape sequence \\%c", s[i])
+			}
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return "", "", fmt.Errorf("closing quote not found")
+}
@@ -0,0 +1,205 @@
+package metrics
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSample(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected Sample
+	}{
+		{
+			name:     "without labels",
+			line:     "process_open_fds 12",
+			expected: Sample{Name: "process_open_fds", Labels: map[string]string{}, Value: 12},
+		},
+		{
+			name:     "with timestamp",
+			line:     "process_open_fds 12 1395066363000",
+			expected: Sample{Name: "process_open_fds", Labels: map[string]string{}, Value: 12},
+		},
+		{
+			name:     "labels",
+			line:     `http_requests_total{method="post",code="200"} 1027`,
+			expected: Sample{Name: "http_requests_total", Labels: map[string]string{"method": "post", "code": "200"}, Value: 1027},
+		},
+		{
+			name:     "labels with spaces and trailing comma",
+			line:     `http_requests_total{ method = "post" , code="200", } 3`,
+			expected: Sample{Name: "http_requests_total", Labels: map[string]string{"method": "post", "code": "200"}, Value: 3},
+		},
+		{
+			name:     "empty labels",
+			line:     `up{} 1`,
+			expected: Sample{Name: "up", Labels: map[string]string{}, Value: 1},
+		},
+		{
+			name:     "escapes in label value",
+			line:     `msdos_file_access_time_seconds{path="C:\\DIR\\FILE.TXT",error="Cannot find file:\n\"FILE.TXT\""} 1.458255915e9`,
+			expected: Sample{Name: "msdos_file_access_time_seconds", Labels: map[string]string{"path": `C:\DIR\FILE.TXT`, "error": "Cannot find file:\n\"FILE.TXT\""}, Value: 1.458255915e9},
+		},
+		{
+			name:     "braces and commas in label value",
+			line:     `route_calls{route="/users/{id},list"} 2`,
+			expected: Sample{Name: "route_calls", Labels: map[string]string{"route": "/users/{id},list"}, Value: 2},
+		},
+		{
+			name:     "infinity",
+			line:     `request_duration_seconds_bucket{le="+Inf"} +Inf`,
+			expected: Sample{Name: "request_duration_seconds_bucket", Labels: map[string]string{"le": "+Inf"}, Value: math.Inf(1)},
+		},
+		{
+			name:     "negative value",
+			line:     `temperature -3.5e-1`,
+			expected: Sample{Name: "temperature", Labels: map[string]string{}, Value: -0.35},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sample, err := parseSample(test.line)
+			if err != nil {
+				t.Fatalf("unable to parse %q: %v", test.line, err)
+			}
+			if !reflect.DeepEqual(*sample, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, *sample)
+			}
+		})
+	}
+}
+
+func TestParseSampleNaN(t *testing.T) {
+	sample, err := parseSample("ratio NaN")
+	if err != nil {
+		t.Fatalf("unable to parse NaN: %v", err)
+	}
+	if !math.IsNaN(sample.Value) {
+		t.Errorf("expected NaN, got %v", sample.Value)
+	}
+}
+
+func TestParseSampleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"no value", "up"},
+		{"no value after labels", `up{job="api"}`},
+		{"invalid value", "up one"},
+		{"too many fields", "up 1 2 3"},
+		{"unclosed braces", `up{job="api" 1`},
+		{"unquoted label value", `up{job=api} 1`},
+		{"label without value", `up{job} 1`},
+		{"unclosed quote", `up{job="api} 1`},
+		{"invalid escape", `up{job="a\tb"} 1`},
+		{"unfinished escape", `up{job="a\`},
+		{"no name", `{job="api"} 1`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sample, err := parseSample(test.line)
+			if err == nil {
+				t.Errorf("expected error for %q, got %#v", test.line, sample)
+			}
+		})
+	}
+}
+
+const exposition = `
+# HELP http_requests_total Total count of requests.
+# TYPE http_requests_total counter
+http_requests_total{method="get",code="200"} 10
+http_requests_total{method="post",code="200"} 3
+
+# HELP request_duration_seconds Duration of requests.
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 2
+request_duration_seconds_bucket{le="1"} 4
+request_duration_seconds_bucket{le="+Inf"} 5
+request_duration_seconds_sum 3.2
+request_duration_seconds_count 5
+
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.05
+rpc_duration_seconds{quantile="0.99"} 0.2
+rpc_duration_seconds_sum 1.5
+rpc_duration_seconds_count 20
+
+# comment which is not type or help
+jobs_count 7
+`
+
+func TestParseMetrics(t *testing.T) {
+	families, err := ParseMetrics(strings.NewReader(exposition))
+	if err != nil {
+		t.Fatalf("unable to parse metrics: %v", err)
+	}
+	tests := []struct {
+		family  string
+		typ     string
+		help    string
+		samples []string
+	}{
+		{"http_requests_total", "counter", "Total count of requests.", []string{"http_requests_total", "http_requests_total"}},
+		{"request_duration_seconds", "histogram", "Duration of requests.", []string{
+			"request_duration_seconds_bucket", "request_duration_seconds_bucket", "request_duration_seconds_bucket",
+			"request_duration_seconds_sum", "request_duration_seconds_count",
+		}},
+		{"rpc_duration_seconds", "summary", "", []string{
+			"rpc_duration_seconds", "rpc_duration_seconds", "rpc_duration_seconds_sum", "rpc_duration_seconds_count",
+		}},
+		// suffix `_count` belongs to family only for histograms and summaries
+		{"jobs_count", "untyped", "", []string{"jobs_count"}},
+	}
+	if len(families) != len(tests) {
+		t.Errorf("expected %d families, got %d: %v", len(tests), len(families), families)
+	}
+	for _, test := range tests {
+		family, ok := families[test.family]
+		if !ok {
+			t.Errorf("family %q not found", test.family)
+			continue
+		}
+		if family.Type != test.typ || family.Help != test.help {
+			t.Errorf("expected family %q of type %q with help %q, got %#v", test.family, test.typ, test.help, family)
+		}
+		var samples []string
+		for _, sample := range family.Samples {
+			samples = append(samples, sample.Name)
+		}
+		if !reflect.DeepEqual(samples, test.samples) {
+			t.Errorf("expected samples %v of family %q, got %v", test.samples, test.family, samples)
+		}
+	}
+
+	sum, found := sumSamples(families, "http_requests_total", map[string]string{"code": "200"})
+	if !found || sum != 13 {
+		t.Errorf("expected sum 13 of http_requests_total, got %v (found %v)", sum, found)
+	}
+	sum, found = sumSamples(families, "request_duration_seconds_count", nil)
+	if !found || sum != 5 {
+		t.Errorf("expected count 5 of request_duration_seconds, got %v (found %v)", sum, found)
+	}
+}
+
+func TestParseMetricsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		metrics string
+	}{
+		{"type without name of type", "# TYPE http_requests_total\n"},
+		{"invalid sample", "# TYPE up gauge\nup{job=\"api\" 1\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseMetrics(strings.NewReader(test.metrics))
+			if err == nil {
+				t.Errorf("expected error for %q", test.metrics)
+			}
+		})
+	}
+}
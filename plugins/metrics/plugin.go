@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	"fmt"
+	"integration_framework/application_config"
+	"integration_framework/plugins"
+)
+
+const defaultUrl = "{{ .application.url }}/metrics"
+
+func init() {
+	plugins.DefineService("metrics", func(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
+		url := defaultUrl
+		if params["url"] != nil {
+			var ok bool
+			url, ok = params["url"].(string)
+			if !ok {
+				return nil, fmt.Errorf("url should be string, but it is %T (%#v)", params["url"], params["url"])
+			}
+		}
+		return NewService(name, url), nil
+	})
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"fmt"
+	"integration_framework/helper"
+	"integration_framework/plugins"
+	"integration_framework/plugins/external"
+	"net/http"
+	"time"
+)
+
+const scrapeTimeout = 10 * time.Second
+
+// Service scrapes metrics in Prometheus text format exposed by application. It launches nothing
+type Service struct {
+	name string
+	// url of metrics endpoint, it is interpolated so it can refer to url of application
+	url string
+	// url of metrics of already running application, it is used instead of url
+	externalEndpoint *external.Endpoint
+}
+
+func NewService(name string, url string) *Service {
+	return &Service{
+		name: name,
+		url:  url,
+	}
+}
+
+func (s *Service) Start() error {
+	return nil
+}
+
+func (s *Service) ReadinessProbe() plugins.IProbe {
+	return nil
+}
+
+func (s *Service) Preparer(param interface{}) (plugins.IServicePreparer, error) {
+	return nil, fmt.Errorf("metrics service can not be prepared")
+}
+
+func (s *Service) scrape(variables map[string]interface{}) (map[string]*MetricFamily, error) {
+	var url string
+	if s.externalEndpoint != nil {
+		url = s.externalEndpoint.Address
+	} else {
+		var err error
+		url, err = helper.ApplyInterpolation(s.url, variables)
+		if err != nil {
+			return nil, fmt.Errorf("unable to interpolate %q: %v", s.url, err)
+		}
+	}
+	client := http.Client{
+		Timeout: scrapeTimeout,
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unsuccessfull status code: %d", resp.StatusCode)
+	}
+	families, err := ParseMetrics(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse metrics from %q: %v", url, err)
+	}
+	return families, nil
+}
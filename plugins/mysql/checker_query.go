@@ -78,15 +78,16 @@ func (pc QueryChecker) Check(conn *sqlx.DB, saveResult plugins.FnResultSaver, va
 		fmt.Printf("..   expected: %#v\n", pc.expectedResult)
 		// separate variable to be able to convert to slice
 		expectedResult := pc.expectedResult
+		// single row can be defined as map, but snapshot should be compared with all rows
 		_, expectingSlice := expectedResult.([]interface{})
-		if !expectingSlice {
+		if !expectingSlice && !testing.IsSnapshotDefinition(expectedResult) {
 			expectedResult = []interface{}{expectedResult}
 		}
 		expectedResult, err = helper.ApplyInterpolationForObject(expectedResult, variables)
 		if err != nil {
 			return fmt.Errorf("unable to apply interpolation: %v", err)
 		}
-		expectedResult, err = testing.ApplyConverters(expectedResult)
+		expectedResult, err = testing.ApplyConverters(expectedResult, variables)
 		if err != nil {
 			return fmt.Errorf("unable to apply converters: %v", err)
 		}
@@ -6,9 +6,13 @@ import (
 	"integration_framework/plugins/docker_compose"
 )
 
-func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, configs *docker_compose.ServiceConfigs, err error) {
-	dockerComposeServiceName = "mysql_" + serviceName
+func (s *Service) GenerateDockerComposeConfig(allocator *docker_compose.Allocator, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, err error) {
+	dockerComposeServiceName = allocator.ServiceName("mysql", serviceName)
 	s.serviceUrl = fmt.Sprintf("root:root@tcp(%s)/%s", dockerComposeServiceName, dbName)
+	s.port, err = allocator.HostPort(dockerComposeServiceName, 3306)
+	if err != nil {
+		return "", nil, err
+	}
 	service := docker_compose.DockerComposeService{
 		Image:   "mysql:8.0",
 		Restart: "always",
@@ -19,6 +23,12 @@ func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName s
 		Ports: []string{
 			fmt.Sprintf("%d:3306", s.port),
 		},
+		Healthcheck: &docker_compose.DockerComposeHealthcheck{
+			Test:     []string{"CMD", "mysqladmin", "ping", "-h", "127.0.0.1", "-uroot", "-proot"},
+			Interval: "1s",
+			Timeout:  "5s",
+			Retries:  120,
+		},
 	}
 	applicationService.AddDependency(dockerComposeServiceName, "tcp", 3306)
 	if s.env.EnvStr != "" {
@@ -32,8 +42,8 @@ func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName s
 			"db":       dbName,
 		})
 		if err != nil {
-			return "", nil, nil, fmt.Errorf("unable to fill environment for mysql %q: %v", serviceName, err)
+			return "", nil, fmt.Errorf("unable to fill environment for mysql %q: %v", serviceName, err)
 		}
 	}
-	return dockerComposeServiceName, &service, nil, nil
+	return dockerComposeServiceName, &service, nil
 }
@@ -0,0 +1,32 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"integration_framework/plugins/external"
+)
+
+func (s *Service) UseExternalEndpoint(endpoint *external.Endpoint) error {
+	_, err := mysql.ParseDSN(endpoint.Address)
+	if err != nil {
+		return fmt.Errorf("unable to parse mysql DSN: %v", err)
+	}
+	s.externalEndpoint = endpoint
+	return nil
+}
+
+func (s *Service) ExternalEndpoint() *external.Endpoint {
+	return s.externalEndpoint
+}
+
+func (s *Service) CheckReachable(ctx context.Context) error {
+	config, err := mysql.ParseDSN(s.externalEndpoint.Address)
+	if err != nil {
+		return fmt.Errorf("unable to parse mysql DSN: %v", err)
+	}
+	if config.Net != "tcp" {
+		return fmt.Errorf("only tcp connections are supported, but DSN uses %q", config.Net)
+	}
+	return external.CheckTcpReachable(ctx, config.Addr)
+}
@@ -9,7 +9,7 @@ import (
 const dbName = "test"
 
 func init() {
-	plugins.DefineService("mysql", func(name string, port int, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
-		return NewService(name, port, env, params), nil
+	plugins.DefineService("mysql", func(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
+		return NewService(name, env, params), nil
 	})
 }
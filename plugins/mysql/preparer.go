@@ -8,7 +8,7 @@ import (
 )
 
 type IPrepare interface {
-	Prepare(conn *sqlx.DB) error
+	Prepare(conn *sqlx.DB, variables map[string]interface{}) error
 }
 
 func (s *Service) Preparer(param interface{}) (plugins.IServicePreparer, error) {
@@ -50,12 +50,26 @@ type Preparer struct {
 	prepares []IPrepare
 }
 
-func (ppc Preparer) PrepareService() error {
+func (ppc Preparer) PrepareService(variables map[string]interface{}) error {
 	for i, prepare := range ppc.prepares {
-		err := prepare.Prepare(ppc.service.conn)
+		err := prepare.Prepare(ppc.service.conn, variables)
 		if err != nil {
 			return fmt.Errorf("unable to prepare mysql %d: %v", i, err)
 		}
 	}
 	return nil
 }
+
+// DestructivePrepares returns names of prepares refused for external service unless allowed
+func (ppc Preparer) DestructivePrepares() []string {
+	var names []string
+	for _, prepare := range ppc.prepares {
+		switch prepare.(type) {
+		case *ClearPrepare:
+			names = append(names, "clear")
+		case *ExecPrepare:
+			names = append(names, "exec")
+		}
+	}
+	return names
+}
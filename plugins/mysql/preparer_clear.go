@@ -12,7 +12,7 @@ func NewClearPrepare() *ClearPrepare {
 type ClearPrepare struct {
 }
 
-func (pp ClearPrepare) Prepare(conn *sqlx.DB) error {
+func (pp ClearPrepare) Prepare(conn *sqlx.DB, variables map[string]interface{}) error {
 	fmt.Println(".. mysql preparer clear")
 	var tableNames []string
 	err := conn.Select(&tableNames, "SHOW TABLES")
@@ -16,8 +16,8 @@ type ExecPrepare struct {
 	exec string
 }
 
-func (pp ExecPrepare) Prepare(conn *sqlx.DB) error {
-	query, err := helper.ApplyInterpolation(pp.exec, nil)
+func (pp ExecPrepare) Prepare(conn *sqlx.DB, variables map[string]interface{}) error {
+	query, err := helper.ApplyInterpolation(pp.exec, variables)
 	if err != nil {
 		return fmt.Errorf("unable to interpolate query: %v", err)
 	}
@@ -1,10 +1,11 @@
 package mysql
 
 import (
-	"context"
 	"fmt"
 	"github.com/jmoiron/sqlx"
 	"integration_framework/application_config"
+	"integration_framework/plugins"
+	"integration_framework/plugins/external"
 )
 
 type Service struct {
@@ -14,19 +15,20 @@ type Service struct {
 	port       int
 	serviceUrl string
 	conn       *sqlx.DB
+	// defined if service is not launched by framework
+	externalEndpoint *external.Endpoint
 }
 
-func NewService(name string, port int, env application_config.ServiceDefinitionEnv, params map[string]interface{}) *Service {
+func NewService(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) *Service {
 	return &Service{
 		name:   name,
 		env:    env,
-		port:   port,
 		params: params,
 	}
 }
 
 func (s *Service) Start() error {
-	conn, err := sqlx.Connect("mysql", fmt.Sprintf("root:root@tcp(localhost:%d)/%s", s.port, dbName))
+	conn, err := sqlx.Connect("mysql", s.Url())
 	if err != nil {
 		return fmt.Errorf("unable to connect to mysql: %v", err)
 	}
@@ -34,6 +36,13 @@ func (s *Service) Start() error {
 	return nil
 }
 
-func (s Service) WaitForPortAvailable(ctx context.Context) error {
-	return nil
+func (s *Service) Url() string {
+	if s.externalEndpoint != nil {
+		return s.externalEndpoint.Address
+	}
+	return fmt.Sprintf("root:root@tcp(localhost:%d)/%s", s.port, dbName)
+}
+
+func (s *Service) ReadinessProbe() plugins.IProbe {
+	return plugins.NewSqlProbe("mysql", s.Url())
 }
@@ -6,9 +6,13 @@ import (
 	"integration_framework/plugins/docker_compose"
 )
 
-func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, configs *docker_compose.ServiceConfigs, err error) {
-	dockerComposeServiceName = "postgres_" + serviceName
+func (s *Service) GenerateDockerComposeConfig(allocator *docker_compose.Allocator, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, err error) {
+	dockerComposeServiceName = allocator.ServiceName("postgres", serviceName)
 	s.serviceUrl = fmt.Sprintf("postgres://postgres:postgres@%s/%s?sslmode=disable", dockerComposeServiceName, dbName)
+	s.port, err = allocator.HostPort(dockerComposeServiceName, 5432)
+	if err != nil {
+		return "", nil, err
+	}
 	service := docker_compose.DockerComposeService{
 		Image:   "postgres:9.6",
 		Restart: "always",
@@ -19,6 +23,12 @@ func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName s
 			fmt.Sprintf("%d:5432", s.port),
 		},
 		TmpFs: []string{"/var/lib/postgresql/data"},
+		Healthcheck: &docker_compose.DockerComposeHealthcheck{
+			Test:     []string{"CMD-SHELL", "pg_isready -U postgres -d " + dbName},
+			Interval: "1s",
+			Timeout:  "5s",
+			Retries:  120,
+		},
 	}
 	applicationService.AddDependency(dockerComposeServiceName, "tcp", 5432)
 	if s.env.EnvStr != "" {
@@ -33,8 +43,8 @@ func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName s
 			"db":       dbName,
 		})
 		if err != nil {
-			return "", nil, nil, fmt.Errorf("unable to fill environment for postgres %q: %v", serviceName, err)
+			return "", nil, fmt.Errorf("unable to fill environment for postgres %q: %v", serviceName, err)
 		}
 	}
-	return dockerComposeServiceName, &service, nil, nil
+	return dockerComposeServiceName, &service, nil
 }
@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"context"
+	"integration_framework/plugins/external"
+)
+
+func (s *Service) UseExternalEndpoint(endpoint *external.Endpoint) error {
+	s.externalEndpoint = endpoint
+	return nil
+}
+
+func (s *Service) ExternalEndpoint() *external.Endpoint {
+	return s.externalEndpoint
+}
+
+func (s *Service) CheckReachable(ctx context.Context) error {
+	return external.CheckUrlReachable(ctx, s.externalEndpoint.Address)
+}
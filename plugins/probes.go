@@ -0,0 +1,94 @@
+package plugins
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"integration_framework/application_config"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ProbeFunc allows to use function as probe
+type ProbeFunc func(ctx context.Context) error
+
+func (f ProbeFunc) Probe(ctx context.Context) error {
+	return f(ctx)
+}
+
+// NewTcpProbe checks that tcp connection can be established
+func NewTcpProbe(address string) IProbe {
+	return ProbeFunc(func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+// NewHttpProbe checks that GET request to url returns response.
+// If requireSuccess is true then status of response should be 2xx
+func NewHttpProbe(url string, requireSuccess bool) IProbe {
+	return ProbeFunc(func(ctx context.Context) error {
+		request, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			return fmt.Errorf("unable to create request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(request.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if requireSuccess && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+			return fmt.Errorf("GET %s returned status %d", url, resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// NewSqlProbe checks that database accepts connections
+func NewSqlProbe(driverName string, dataSourceName string) IProbe {
+	return ProbeFunc(func(ctx context.Context) error {
+		db, err := sql.Open(driverName, dataSourceName)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		return db.PingContext(ctx)
+	})
+}
+
+// NewSmtpBannerProbe checks that smtp server sends greeting with code 220
+func NewSmtpBannerProbe(address string) IProbe {
+	return ProbeFunc(func(ctx context.Context) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		banner, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("unable to read smtp banner: %v", err)
+		}
+		if !strings.HasPrefix(banner, "220") {
+			return fmt.Errorf("unexpected smtp banner %q", strings.TrimSpace(banner))
+		}
+		return nil
+	})
+}
+
+// NewApplicationProbe checks application listening on address like `localhost:8080`
+func NewApplicationProbe(config *application_config.ApplicationConfig, address string) IProbe {
+	if config.HealthPath != "" {
+		return NewHttpProbe("http://"+address+config.HealthPath, true)
+	}
+	return NewHttpProbe("http://"+address+"/", false)
+}
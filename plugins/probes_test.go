@@ -0,0 +1,150 @@
+package plugins
+
+import (
+	"context"
+	"integration_framework/application_config"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func probeWithTimeout(probe IProbe) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return probe.Probe(ctx)
+}
+
+// closedAddress returns address which doesn't accept connections
+func closedAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+	return address
+}
+
+// newBannerServer accepts connections and sends banner to every one
+func newBannerServer(t *testing.T, banner string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(banner))
+			conn.Close()
+		}
+	}()
+	return listener
+}
+
+func TestTcpProbe(t *testing.T) {
+	listener := newBannerServer(t, "")
+	defer listener.Close()
+	err := probeWithTimeout(NewTcpProbe(listener.Addr().String()))
+	if err != nil {
+		t.Errorf("expected connection established, got %v", err)
+	}
+	err = probeWithTimeout(NewTcpProbe(closedAddress(t)))
+	if err == nil {
+		t.Errorf("expected error of closed port")
+	}
+}
+
+func TestHttpProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		url            string
+		requireSuccess bool
+		expectedError  string
+	}{
+		{name: "success", url: server.URL + "/health", requireSuccess: true},
+		{name: "any status", url: server.URL + "/", requireSuccess: false},
+		{name: "unavailable", url: server.URL + "/", requireSuccess: true, expectedError: "returned status 503"},
+		{name: "closed port", url: "http://" + closedAddress(t) + "/", expectedError: "connection refused"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := probeWithTimeout(NewHttpProbe(test.url, test.requireSuccess))
+			if test.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected success, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("expected error %q, got %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestSmtpBannerProbe(t *testing.T) {
+	tests := []struct {
+		banner        string
+		expectedError string
+	}{
+		{banner: "220 localhost ESMTP ready\r\n"},
+		{banner: "554 no service\r\n", expectedError: `unexpected smtp banner "554 no service"`},
+		{banner: "", expectedError: "unable to read smtp banner"},
+	}
+	for _, test := range tests {
+		t.Run(test.banner, func(t *testing.T) {
+			listener := newBannerServer(t, test.banner)
+			defer listener.Close()
+			err := probeWithTimeout(NewSmtpBannerProbe(listener.Addr().String()))
+			if test.expectedError == "" {
+				if err != nil {
+					t.Errorf("expected success, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("expected error %q, got %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestApplicationProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ready" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "http://")
+
+	// any response means that application listens if health path is not defined
+	err := probeWithTimeout(NewApplicationProbe(&application_config.ApplicationConfig{}, address))
+	if err != nil {
+		t.Errorf("expected application ready, got %v", err)
+	}
+	err = probeWithTimeout(NewApplicationProbe(&application_config.ApplicationConfig{HealthPath: "/ready"}, address))
+	if err != nil {
+		t.Errorf("expected application ready, got %v", err)
+	}
+	err = probeWithTimeout(NewApplicationProbe(&application_config.ApplicationConfig{HealthPath: "/health"}, address))
+	if err == nil || !strings.Contains(err.Error(), "returned status 404") {
+		t.Errorf("expected error of health path, got %v", err)
+	}
+}
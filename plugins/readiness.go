@@ -0,0 +1,137 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"integration_framework/application_config"
+	"sort"
+	"time"
+)
+
+const (
+	defaultReadinessTimeout     = 2 * time.Minute
+	defaultReadinessInterval    = 500 * time.Millisecond
+	defaultReadinessMaxInterval = 5 * time.Second
+	probeTimeout                = 10 * time.Second
+)
+
+// IProbe checks that service is ready to be used
+type IProbe interface {
+	Probe(ctx context.Context) error
+}
+
+// FatalProbeError is returned by probe if service will never become ready (for example its process exited), so waiting should be stopped
+type FatalProbeError struct {
+	Err error
+}
+
+func (e FatalProbeError) Error() string {
+	return e.Err.Error()
+}
+
+type ReadinessOptions struct {
+	Timeout     time.Duration
+	Interval    time.Duration
+	MaxInterval time.Duration
+}
+
+// NewReadinessOptions creates options from global readiness config overridden by readiness config of service. Both configs can be nil
+func NewReadinessOptions(globalConfig *application_config.ReadinessConfig, serviceConfig *application_config.ReadinessConfig) (ReadinessOptions, error) {
+	options := ReadinessOptions{
+		Timeout:     defaultReadinessTimeout,
+		Interval:    defaultReadinessInterval,
+		MaxInterval: defaultReadinessMaxInterval,
+	}
+	for _, config := range []*application_config.ReadinessConfig{globalConfig, serviceConfig} {
+		if config == nil {
+			continue
+		}
+		timeout, interval, maxInterval, err := config.Durations()
+		if err != nil {
+			return options, err
+		}
+		if timeout != 0 {
+			options.Timeout = timeout
+		}
+		if interval != 0 {
+			options.Interval = interval
+		}
+		if maxInterval != 0 {
+			options.MaxInterval = maxInterval
+		}
+	}
+	return options, nil
+}
+
+// WaitForReady calls probe until it succeeds. Interval between calls doubles every time until it reaches max interval
+func WaitForReady(ctx context.Context, name string, probe IProbe, options ReadinessOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+	interval := options.Interval
+	for {
+		probeCtx, cancelProbe := context.WithTimeout(ctx, probeTimeout)
+		err := probe.Probe(probeCtx)
+		cancelProbe()
+		if err == nil {
+			return nil
+		}
+		if fatalErr, ok := err.(FatalProbeError); ok {
+			return fmt.Errorf("%s will never become ready: %v", name, fatalErr.Err)
+		}
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return fmt.Errorf("%s not ready after %s: %v", name, options.Timeout, err)
+			}
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+		if interval > options.MaxInterval {
+			interval = options.MaxInterval
+		}
+	}
+}
+
+// WaitForServicesReady waits for every service having readiness probe
+func WaitForServicesReady(ctx context.Context, config *application_config.Config, services map[string]IService) error {
+	var servicesNames []string
+	for serviceName := range services {
+		servicesNames = append(servicesNames, serviceName)
+	}
+	sort.Strings(servicesNames)
+	for _, serviceName := range servicesNames {
+		probe := services[serviceName].ReadinessProbe()
+		if probe == nil {
+			continue
+		}
+		options, err := NewReadinessOptions(config.Readiness, config.Services[serviceName].Readiness)
+		if err != nil {
+			return fmt.Errorf("invalid readiness config of service %q: %v", serviceName, err)
+		}
+		err = WaitForReady(ctx, fmt.Sprintf("service %q", serviceName), probe, options)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitForApplicationReady waits for application listening on address like `localhost:8080`.
+// aliveProbe (can be nil) is called before every check and should return FatalProbeError if application stopped
+func WaitForApplicationReady(ctx context.Context, config *application_config.Config, applicationName string, address string, aliveProbe IProbe) error {
+	options, err := NewReadinessOptions(config.Readiness, nil)
+	if err != nil {
+		return fmt.Errorf("invalid readiness config: %v", err)
+	}
+	applicationProbe := NewApplicationProbe(config.Applications[applicationName], address)
+	return WaitForReady(ctx, fmt.Sprintf("application %q", applicationName), ProbeFunc(func(ctx context.Context) error {
+		if aliveProbe != nil {
+			err := aliveProbe.Probe(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return applicationProbe.Probe(ctx)
+	}), options)
+}
@@ -0,0 +1,147 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"integration_framework/application_config"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeProbe returns errors in turn and then succeeds. Times of calls are recorded
+type fakeProbe struct {
+	errors []error
+	calls  []time.Time
+}
+
+func (p *fakeProbe) Probe(ctx context.Context) error {
+	p.calls = append(p.calls, time.Now())
+	if _, ok := ctx.Deadline(); !ok {
+		return FatalProbeError{Err: errors.New("probe called without deadline")}
+	}
+	if len(p.calls) > len(p.errors) {
+		return nil
+	}
+	return p.errors[len(p.calls)-1]
+}
+
+func repeatError(err error, count int) []error {
+	res := make([]error, count)
+	for i := range res {
+		res[i] = err
+	}
+	return res
+}
+
+func TestNewReadinessOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		global        *application_config.ReadinessConfig
+		service       *application_config.ReadinessConfig
+		expected      ReadinessOptions
+		expectedError string
+	}{
+		{
+			name:     "defaults",
+			expected: ReadinessOptions{Timeout: defaultReadinessTimeout, Interval: defaultReadinessInterval, MaxInterval: defaultReadinessMaxInterval},
+		},
+		{
+			name:     "global",
+			global:   &application_config.ReadinessConfig{Timeout: "1m", Interval: "1s"},
+			expected: ReadinessOptions{Timeout: time.Minute, Interval: time.Second, MaxInterval: defaultReadinessMaxInterval},
+		},
+		{
+			name:     "service overrides global",
+			global:   &application_config.ReadinessConfig{Timeout: "1m", Interval: "1s"},
+			service:  &application_config.ReadinessConfig{Timeout: "10s", MaxInterval: "2s"},
+			expected: ReadinessOptions{Timeout: 10 * time.Second, Interval: time.Second, MaxInterval: 2 * time.Second},
+		},
+		{
+			name:          "invalid duration",
+			service:       &application_config.ReadinessConfig{Interval: "often"},
+			expectedError: "invalid interval",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options, err := NewReadinessOptions(test.global, test.service)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to create options: %v", err)
+			}
+			if options != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, options)
+			}
+		})
+	}
+}
+
+func TestWaitForReadyBackoff(t *testing.T) {
+	probe := &fakeProbe{errors: repeatError(errors.New("connection refused"), 5)}
+	options := ReadinessOptions{Timeout: 5 * time.Second, Interval: 20 * time.Millisecond, MaxInterval: 50 * time.Millisecond}
+	err := WaitForReady(context.Background(), "service", probe, options)
+	if err != nil {
+		t.Fatalf("expected service ready, got %v", err)
+	}
+	if len(probe.calls) != 6 {
+		t.Fatalf("expected probe called until success, got %d calls", len(probe.calls))
+	}
+	// interval doubles until max interval
+	expectedIntervals := []time.Duration{20, 40, 50, 50, 50}
+	for i, expected := range expectedIntervals {
+		expected *= time.Millisecond
+		interval := probe.calls[i+1].Sub(probe.calls[i])
+		if interval < expected || interval >= 2*expected {
+			t.Errorf("interval #%d: expected %v, got %v", i, expected, interval)
+		}
+	}
+}
+
+func TestWaitForReadyTimeout(t *testing.T) {
+	probe := &fakeProbe{errors: repeatError(errors.New("connection refused"), 1000)}
+	options := ReadinessOptions{Timeout: 100 * time.Millisecond, Interval: 10 * time.Millisecond, MaxInterval: 20 * time.Millisecond}
+	start := time.Now()
+	err := WaitForReady(context.Background(), `service "db"`, probe, options)
+	if err == nil || err.Error() != `service "db" not ready after 100ms: connection refused` {
+		t.Errorf("expected error of timeout with last error of probe, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected waiting for timeout, waited %v", elapsed)
+	}
+	if len(probe.calls) < 3 {
+		t.Errorf("expected probe called several times, got %d calls", len(probe.calls))
+	}
+}
+
+func TestWaitForReadyFatalError(t *testing.T) {
+	probe := &fakeProbe{errors: []error{
+		errors.New("connection refused"),
+		FatalProbeError{Err: errors.New("process exited with code 1")},
+		errors.New("connection refused"),
+	}}
+	options := ReadinessOptions{Timeout: 5 * time.Second, Interval: 10 * time.Millisecond, MaxInterval: 10 * time.Millisecond}
+	err := WaitForReady(context.Background(), `application "api"`, probe, options)
+	if err == nil || err.Error() != `application "api" will never become ready: process exited with code 1` {
+		t.Errorf("expected fatal error, got %v", err)
+	}
+	if len(probe.calls) != 2 {
+		t.Errorf("expected waiting stopped by fatal error, got %d calls", len(probe.calls))
+	}
+}
+
+func TestWaitForReadyCanceled(t *testing.T) {
+	probe := &fakeProbe{errors: repeatError(errors.New("connection refused"), 1000)}
+	options := ReadinessOptions{Timeout: 5 * time.Second, Interval: 10 * time.Millisecond, MaxInterval: 10 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	err := WaitForReady(ctx, "service", probe, options)
+	if err != context.Canceled {
+		t.Errorf("expected canceled waiting, got %v", err)
+	}
+}
@@ -6,7 +6,8 @@ import (
 )
 
 type IRequester interface {
-	MakeRequest() (responseBody []byte, statusCode int, err error)
+	// variables are used to interpolate url of request, like `{{ .application.url }}/graphql`
+	MakeRequest(variables map[string]interface{}) (responseBody []byte, statusCode int, err error)
 
 	// joins caller requester with provided requester returning new requester.
 	// caller requester should remain unchanged
@@ -1,7 +1,6 @@
 package plugins
 
 import (
-	"context"
 	"fmt"
 	"integration_framework/application_config"
 )
@@ -9,12 +8,26 @@ import (
 type IService interface {
 	Preparer(params interface{}) (IServicePreparer, error)
 	Checker(params interface{}) (IServiceChecker, error)
-	WaitForPortAvailable(ctx context.Context) error
+	// ReadinessProbe returns probe to check that service is ready or nil if service is always ready
+	ReadinessProbe() IProbe
 	Start() error
 }
 
+// IServiceWithUrl is implemented by services reachable from host.
+// Url is available in interpolation as `{{ .services.<name>.url }}`
+type IServiceWithUrl interface {
+	Url() string
+}
+
 type IServicePreparer interface {
-	PrepareService() error
+	PrepareService(variables map[string]interface{}) error
+}
+
+// IDestructivePreparer is implemented by preparers which can remove or change data of service, like `clear` or `exec` of databases.
+// DestructivePrepares returns names of such prepares, they are refused for external services unless allowed
+type IDestructivePreparer interface {
+	IServicePreparer
+	DestructivePrepares() []string
 }
 
 type FnResultSaver func(key string, value interface{})
@@ -23,7 +36,18 @@ type IServiceChecker interface {
 	CheckService(saveResult FnResultSaver, variables map[string]interface{}) error
 }
 
-type serviceConstructor func(name string, port int, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (IService, error)
+// IServiceCheckerBeforeRequest is implemented by checkers which should remember state of service before request of test, for example to check difference
+type IServiceCheckerBeforeRequest interface {
+	BeforeRequest(variables map[string]interface{}) error
+}
+
+// IServiceWithDefaultChecker is implemented by services which check every test even if test doesn't define checks of service.
+// DefaultChecker returns nil if service has nothing to check
+type IServiceWithDefaultChecker interface {
+	DefaultChecker() IServiceChecker
+}
+
+type serviceConstructor func(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (IService, error)
 
 var serviceConstructors map[string]serviceConstructor
 
@@ -39,12 +63,12 @@ func DefineService(serviceType string, constructor serviceConstructor) {
 	serviceConstructors[serviceType] = constructor
 }
 
-func NewService(serviceName string, serviceType string, port int, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (IService, error) {
+func NewService(serviceName string, serviceType string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (IService, error) {
 	constructor, ok := serviceConstructors[serviceType]
 	if !ok {
 		return nil, fmt.Errorf("service with type %q not defined", serviceType)
 	}
-	service, err := constructor(serviceName, port, env, params)
+	service, err := constructor(serviceName, env, params)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create service %q: %v", serviceName, err)
 	}
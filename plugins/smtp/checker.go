@@ -80,7 +80,7 @@ type Checker struct {
 
 func (pcc Checker) CheckService(saveResult plugins.FnResultSaver, variables map[string]interface{}) error {
 	for i, check := range pcc.checkers {
-		err := check.Check(fmt.Sprintf("http://localhost:%d/", pcc.service.port), variables)
+		err := check.Check(pcc.service.Url(), variables)
 		if err != nil {
 			return fmt.Errorf("unable to check smtp %d: %v", i, err)
 		}
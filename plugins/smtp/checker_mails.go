@@ -3,6 +3,7 @@ package smtp
 import (
 	"encoding/json"
 	"fmt"
+	"integration_framework/helper"
 	"io/ioutil"
 	"net/http"
 )
@@ -13,6 +14,30 @@ type MailCheck struct {
 	content *string
 }
 
+func (mc MailCheck) interpolate(variables map[string]interface{}) (res MailCheck, err error) {
+	res.from, err = interpolateOptional(mc.from, variables)
+	if err != nil {
+		return
+	}
+	res.subject, err = interpolateOptional(mc.subject, variables)
+	if err != nil {
+		return
+	}
+	res.content, err = interpolateOptional(mc.content, variables)
+	return
+}
+
+func interpolateOptional(str *string, variables map[string]interface{}) (*string, error) {
+	if str == nil {
+		return nil, nil
+	}
+	interpolated, err := helper.ApplyInterpolation(*str, variables)
+	if err != nil {
+		return nil, fmt.Errorf("unable to interpolate %q: %v", *str, err)
+	}
+	return &interpolated, nil
+}
+
 type ActualMail struct {
 	From    string `json:"from"`
 	To      string `json:"to"`
@@ -47,16 +72,24 @@ func (mc MailsChecker) Check(httpServiceUrl string, variables map[string]interfa
 	if err != nil {
 		return fmt.Errorf("unable to unmarshal response body: %v", err)
 	}
+	mailbox, err := helper.ApplyInterpolation(mc.mailbox, variables)
+	if err != nil {
+		return fmt.Errorf("unable to interpolate mailbox %q: %v", mc.mailbox, err)
+	}
 	var actualMailsForThisMailbox []ActualMail
 	for _, actualMail := range actualMails {
-		if actualMail.To == mc.mailbox {
+		if actualMail.To == mailbox {
 			actualMailsForThisMailbox = append(actualMailsForThisMailbox, actualMail)
 		}
 	}
 	if len(actualMailsForThisMailbox) != len(mc.mails) {
 		return fmt.Errorf("different mails count: actual is %d, expected is %d", len(actualMailsForThisMailbox), len(mc.mails))
 	}
-	for i, mailCheck := range mc.mails {
+	for i, mailCheckTemplate := range mc.mails {
+		mailCheck, err := mailCheckTemplate.interpolate(variables)
+		if err != nil {
+			return fmt.Errorf("check #%d failed: unable to apply interpolation: %v", i, err)
+		}
 		actualMail := actualMailsForThisMailbox[i]
 		if mailCheck.from != nil {
 			if *mailCheck.from != actualMail.From {
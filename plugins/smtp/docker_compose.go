@@ -6,30 +6,52 @@ import (
 	"integration_framework/plugins/docker_compose"
 )
 
-func (s *Service) GenerateDockerComposeConfig(tmpDirectory string, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, configs *docker_compose.ServiceConfigs, err error) {
-	dockerComposeServiceName = "smtp_" + serviceName
+// defaultImage is built by `make smtp_mock_image`
+const defaultImage = "integration_framework/smtp_mock"
+
+func (s *Service) GenerateDockerComposeConfig(allocator *docker_compose.Allocator, serviceName string, applicationService *docker_compose.DockerComposeService) (dockerComposeServiceName string, dockerComposeService *docker_compose.DockerComposeService, err error) {
+	dockerComposeServiceName = allocator.ServiceName("smtp", serviceName)
+	s.port, err = allocator.HostPort(dockerComposeServiceName, 8080)
+	if err != nil {
+		return "", nil, err
+	}
+	s.smtpPort, err = allocator.HostPort(dockerComposeServiceName, 25)
+	if err != nil {
+		return "", nil, err
+	}
+	image, ok := s.params["image"].(string)
+	if !ok {
+		image = defaultImage
+	}
+	username, password := s.credentials()
 	service := docker_compose.DockerComposeService{
-		Build: docker_compose.DockerComposeServiceBuild{
-			Context: "/home/aliksend/Documents/simple_smtp", // TODO use image
-		},
+		Image:   image,
 		Restart: "on-failure",
 		Environment: map[string]string{
-			"HTTP_PORT": "8080",
-			"SMTP_PORT": "25",
+			"HTTP_PORT":     "8080",
+			"SMTP_PORT":     "25",
+			"SMTP_USERNAME": username,
+			"SMTP_PASSWORD": password,
 		},
 		Ports: []string{
-			fmt.Sprintf("%d:8080", s.port), // expose only http port
+			fmt.Sprintf("%d:8080", s.port),
+			fmt.Sprintf("%d:25", s.smtpPort),
 		},
 	}
+	if !ok {
+		service.SetLocalImage("make smtp_mock_image")
+	}
 	applicationService.AddDependency(dockerComposeServiceName, "tcp", 8080)
 	if s.env.EnvMap != nil {
 		err := helper.FillEnvironment(&applicationService.Environment, s.env.EnvMap, map[string]string{
-			"host": dockerComposeServiceName,
-			"port": "25",
+			"host":     dockerComposeServiceName,
+			"port":     "25",
+			"username": username,
+			"password": password,
 		})
 		if err != nil {
-			return "", nil, nil, fmt.Errorf("unable to fill environment for smtp %q: %v", serviceName, err)
+			return "", nil, fmt.Errorf("unable to fill environment for smtp %q: %v", serviceName, err)
 		}
 	}
-	return dockerComposeServiceName, &service, nil, nil
+	return dockerComposeServiceName, &service, nil
 }
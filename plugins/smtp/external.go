@@ -0,0 +1,24 @@
+package smtp
+
+import (
+	"context"
+	"integration_framework/plugins/external"
+	"strings"
+)
+
+// UseExternalEndpoint uses url of http api of smtp server (like `http://host:port/`) as endpoint
+func (s *Service) UseExternalEndpoint(endpoint *external.Endpoint) error {
+	if !strings.HasSuffix(endpoint.Address, "/") {
+		endpoint.Address += "/"
+	}
+	s.externalEndpoint = endpoint
+	return nil
+}
+
+func (s *Service) ExternalEndpoint() *external.Endpoint {
+	return s.externalEndpoint
+}
+
+func (s *Service) CheckReachable(ctx context.Context) error {
+	return external.CheckUrlReachable(ctx, s.externalEndpoint.Address)
+}
@@ -0,0 +1,65 @@
+package smtp
+
+import (
+	"context"
+	"fmt"
+	"github.com/emersion/go-smtp"
+	"integration_framework/smtp_mock"
+	"net"
+	"net/http"
+	"time"
+)
+
+const stopTimeout = 10 * time.Second
+
+// StartInProcess starts smtp server and its http api inside of framework process on ports allocated for service. It is used by local launcher instead of container
+func (s *Service) StartInProcess() error {
+	server := smtp_mock.NewServer(s.credentials())
+	smtpListener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.smtpPort))
+	if err != nil {
+		return fmt.Errorf("unable to listen port %d: %v", s.smtpPort, err)
+	}
+	httpListener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		smtpListener.Close()
+		return fmt.Errorf("unable to listen port %d: %v", s.port, err)
+	}
+	// smtp server is stopped by closing of listener because Close of smtp.Server can't be called before Serve
+	s.smtpListener = smtpListener
+	s.stopped = make(chan struct{})
+	s.httpServer = &http.Server{
+		Handler: server,
+	}
+	go func(smtpServer *smtp.Server, stopped chan struct{}) {
+		err := smtpServer.Serve(smtpListener)
+		select {
+		case <-stopped:
+		default:
+			fmt.Printf("smtp server %q stopped: %v\n", s.name, err)
+		}
+	}(server.SmtpServer(smtpListener.Addr().String()), s.stopped)
+	go func(server *http.Server) {
+		err := server.Serve(httpListener)
+		if err != nil && err != http.ErrServerClosed {
+			fmt.Printf("http api of smtp %q stopped: %v\n", s.name, err)
+		}
+	}(s.httpServer)
+	return nil
+}
+
+func (s *Service) StopInProcess() error {
+	if s.httpServer == nil {
+		return nil
+	}
+	close(s.stopped)
+	s.smtpListener.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+	err := s.httpServer.Shutdown(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to stop http api of smtp %q: %v", s.name, err)
+	}
+	s.smtpListener = nil
+	s.httpServer = nil
+	return nil
+}
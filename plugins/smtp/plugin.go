@@ -8,7 +8,7 @@ import (
 const dbName = "test"
 
 func init() {
-	plugins.DefineService("smtp", func(name string, port int, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
-		return NewService(name, port, env)
+	plugins.DefineService("smtp", func(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (plugins.IService, error) {
+		return NewService(name, env, params)
 	})
 }
@@ -7,7 +7,7 @@ import (
 )
 
 type IPrepare interface {
-	Prepare(httpServiceUrl string) error
+	Prepare(httpServiceUrl string, variables map[string]interface{}) error
 }
 
 func (s *Service) Preparer(param interface{}) (plugins.IServicePreparer, error) {
@@ -36,12 +36,23 @@ type Preparer struct {
 	prepares []IPrepare
 }
 
-func (ppc Preparer) PrepareService() error {
+func (ppc Preparer) PrepareService(variables map[string]interface{}) error {
 	for i, prepare := range ppc.prepares {
-		err := prepare.Prepare(fmt.Sprintf("http://localhost:%d/", ppc.service.port))
+		err := prepare.Prepare(ppc.service.Url(), variables)
 		if err != nil {
 			return fmt.Errorf("unable to prepare smtp %d: %v", i, err)
 		}
 	}
 	return nil
 }
+
+// DestructivePrepares returns names of prepares refused for external service unless allowed
+func (ppc Preparer) DestructivePrepares() []string {
+	var names []string
+	for _, prepare := range ppc.prepares {
+		if _, ok := prepare.(*ClearAllPreparer); ok {
+			names = append(names, "clear")
+		}
+	}
+	return names
+}
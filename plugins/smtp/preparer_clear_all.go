@@ -12,7 +12,7 @@ func NewClearAllPreparer() *ClearAllPreparer {
 type ClearAllPreparer struct {
 }
 
-func (pp ClearAllPreparer) Prepare(httpServiceUrl string) error {
+func (pp ClearAllPreparer) Prepare(httpServiceUrl string, variables map[string]interface{}) error {
 	fmt.Println(".. smtp preparer clear")
 	resp, err := http.Post(httpServiceUrl+"__reset_mails", "application/json", nil)
 	if err != nil {
@@ -2,20 +2,43 @@ package smtp
 
 import (
 	"context"
+	"fmt"
 	"integration_framework/application_config"
+	"integration_framework/plugins"
+	"integration_framework/plugins/external"
+	"net"
+	"net/http"
 )
 
 type Service struct {
 	name string
 	port int
-	env  application_config.ServiceDefinitionEnv
+	// port of smtp server on host, port is port of http api
+	smtpPort int
+	env      application_config.ServiceDefinitionEnv
+	params   map[string]interface{}
+	// servers started by local launcher instead of container
+	smtpListener net.Listener
+	stopped      chan struct{}
+	httpServer   *http.Server
+	// defined if service is not launched by framework
+	externalEndpoint *external.Endpoint
 }
 
-func NewService(name string, port int, env application_config.ServiceDefinitionEnv) (*Service, error) {
+func NewService(name string, env application_config.ServiceDefinitionEnv, params map[string]interface{}) (*Service, error) {
+	for _, param := range []string{"image", "username", "password"} {
+		value, ok := params[param]
+		if !ok {
+			continue
+		}
+		if _, ok := value.(string); !ok {
+			return nil, fmt.Errorf("param %q of smtp should be string, but it is %T", param, value)
+		}
+	}
 	return &Service{
-		name: name,
-		port: port,
-		env:  env,
+		name:   name,
+		env:    env,
+		params: params,
 	}, nil
 }
 
@@ -24,6 +47,35 @@ func (s *Service) Start() error {
 	return nil
 }
 
-func (s Service) WaitForPortAvailable(ctx context.Context) error {
-	return nil
+func (s Service) Url() string {
+	if s.externalEndpoint != nil {
+		return s.externalEndpoint.Address
+	}
+	return fmt.Sprintf("http://localhost:%d/", s.port)
+}
+
+func (s *Service) ReadinessProbe() plugins.IProbe {
+	apiProbe := plugins.NewHttpProbe(s.Url()+"__mails", true)
+	if s.externalEndpoint != nil {
+		return apiProbe
+	}
+	bannerProbe := plugins.NewSmtpBannerProbe(fmt.Sprintf("localhost:%d", s.smtpPort))
+	return plugins.ProbeFunc(func(ctx context.Context) error {
+		err := apiProbe.Probe(ctx)
+		if err != nil {
+			return fmt.Errorf("http api not ready: %v", err)
+		}
+		err = bannerProbe.Probe(ctx)
+		if err != nil {
+			return fmt.Errorf("smtp server not ready: %v", err)
+		}
+		return nil
+	})
+}
+
+// credentials returns username and password required by smtp server. Empty username means that any credentials are accepted
+func (s Service) credentials() (string, string) {
+	username, _ := s.params["username"].(string)
+	password, _ := s.params["password"].(string)
+	return username, password
 }
@@ -0,0 +1,161 @@
+package smtp_mock
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+)
+
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	// content is encoded to base64 in json
+	Content []byte `json:"content"`
+}
+
+// message is parsed MIME message
+type message struct {
+	subject     string
+	headers     map[string]string
+	text        string
+	html        string
+	attachments []Attachment
+}
+
+func parseMessage(raw []byte) (*message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read message: %v", err)
+	}
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	res := message{
+		headers: make(map[string]string, len(m.Header)),
+	}
+	for name, values := range m.Header {
+		value, err := decoder.DecodeHeader(strings.Join(values, ", "))
+		if err != nil {
+			value = strings.Join(values, ", ")
+		}
+		res.headers[name] = value
+	}
+	res.subject = res.headers["Subject"]
+	err = res.parsePart(m.Header, m.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// partHeader is header of message or of part of multipart message
+type partHeader interface {
+	Get(key string) string
+}
+
+// parsePart collects texts and attachments from part. Multipart parts are parsed recursively
+func (m *message) parsePart(header partHeader, body io.Reader) error {
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("unable to parse content type %q: %v", contentType, err)
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("unable to read part of multipart message: %v", err)
+			}
+			err = m.parsePart(part.Header, part)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := ioutil.ReadAll(decodeTransferEncoding(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("unable to read content of part: %v", err)
+	}
+	disposition, dispositionParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	// clients encode names of attachments like headers
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	decodedFilename, err := decoder.DecodeHeader(filename)
+	if err == nil {
+		filename = decodedFilename
+	}
+	switch {
+	case disposition == "attachment" || filename != "":
+		m.attachments = append(m.attachments, Attachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			Size:        len(content),
+			Content:     content,
+		})
+	case mediaType == "text/plain" && m.text == "":
+		m.text = normalizeText(decodeText(params["charset"], content))
+	case mediaType == "text/html" && m.html == "":
+		m.html = normalizeText(decodeText(params["charset"], content))
+	}
+	return nil
+}
+
+// decodeText converts text to utf-8. Text in unsupported charset is kept as is
+func decodeText(charset string, content []byte) string {
+	text, err := decodeCharset(charset, content)
+	if err != nil {
+		log.Printf("unable to decode text of mail: %v", err)
+		return string(content)
+	}
+	return text
+}
+
+// decodeTransferEncoding decodes body. Quoted-printable parts of multipart message are already decoded by multipart reader
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlinesSkipper{r: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// newlinesSkipper removes line breaks of base64 encoded content
+type newlinesSkipper struct {
+	r io.Reader
+}
+
+func (s *newlinesSkipper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	res := p[:0]
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			res = append(res, b)
+		}
+	}
+	return len(res), err
+}
+
+func normalizeText(text string) string {
+	return strings.TrimRight(strings.Replace(text, "\r\n", "\n", -1), "\n")
+}
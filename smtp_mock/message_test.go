@@ -0,0 +1,210 @@
+package smtp_mock
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// crlf converts line breaks of message to smtp ones
+func crlf(s string) []byte {
+	return []byte(strings.Replace(s, "\n", "\r\n", -1))
+}
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected message
+	}{
+		{
+			name: "plain text",
+			raw: `From: shop@example.com
+Subject: Order
+Content-Type: text/plain
+
+Your order is ready
+
+`,
+			expected: message{
+				subject: "Order",
+				headers: map[string]string{"From": "shop@example.com", "Subject": "Order", "Content-Type": "text/plain"},
+				text:    "Your order is ready",
+			},
+		},
+		{
+			name: "encoded words of headers",
+			raw: `From: =?UTF-8?B?0JzQsNCz0LDQt9C40L0=?= <shop@example.com>
+To: =?koi8-r?B?+sHLwdo=?= <a@example.com>
+Subject: =?windows-1251?Q?=D1=F7=B8=F2?= =?ISO-8859-1?Q?Gr=FC=DFe?=
+
+text
+`,
+			expected: message{
+				subject: "СчётGrüße",
+				headers: map[string]string{"From": "Магазин <shop@example.com>", "To": "Заказ <a@example.com>", "Subject": "СчётGrüße"},
+				text:    "text",
+			},
+		},
+		{
+			name: "unsupported charset of header",
+			raw: `Subject: =?x-unknown?Q?abc?=
+
+text
+`,
+			expected: message{
+				subject: "=?x-unknown?Q?abc?=",
+				headers: map[string]string{"Subject": "=?x-unknown?Q?abc?="},
+				text:    "text",
+			},
+		},
+		{
+			name: "alternative parts in charsets",
+			raw: `Subject: Hello
+Content-Type: multipart/alternative; boundary="b1"
+
+--b1
+Content-Type: text/plain; charset=windows-1251
+Content-Transfer-Encoding: base64
+
+z/Do4uXyLCDs
+6PA=
+--b1
+Content-Type: text/html; charset=iso-8859-1
+Content-Transfer-Encoding: quoted-printable
+
+<p>=47=72=FC=DF=65, long line =
+continued</p>
+--b1--
+`,
+			expected: message{
+				subject: "Hello",
+				headers: map[string]string{"Subject": "Hello", "Content-Type": `multipart/alternative; boundary="b1"`},
+				text:    "Привет, мир",
+				html:    "<p>Grüße, long line continued</p>",
+			},
+		},
+		{
+			name: "attachments",
+			raw: `Subject: Invoice
+Content-Type: multipart/mixed; boundary="outer"
+
+--outer
+Content-Type: multipart/alternative; boundary="inner"
+
+--inner
+Content-Type: text/plain
+
+See attachments
+--inner--
+--outer
+Content-Type: application/pdf
+Content-Disposition: attachment; filename="=?UTF-8?B?0L7RgtGH0ZHRgi5wZGY=?="
+Content-Transfer-Encoding: base64
+
+JVBE
+Ri0x
+--outer
+Content-Type: text/csv; name="rows.csv"
+
+a,b
+--outer
+Content-Type: text/plain
+Content-Disposition: attachment
+
+inline text
+--outer--
+`,
+			expected: message{
+				subject: "Invoice",
+				headers: map[string]string{"Subject": "Invoice", "Content-Type": `multipart/mixed; boundary="outer"`},
+				text:    "See attachments",
+				attachments: []Attachment{
+					{Filename: "отчёт.pdf", ContentType: "application/pdf", Size: 6, Content: []byte("%PDF-1")},
+					{Filename: "rows.csv", ContentType: "text/csv", Size: 3, Content: []byte("a,b")},
+					{ContentType: "text/plain", Size: 11, Content: []byte("inline text")},
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := parseMessage(crlf(test.raw))
+			if err != nil {
+				t.Fatalf("unable to parse message: %v", err)
+			}
+			if !reflect.DeepEqual(*res, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, *res)
+			}
+		})
+	}
+}
+
+func TestParseMessageErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		expectedError string
+	}{
+		{
+			name:          "without headers",
+			raw:           "text",
+			expectedError: "unable to read message",
+		},
+		{
+			name:          "invalid content type",
+			raw:           "Content-Type: text/plain; charset\n\ntext\n",
+			expectedError: "unable to parse content type",
+		},
+		{
+			name:          "unclosed multipart",
+			raw:           "Content-Type: multipart/mixed; boundary=b\n\n--b\nContent-Type: text/plain\n\ntext",
+			expectedError: "unable to read content of part",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseMessage(crlf(test.raw))
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("expected error %q, got %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestDecodeCharset(t *testing.T) {
+	tests := []struct {
+		charset       string
+		content       []byte
+		expected      string
+		expectedError string
+	}{
+		{charset: "", content: []byte("текст"), expected: "текст"},
+		{charset: "US-ASCII", content: []byte("text"), expected: "text"},
+		{charset: "ISO-8859-1", content: []byte{0x47, 0x72, 0xfc, 0xdf, 0x65}, expected: "Grüße"},
+		{charset: "windows-1252", content: []byte{0x80, 0x20, 0x93, 0x71, 0x94}, expected: "€ “q”"},
+		{charset: "cp1251", content: []byte{0xd1, 0xf7, 0xb8, 0xf2}, expected: "Счёт"},
+		{charset: "KOI8-R", content: []byte{0xfa, 0xc1, 0xcb, 0xc1, 0xda}, expected: "Заказ"},
+		{charset: "utf-16", content: []byte{0xff, 0xfe, 0x3f, 0x04, 0x40, 0x04}, expected: "пр"},
+		{charset: "utf-16be", content: []byte{0x04, 0x3f, 0xd8, 0x3d, 0xde, 0x00}, expected: "п😀"},
+		{charset: "utf-16le", content: []byte{0x3f, 0x04, 0x40}, expected: "п�"},
+		{charset: "x-unknown", content: []byte("text"), expectedError: `charset "x-unknown" is not supported`},
+	}
+	for _, test := range tests {
+		t.Run(test.charset, func(t *testing.T) {
+			res, err := decodeCharset(test.charset, test.content)
+			if test.expectedError != "" {
+				if err == nil || err.Error() != test.expectedError {
+					t.Fatalf("expected error %q, got %v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to decode: %v", err)
+			}
+			if res != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, res)
+			}
+		})
+	}
+}
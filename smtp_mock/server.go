@@ -0,0 +1,182 @@
+// Package smtp_mock implements smtp server which captures mails sent by application.
+//
+// Every received mail is parsed and recorded once for every recipient. Captured mails are available by http api:
+//
+//	GET /__mails - returns list of captured mails like `[{"from": "a@b.c", "to": "d@e.f", "subject": "...", "content": "..."}]`
+//	POST /__reset_mails - removes captured mails
+//
+// AUTH PLAIN and LOGIN are supported. If credentials are defined only clients authenticated with them can send mails,
+// otherwise any credentials are accepted and authentication is optional
+package smtp_mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/emersion/go-sasl"
+	"github.com/emersion/go-smtp"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+)
+
+type Mail struct {
+	// address from MAIL FROM command
+	From string `json:"from"`
+	// address from RCPT TO command
+	To string `json:"to"`
+	// name of authenticated user, empty if client was not authenticated
+	Username string `json:"username"`
+	Subject  string `json:"subject"`
+	// text/plain part of mail
+	Content string `json:"content"`
+	// text/html part of mail
+	Html        string            `json:"html"`
+	Headers     map[string]string `json:"headers"`
+	Attachments []Attachment      `json:"attachments"`
+}
+
+type Server struct {
+	mutex    sync.Mutex
+	username string
+	password string
+	mails    []Mail
+}
+
+// NewServer creates server which accepts only defined credentials. If username is empty any credentials are accepted
+func NewServer(username string, password string) *Server {
+	return &Server{
+		username: username,
+		password: password,
+	}
+}
+
+// SmtpServer creates smtp server which captures mails to s
+func (s *Server) SmtpServer(addr string) *smtp.Server {
+	server := smtp.NewServer(s)
+	server.Addr = addr
+	server.Domain = "localhost"
+	server.AllowInsecureAuth = true
+	server.EnableAuth(sasl.Login, func(conn *smtp.Conn) sasl.Server {
+		return sasl.NewLoginServer(func(username, password string) error {
+			state := conn.State()
+			session, err := s.Login(&state, username, password)
+			if err != nil {
+				return err
+			}
+			conn.SetSession(session)
+			return nil
+		})
+	})
+	return server
+}
+
+func (s *Server) Mails() []Mail {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	mails := make([]Mail, len(s.mails))
+	copy(mails, s.mails)
+	return mails
+}
+
+func (s *Server) ResetMails() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.mails = nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/__mails":
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(s.Mails())
+		if err != nil {
+			log.Printf("unable to write mails: %v", err)
+		}
+	case "/__reset_mails":
+		s.ResetMails()
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Login implements smtp.Backend
+func (s *Server) Login(state *smtp.ConnectionState, username, password string) (smtp.Session, error) {
+	if s.username != "" && (username != s.username || password != s.password) {
+		return nil, fmt.Errorf("invalid username or password")
+	}
+	return &session{
+		server:   s,
+		username: username,
+	}, nil
+}
+
+// AnonymousLogin implements smtp.Backend
+func (s *Server) AnonymousLogin(state *smtp.ConnectionState) (smtp.Session, error) {
+	if s.username != "" {
+		return nil, smtp.ErrAuthRequired
+	}
+	return &session{
+		server: s,
+	}, nil
+}
+
+func (s *Server) addMails(mails []Mail) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.mails = append(s.mails, mails...)
+}
+
+type session struct {
+	server   *Server
+	username string
+	from     string
+	to       []string
+}
+
+func (s *session) Reset() {
+	s.from = ""
+	s.to = nil
+}
+
+func (s *session) Logout() error {
+	return nil
+}
+
+func (s *session) Mail(from string, opts smtp.MailOptions) error {
+	s.from = from
+	return nil
+}
+
+func (s *session) Rcpt(to string) error {
+	s.to = append(s.to, to)
+	return nil
+}
+
+func (s *session) Data(r io.Reader) error {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("unable to read mail: %v", err)
+	}
+	message, err := parseMessage(raw)
+	if err != nil {
+		log.Printf("unable to parse mail from %q: %v", s.from, err)
+		return err
+	}
+	mails := make([]Mail, 0, len(s.to))
+	for _, to := range s.to {
+		mails = append(mails, Mail{
+			From:        s.from,
+			To:          to,
+			Username:    s.username,
+			Subject:     message.subject,
+			Content:     message.text,
+			Html:        message.html,
+			Headers:     message.headers,
+			Attachments: message.attachments,
+		})
+	}
+	s.server.addMails(mails)
+	return nil
+}
@@ -0,0 +1,239 @@
+package smtp_mock
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+// startTestServer starts smtp server and http api, they should be closed by test
+func startTestServer(t *testing.T, username string, password string) (smtpAddr string, smtpServer net.Listener, httpServer *httptest.Server) {
+	server := NewServer(username, password)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	go server.SmtpServer(listener.Addr().String()).Serve(listener)
+	return listener.Addr().String(), listener, httptest.NewServer(server)
+}
+
+// loginAuth implements AUTH LOGIN which is not supported by net/smtp
+type loginAuth struct {
+	username string
+	password string
+}
+
+func (a loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", nil, nil
+}
+
+func (a loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(string(fromServer)) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, errors.New("unexpected challenge " + string(fromServer))
+	}
+}
+
+func sendMail(addr string, auth smtp.Auth, from string, to []string, raw string) error {
+	client, err := smtp.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		err = client.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+	err = client.Mail(from)
+	if err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		err = client.Rcpt(recipient)
+		if err != nil {
+			return err
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(crlf(raw))
+	if err != nil {
+		return err
+	}
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func getMails(t *testing.T, url string) []Mail {
+	resp, err := http.Get(url + "/__mails")
+	if err != nil {
+		t.Fatalf("unable to get mails: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mails []Mail
+	err = json.Unmarshal(body, &mails)
+	if err != nil {
+		t.Fatalf("unable to unmarshal mails %s: %v", body, err)
+	}
+	return mails
+}
+
+const testMail = `Subject: Hello
+Content-Type: multipart/mixed; boundary="b"
+
+--b
+Content-Type: text/plain
+
+Hi
+--b
+Content-Type: text/plain; name="a.txt"
+
+abc
+--b--
+`
+
+func TestMailsApi(t *testing.T) {
+	addr, listener, httpServer := startTestServer(t, "", "")
+	defer listener.Close()
+	defer httpServer.Close()
+
+	err := sendMail(addr, nil, "shop@example.com", []string{"a@example.com", "b@example.com"}, testMail)
+	if err != nil {
+		t.Fatalf("unable to send mail: %v", err)
+	}
+	mails := getMails(t, httpServer.URL)
+	if len(mails) != 2 {
+		t.Fatalf("expected mail recorded for every recipient, got %+v", mails)
+	}
+	for i, to := range []string{"a@example.com", "b@example.com"} {
+		mail := mails[i]
+		if mail.From != "shop@example.com" || mail.To != to || mail.Username != "" || mail.Subject != "Hello" || mail.Content != "Hi" {
+			t.Errorf("unexpected mail #%d %+v", i, mail)
+		}
+		if len(mail.Attachments) != 1 || mail.Attachments[0].Filename != "a.txt" || string(mail.Attachments[0].Content) != "abc" {
+			t.Errorf("unexpected attachments of mail #%d %+v", i, mail.Attachments)
+		}
+	}
+
+	resp, err := http.Post(httpServer.URL+"/__reset_mails", "", nil)
+	if err != nil {
+		t.Fatalf("unable to reset mails: %v", err)
+	}
+	resp.Body.Close()
+	mails = getMails(t, httpServer.URL)
+	if len(mails) != 0 {
+		t.Errorf("expected mails removed, got %+v", mails)
+	}
+
+	resp, err = http.Get(httpServer.URL + "/unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected not found, got %d", resp.StatusCode)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name             string
+		username         string
+		password         string
+		auth             smtp.Auth
+		expectedError    string
+		expectedUsername string
+	}{
+		{
+			name:             "plain",
+			username:         "user",
+			password:         "secret",
+			auth:             smtp.PlainAuth("", "user", "secret", "127.0.0.1"),
+			expectedUsername: "user",
+		},
+		{
+			name:             "login",
+			username:         "user",
+			password:         "secret",
+			auth:             loginAuth{"user", "secret"},
+			expectedUsername: "user",
+		},
+		{
+			name:          "invalid password",
+			username:      "user",
+			password:      "secret",
+			auth:          smtp.PlainAuth("", "user", "wrong", "127.0.0.1"),
+			expectedError: "invalid username or password",
+		},
+		{
+			name:          "invalid password of login",
+			username:      "user",
+			password:      "secret",
+			auth:          loginAuth{"user", "wrong"},
+			expectedError: "invalid username or password",
+		},
+		{
+			name:          "anonymous with credentials",
+			username:      "user",
+			password:      "secret",
+			expectedError: "Please authenticate first",
+		},
+		{
+			name:             "any credentials",
+			auth:             smtp.PlainAuth("", "other", "any", "127.0.0.1"),
+			expectedUsername: "other",
+		},
+		{
+			name: "anonymous",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr, listener, httpServer := startTestServer(t, test.username, test.password)
+			defer listener.Close()
+			defer httpServer.Close()
+
+			err := sendMail(addr, test.auth, "shop@example.com", []string{"a@example.com"}, testMail)
+			if test.expectedError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+					t.Fatalf("expected error %q, got %v", test.expectedError, err)
+				}
+				if mails := getMails(t, httpServer.URL); len(mails) != 0 {
+					t.Errorf("expected mail rejected, got %+v", mails)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unable to send mail: %v", err)
+			}
+			mails := getMails(t, httpServer.URL)
+			if len(mails) != 1 || mails[0].Username != test.expectedUsername {
+				t.Errorf("expected mail of user %q, got %+v", test.expectedUsername, mails)
+			}
+		})
+	}
+}
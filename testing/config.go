@@ -3,8 +3,11 @@ package testing
 import (
 	"fmt"
 	"integration_framework/application_config"
+	"integration_framework/helper"
 	"integration_framework/plugins"
+	"integration_framework/plugins/external"
 	"sort"
+	"strings"
 )
 
 func ParseConfig(config *application_config.Config) (*ParsedConfig, error) {
@@ -16,15 +19,11 @@ func ParseConfig(config *application_config.Config) (*ParsedConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to create services: %v", err)
 	}
-	err = res.createRequesterConstructor()
+	err = res.createRequesterConstructors()
 	if err != nil {
-		return nil, fmt.Errorf("unable to create requester constructor: %v", err)
+		return nil, fmt.Errorf("unable to create requester constructors: %v", err)
 	}
-	err = res.createGeneralCasesRequesters()
-	if err != nil {
-		return nil, fmt.Errorf("unable to create general cases requesters: %v", err)
-	}
-	err = res.createTesters(config.Cases, nil, nil, "", false)
+	err = res.createTesters(config.Cases, nil, nil, nil, "", "", "", false)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create testers: %v", err)
 	}
@@ -36,15 +35,16 @@ type ParsedConfig struct {
 	Services map[string]plugins.IService
 	Testers  []Tester
 
-	onlyCases              []string
-	config                 *application_config.Config
-	requesterConstructor   plugins.RequesterConstructor
-	generalCasesRequesters map[string]plugins.IRequester
+	onlyCases []string
+	config    *application_config.Config
+	// checkers of services added to every test
+	defaultCheckers []plugins.IServiceChecker
+	// by name of application
+	requesterConstructors map[string]plugins.RequesterConstructor
 }
 
 func (pc *ParsedConfig) createServices() error {
 	pc.Services = make(map[string]plugins.IService)
-	port := 9000
 	var serviceNames []string
 	for serviceName := range pc.config.Services {
 		serviceNames = append(serviceNames, serviceName)
@@ -52,40 +52,49 @@ func (pc *ParsedConfig) createServices() error {
 	sort.Strings(serviceNames)
 	for _, serviceName := range serviceNames {
 		serviceConfig := pc.config.Services[serviceName]
-		service, err := plugins.NewService(serviceName, serviceConfig.Type, port, serviceConfig.Env, serviceConfig.Params)
+		service, err := plugins.NewService(serviceName, serviceConfig.Type, serviceConfig.Env, serviceConfig.Params)
 		if err != nil {
 			return fmt.Errorf("unable to create service %q: %v", serviceName, err)
 		}
 		pc.Services[serviceName] = service
-		port++
+		if serviceWithDefaultChecker, ok := service.(plugins.IServiceWithDefaultChecker); ok {
+			if checker := serviceWithDefaultChecker.DefaultChecker(); checker != nil {
+				pc.defaultCheckers = append(pc.defaultCheckers, checker)
+			}
+		}
 	}
 	return nil
 }
 
-func (pc *ParsedConfig) createRequesterConstructor() error {
-	var ok bool
-	pc.requesterConstructor, ok = plugins.GetRequesterConstructor(pc.config.Application.RequestType)
-	if !ok {
-		return fmt.Errorf("unable to find requester for type %q", pc.config.Application.RequestType)
+func (pc *ParsedConfig) createRequesterConstructors() error {
+	pc.requesterConstructors = make(map[string]plugins.RequesterConstructor)
+	for applicationName, application := range pc.config.Applications {
+		requesterConstructor, ok := plugins.GetRequesterConstructor(application.RequestType)
+		if !ok {
+			return fmt.Errorf("unable to find requester for type %q of application %q", application.RequestType, applicationName)
+		}
+		pc.requesterConstructors[applicationName] = requesterConstructor
 	}
 	return nil
 }
 
-func (pc *ParsedConfig) createGeneralCasesRequesters() error {
-	pc.generalCasesRequesters = make(map[string]plugins.IRequester)
-	for generalCaseName, generalCase := range pc.config.GeneralCases {
-		for testCaseName, testCase := range generalCase.Cases {
-			if testCase.Request != nil {
-				generalCaseTestName := generalCaseName + " " + testCaseName
-				requester, err := pc.requesterConstructor(testCase.Request, pc.config.Application.RequestDefaults)
-				if err != nil {
-					return fmt.Errorf("unable to create requester for general case %q: %v", generalCaseTestName, err)
-				}
-				pc.generalCasesRequesters[generalCaseTestName] = requester
-			}
+// resolveApplication returns name of application which receives requests of test case.
+// it can be omitted if there is only one application
+func (pc *ParsedConfig) resolveApplication(applicationName string) (string, error) {
+	if applicationName == "" {
+		if len(pc.config.Applications) != 1 {
+			return "", fmt.Errorf("application should be specified because several applications defined")
 		}
+		return pc.config.ApplicationsNames()[0], nil
 	}
-	return nil
+	if _, ok := pc.config.Applications[applicationName]; !ok {
+		return "", fmt.Errorf("application %q not defined", applicationName)
+	}
+	return applicationName, nil
+}
+
+func (pc *ParsedConfig) newRequester(applicationName string, request interface{}) (plugins.IRequester, error) {
+	return pc.requesterConstructors[applicationName](request, pc.config.Applications[applicationName].RequestDefaults)
 }
 
 func getOnlyCases(cases application_config.TestCases, casePrefix string) (res []string) {
@@ -101,98 +110,203 @@ func getOnlyCases(cases application_config.TestCases, casePrefix string) (res []
 	return
 }
 
-func (pc *ParsedConfig) createTesters(cases application_config.TestCases, parentPreparers []plugins.IServicePreparer, parentCheckers []plugins.IServiceChecker, casePrefix string, ignoreOnly bool) error {
+// createTesters creates testers of cases and their sub-cases.
+// casePrefix is name of parent case with parameters, pathPrefix is name of parent case in config used to find only-cases
+func (pc *ParsedConfig) createTesters(cases application_config.TestCases, parentPreparers []plugins.IServicePreparer, parentCheckers []plugins.IServiceChecker, parentParams map[string]interface{}, parentApplication string, casePrefix string, pathPrefix string, ignoreOnly bool) error {
 	for tCN, testCase := range cases {
-		testCaseName := casePrefix + tCN
-
 		if testCase.Skip {
 			continue
 		}
 
-		// process only-cases
-		// if this test not exists in onlyCases then preparers and checkers should be created but only for children
-		allowedToProcess := true
-		if !ignoreOnly && len(pc.onlyCases) != 0 {
-			found := false
-			for _, onlyCaseName := range pc.onlyCases {
-				if testCaseName == onlyCaseName {
-					found = true
-					break
-				}
+		// test case without parameters is processed once with parameters of parent
+		if testCase.Parameters == nil {
+			err := pc.createTestersForCase(casePrefix+tCN, pathPrefix+tCN, testCase, parentPreparers, parentCheckers, parentParams, parentApplication, ignoreOnly)
+			if err != nil {
+				return err
 			}
-			allowedToProcess = found
+			continue
 		}
 
-		// create preparers
-		servicePreparers := append([]plugins.IServicePreparer{}, parentPreparers...)
-		for serviceName, servicePreparerParams := range testCase.PrepareServices {
-			service, ok := pc.Services[serviceName]
-			if !ok {
-				return fmt.Errorf("unable to find service with name %q", serviceName)
+		for _, combination := range testCase.Parameters.Combinations() {
+			params := make(map[string]interface{}, len(parentParams)+len(combination))
+			for paramName, paramValue := range parentParams {
+				params[paramName] = paramValue
+			}
+			for paramName, paramValue := range combination {
+				params[paramName] = paramValue
 			}
-			// fmt.Printf("-- create service %q preparer %#v for test case %q\n", serviceName, servicePreparerParams, testCaseName)
-			servicePreparer, err := service.Preparer(servicePreparerParams)
+			err := pc.createTestersForCase(casePrefix+tCN+parametersSuffix(combination), pathPrefix+tCN, testCase, parentPreparers, parentCheckers, params, parentApplication, ignoreOnly)
 			if err != nil {
-				return fmt.Errorf("unable to create preparer for service %q: %v", serviceName, err)
+				return err
 			}
-			servicePreparers = append(servicePreparers, servicePreparer)
 		}
+	}
+	return nil
+}
 
-		// create checkers
-		serviceCheckers := append([]plugins.IServiceChecker{}, parentCheckers...)
-		for _, checkServicesMap := range testCase.CheckServices {
-			for serviceName, serviceCheckerParams := range checkServicesMap {
-				service, ok := pc.Services[serviceName]
-				if !ok {
-					return fmt.Errorf("unable to find service with name %q", serviceName)
-				}
-				serviceChecker, err := service.Checker(serviceCheckerParams)
-				if err != nil {
-					return fmt.Errorf("unable to create checker for service %q: %v", serviceName, err)
-				}
-				serviceCheckers = append(serviceCheckers, serviceChecker)
+// parametersSuffix returns suffix like ` [param1=value1, param2=value2]` to distinguish testers of parametrized test case
+func parametersSuffix(combination map[string]interface{}) string {
+	var paramNames []string
+	for paramName := range combination {
+		paramNames = append(paramNames, paramName)
+	}
+	sort.Strings(paramNames)
+	parts := make([]string, len(paramNames))
+	for i, paramName := range paramNames {
+		parts[i] = fmt.Sprintf("%s=%v", paramName, combination[paramName])
+	}
+	return " [" + strings.Join(parts, ", ") + "]"
+}
+
+func (pc *ParsedConfig) createTestersForCase(testCaseName string, testCasePath string, testCase *application_config.TestCase, parentPreparers []plugins.IServicePreparer, parentCheckers []plugins.IServiceChecker, params map[string]interface{}, parentApplication string, ignoreOnly bool) error {
+	// process only-cases
+	// if this test not exists in onlyCases then preparers and checkers should be created but only for children.
+	// path is used instead of name because names of parametrized test cases contain parameters
+	allowedToProcess := true
+	if !ignoreOnly && len(pc.onlyCases) != 0 {
+		found := false
+		for _, onlyCaseName := range pc.onlyCases {
+			if testCasePath == onlyCaseName {
+				found = true
+				break
 			}
 		}
+		allowedToProcess = found
+	}
 
-		if allowedToProcess {
-			// create testers for general cases
-			if testCase.GeneralCases != nil {
-				err := pc.createGeneralTesters(testCaseName, servicePreparers, serviceCheckers, *testCase.GeneralCases)
-				if err != nil {
-					return fmt.Errorf("unable to create general cases for %q: %v", testCaseName, err)
-				}
-			}
+	servicePreparers, err := pc.createPreparers(parentPreparers, testCase.PrepareServices)
+	if err != nil {
+		return err
+	}
+	serviceCheckers, err := pc.createCheckers(parentCheckers, testCase.CheckServices)
+	if err != nil {
+		return err
+	}
+	application := parentApplication
+	if testCase.Application != "" {
+		application = testCase.Application
+	}
 
-			// create tester for this test case
-			if testCase.Request != nil || testCase.ModifyRequest != nil || testCase.ExpectedCode != 0 || testCase.ExpectedResponse != nil {
-				if testCase.Request == nil {
-					return fmt.Errorf("unable to create tester %q: request should be set", testCaseName)
-				}
-				requester, err := pc.requesterConstructor(testCase.Request, pc.config.Application.RequestDefaults)
-				if err != nil {
-					return fmt.Errorf("unable to create request for tester %q: %v", testCaseName, err)
-				}
-				// fmt.Printf("**** created requster for test case %q: %#v\n", testCaseName, requester)
-				err = pc.createTester(testCaseName, servicePreparers, serviceCheckers, testCase, requester)
-				if err != nil {
-					return fmt.Errorf("unable to create tester %q: %v", testCaseName, err)
-				}
+	if allowedToProcess {
+		// create testers for general cases
+		if testCase.GeneralCases != nil {
+			err := pc.createGeneralTesters(testCaseName, servicePreparers, serviceCheckers, params, application, *testCase.GeneralCases)
+			if err != nil {
+				return fmt.Errorf("unable to create general cases for %q: %v", testCaseName, err)
 			}
 		}
 
-		// create testers for sub-cases
-		if testCase.Cases != nil {
-			// ignore `only` flag in all children if test case is allowed to process
-			err := pc.createTesters(testCase.Cases, servicePreparers, serviceCheckers, testCaseName+" ", allowedToProcess)
+		// create tester for this test case
+		if testCase.Request != nil || testCase.ModifyRequest != nil || testCase.ExpectedCode != 0 || testCase.ExpectedResponse != nil {
+			if testCase.Request == nil {
+				return fmt.Errorf("unable to create tester %q: request should be set", testCaseName)
+			}
+			applicationName, err := pc.resolveApplication(application)
 			if err != nil {
-				return err
+				return fmt.Errorf("unable to create tester %q: %v", testCaseName, err)
+			}
+			requester, err := pc.newRequesterFactory(applicationName, testCase.Request, len(params) != 0)
+			if err != nil {
+				return fmt.Errorf("unable to create request for tester %q: %v", testCaseName, err)
+			}
+			err = pc.createTester(testCaseName, servicePreparers, serviceCheckers, params, nil, applicationName, testCase, requester)
+			if err != nil {
+				return fmt.Errorf("unable to create tester %q: %v", testCaseName, err)
 			}
 		}
 	}
+
+	// create testers for sub-cases
+	if testCase.Cases != nil {
+		// ignore `only` flag in all children if test case is allowed to process
+		err := pc.createTesters(testCase.Cases, servicePreparers, serviceCheckers, params, application, testCaseName+" ", testCasePath+" ", allowedToProcess)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (pc *ParsedConfig) createGeneralTesters(testCaseName string, servicePreparers []plugins.IServicePreparer, serviceCheckers []plugins.IServiceChecker, selector application_config.GeneralCasesSelector) (err error) {
+func (pc *ParsedConfig) createPreparers(parentPreparers []plugins.IServicePreparer, prepareServices map[string]interface{}) ([]plugins.IServicePreparer, error) {
+	servicePreparers := append([]plugins.IServicePreparer{}, parentPreparers...)
+	for serviceName, servicePreparerParams := range prepareServices {
+		service, ok := pc.Services[serviceName]
+		if !ok {
+			return nil, fmt.Errorf("unable to find service with name %q", serviceName)
+		}
+		servicePreparer, err := service.Preparer(servicePreparerParams)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create preparer for service %q: %v", serviceName, err)
+		}
+		servicePreparers = append(servicePreparers, external.GuardPreparer(service, servicePreparer))
+	}
+	return servicePreparers, nil
+}
+
+func (pc *ParsedConfig) createCheckers(parentCheckers []plugins.IServiceChecker, checkServices []map[string]interface{}) ([]plugins.IServiceChecker, error) {
+	serviceCheckers := append([]plugins.IServiceChecker{}, parentCheckers...)
+	for _, checkServicesMap := range checkServices {
+		for serviceName, serviceCheckerParams := range checkServicesMap {
+			service, ok := pc.Services[serviceName]
+			if !ok {
+				return nil, fmt.Errorf("unable to find service with name %q", serviceName)
+			}
+			serviceChecker, err := service.Checker(serviceCheckerParams)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create checker for service %q: %v", serviceName, err)
+			}
+			serviceCheckers = append(serviceCheckers, serviceChecker)
+		}
+	}
+	return serviceCheckers, nil
+}
+
+// newRequesterFactory returns constructor of requester of test case.
+// Request of parametrized test case is interpolated before every execution, when parameters and urls of launched services are known.
+// Request of other test case is used as is, so its requester is created once
+func (pc *ParsedConfig) newRequesterFactory(applicationName string, request interface{}, parametrized bool) (requesterFactory, error) {
+	if !parametrized {
+		requester, err := pc.newRequester(applicationName, request)
+		if err != nil {
+			return nil, err
+		}
+		return func(variables map[string]interface{}) (plugins.IRequester, error) {
+			return requester, nil
+		}, nil
+	}
+	return func(variables map[string]interface{}) (plugins.IRequester, error) {
+		interpolatedRequest, err := helper.ApplyInterpolationForObject(request, variables)
+		if err != nil {
+			return nil, fmt.Errorf("unable to interpolate request: %v", err)
+		}
+		return pc.newRequester(applicationName, interpolatedRequest)
+	}, nil
+}
+
+// environmentVariables returns variables describing launched environment: `applications.<name>.url` and `services.<name>.url`.
+// `application.url` is added by tester because it depends on application of test case
+func (pc *ParsedConfig) environmentVariables(applicationsUrls map[string]string) map[string]interface{} {
+	services := make(map[string]interface{}, len(pc.Services))
+	for serviceName, service := range pc.Services {
+		serviceVariables := make(map[string]interface{})
+		if serviceWithUrl, ok := service.(plugins.IServiceWithUrl); ok {
+			serviceVariables["url"] = serviceWithUrl.Url()
+		}
+		services[serviceName] = serviceVariables
+	}
+	applications := make(map[string]interface{}, len(applicationsUrls))
+	for applicationName, applicationUrl := range applicationsUrls {
+		applications[applicationName] = map[string]interface{}{
+			"url": applicationUrl,
+		}
+	}
+	return map[string]interface{}{
+		"services":     services,
+		"applications": applications,
+	}
+}
+
+func (pc *ParsedConfig) createGeneralTesters(testCaseName string, servicePreparers []plugins.IServicePreparer, serviceCheckers []plugins.IServiceChecker, params map[string]interface{}, application string, selector application_config.GeneralCasesSelector) (err error) {
 	for generalCaseName, generalCase := range pc.config.GeneralCases {
 		if !generalCase.AutoInclude {
 			include := false
@@ -219,23 +333,66 @@ func (pc *ParsedConfig) createGeneralTesters(testCaseName string, servicePrepare
 		}
 
 		for generalTestCaseName, generalTestCase := range generalCase.Cases {
-			selectorRequester, err := pc.requesterConstructor(selector.Request, pc.config.Application.RequestDefaults)
+			generalCaseTestName := generalCaseName + " " + generalTestCaseName
+			err := pc.createGeneralTester(testCaseName, generalCaseTestName, generalTestCase, servicePreparers, serviceCheckers, params, application, selector)
 			if err != nil {
-				return fmt.Errorf("unable to create requester for selector's request for general case %q for test case %q: %v", generalCaseName+" "+generalTestCaseName, testCaseName, err)
-			}
-			generalCaseRequester, ok := pc.generalCasesRequesters[generalCaseName+" "+generalTestCaseName]
-			if !ok {
-				return fmt.Errorf("unable to find requester for general case %q for test case %q: %v", generalCaseName+" "+generalTestCaseName, testCaseName, err)
-			}
-			requester, err := generalCaseRequester.Join(selectorRequester)
-			if err != nil {
-				return fmt.Errorf("unable to join requesters for general case %q for test case %q: %v", generalCaseName+" "+generalTestCaseName, testCaseName, err)
-			}
-			err = pc.createTester(testCaseName+" "+generalCaseName+" "+generalTestCaseName, servicePreparers, serviceCheckers, generalTestCase, requester)
-			if err != nil {
-				return fmt.Errorf("unable to create tester for general case %q for test case %q: %v", generalCaseName+" "+generalTestCaseName, testCaseName, err)
+				return fmt.Errorf("unable to create tester for general case %q for test case %q: %v", generalCaseTestName, testCaseName, err)
 			}
 		}
 	}
 	return
 }
+
+// createGeneralTester creates tester of general case included by test case.
+// Parameters passed by selector's `with` are available for general case along with parameters of test case, they are interpolated by tester
+func (pc *ParsedConfig) createGeneralTester(testCaseName string, generalCaseTestName string, generalTestCase *application_config.TestCase, parentPreparers []plugins.IServicePreparer, parentCheckers []plugins.IServiceChecker, params map[string]interface{}, application string, selector application_config.GeneralCasesSelector) error {
+	if generalTestCase.Request == nil {
+		return fmt.Errorf("request should be set")
+	}
+	// general case can be written for specific application
+	if generalTestCase.Application != "" {
+		application = generalTestCase.Application
+	}
+	applicationName, err := pc.resolveApplication(application)
+	if err != nil {
+		return err
+	}
+	selectorRequester, err := pc.newRequesterFactory(applicationName, selector.Request, len(params) != 0)
+	if err != nil {
+		return fmt.Errorf("unable to create requester for selector's request: %v", err)
+	}
+	generalCaseRequester, err := pc.newRequesterFactory(applicationName, generalTestCase.Request, len(params) != 0 || len(selector.With) != 0)
+	if err != nil {
+		return fmt.Errorf("unable to create requester: %v", err)
+	}
+	requester := func(variables map[string]interface{}) (plugins.IRequester, error) {
+		requester, err := generalCaseRequester(variables)
+		if err != nil {
+			return nil, err
+		}
+		// selector's request is interpolated with parameters of test case only
+		selectorVariables := make(map[string]interface{}, len(variables))
+		for key, value := range variables {
+			selectorVariables[key] = value
+		}
+		selectorVariables["params"] = params
+		selectorRequester, err := selectorRequester(selectorVariables)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create requester for selector's request: %v", err)
+		}
+		requester, err = requester.Join(selectorRequester)
+		if err != nil {
+			return nil, fmt.Errorf("unable to join requesters: %v", err)
+		}
+		return requester, nil
+	}
+	servicePreparers, err := pc.createPreparers(parentPreparers, generalTestCase.PrepareServices)
+	if err != nil {
+		return err
+	}
+	serviceCheckers, err := pc.createCheckers(parentCheckers, generalTestCase.CheckServices)
+	if err != nil {
+		return err
+	}
+	return pc.createTester(testCaseName+" "+generalCaseTestName, servicePreparers, serviceCheckers, params, selector.With, applicationName, generalTestCase, requester)
+}
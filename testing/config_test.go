@@ -0,0 +1,230 @@
+package testing
+
+import (
+	"gopkg.in/yaml.v2"
+	"integration_framework/application_config"
+	"integration_framework/plugins"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+type testRequester struct {
+	request interface{}
+}
+
+// madeRequests are requests made by test requesters
+var madeRequests []interface{}
+
+func (r testRequester) MakeRequest(variables map[string]interface{}) ([]byte, int, error) {
+	madeRequests = append(madeRequests, r.request)
+	return []byte("{}"), 200, nil
+}
+
+func (r testRequester) Join(other plugins.IRequester) (plugins.IRequester, error) {
+	return testRequester{request: []interface{}{r.request, other.(testRequester).request}}, nil
+}
+
+func init() {
+	plugins.DefineRequester("test", func(params interface{}, defaults application_config.RequestDefaults) (plugins.IRequester, error) {
+		return testRequester{request: params}, nil
+	})
+}
+
+func parseTestConfig(t *testing.T, configYaml string) *ParsedConfig {
+	var config application_config.Config
+	err := yaml.Unmarshal([]byte(configYaml), &config)
+	if err != nil {
+		t.Fatalf("unable to unmarshal config: %v", err)
+	}
+	parsedConfig, err := ParseConfig(&config)
+	if err != nil {
+		t.Fatalf("unable to parse config: %v", err)
+	}
+	return parsedConfig
+}
+
+func testersNames(parsedConfig *ParsedConfig) []string {
+	var names []string
+	for _, tester := range parsedConfig.Testers {
+		names = append(names, tester.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestOnlyCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		cases    string
+		expected []string
+	}{
+		{
+			name: "without only",
+			cases: `
+- first: {request: {}, expected_code: 200}
+- group:
+    cases:
+      - child: {request: {}, expected_code: 200}`,
+			expected: []string{"first", "group child"},
+		},
+		{
+			name: "only case with children",
+			cases: `
+- first: {request: {}, expected_code: 200}
+- group:
+    only: true
+    request: {}
+    expected_code: 200
+    cases:
+      - child: {request: {}, expected_code: 200}`,
+			expected: []string{"group", "group child"},
+		},
+		{
+			name: "nested only case",
+			cases: `
+- first: {request: {}, expected_code: 200}
+- group:
+    request: {}
+    expected_code: 200
+    cases:
+      - child: {request: {}, expected_code: 200}
+      - focused: {only: true, request: {}, expected_code: 200}`,
+			expected: []string{"group focused"},
+		},
+		{
+			name: "only case in parametrized case",
+			cases: `
+- first: {request: {}, expected_code: 200}
+- parametrized:
+    parameters: [{id: 1}, {id: 2}]
+    cases:
+      - focused: {only: true, request: {path: "/{{ .params.id }}"}, expected_code: 200}
+      - other: {request: {}, expected_code: 200}`,
+			expected: []string{"parametrized [id=1] focused", "parametrized [id=2] focused"},
+		},
+		{
+			name: "parametrized only case",
+			cases: `
+- first: {request: {}, expected_code: 200}
+- parametrized:
+    only: true
+    parameters: [{id: 1}, {id: 2}]
+    cases:
+      - child: {request: {path: "/{{ .params.id }}"}, expected_code: 200}`,
+			expected: []string{"parametrized [id=1] child", "parametrized [id=2] child"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cases application_config.TestCases
+			err := yaml.Unmarshal([]byte(test.cases), &cases)
+			if err != nil {
+				t.Fatalf("unable to unmarshal cases: %v", err)
+			}
+			parsedConfig := parseTestConfig(t, "applications: {app: {request_type: test}}")
+			parsedConfig.Testers = nil
+			parsedConfig.onlyCases = getOnlyCases(cases, "")
+			err = parsedConfig.createTesters(cases, nil, nil, nil, "", "", "", false)
+			if err != nil {
+				t.Fatalf("unable to create testers: %v", err)
+			}
+			names := testersNames(parsedConfig)
+			if !reflect.DeepEqual(names, test.expected) {
+				t.Errorf("expected testers %v, got %v", test.expected, names)
+			}
+		})
+	}
+}
+
+func TestRequestsInterpolatedOnExec(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		tester   string
+		expected interface{}
+	}{
+		{
+			name: "not parametrized case",
+			config: `
+cases:
+  - plain: {request: {path: "{{ .services.api.url }}"}, expected_code: 200}`,
+			tester:   "plain",
+			expected: map[interface{}]interface{}{"path": "{{ .services.api.url }}"},
+		},
+		{
+			name: "parametrized case",
+			config: `
+cases:
+  - parametrized:
+      parameters: [{id: 1}]
+      request: {path: "{{ .services.api.url }}/{{ .params.id }}"}
+      expected_code: 200`,
+			tester:   "parametrized [id=1]",
+			expected: map[interface{}]interface{}{"path": "http://api:8080/1"},
+		},
+		{
+			name: "general case with parameters of selector",
+			config: `
+general_cases:
+  common:
+    cases:
+      - check: {request: {path: "{{ .params.url }}/{{ .params.id }}"}, expected_code: 200}
+cases:
+  - parametrized:
+      parameters: [{id: 1}]
+      request: {path: "/{{ .params.id }}"}
+      expected_code: 200
+      general_cases:
+        include: [common]
+        request: {path: "/{{ .params.id }}"}
+        with: {url: "{{ .services.api.url }}"}`,
+			tester: "parametrized [id=1] common check",
+			expected: []interface{}{
+				map[interface{}]interface{}{"path": "http://api:8080/1"},
+				map[interface{}]interface{}{"path": "/1"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			parsedConfig := parseTestConfig(t, "applications: {app: {request_type: test}}\n"+test.config)
+			environment := map[string]interface{}{
+				"services": map[string]interface{}{
+					"api": map[string]interface{}{"url": "http://api:8080"},
+				},
+			}
+			var tester *Tester
+			for i := range parsedConfig.Testers {
+				if parsedConfig.Testers[i].Name == test.tester {
+					tester = &parsedConfig.Testers[i]
+				}
+			}
+			if tester == nil {
+				t.Fatalf("tester %q not found in %v", test.tester, testersNames(parsedConfig))
+			}
+			madeRequests = nil
+			err := tester.Exec(environment)
+			if err != nil {
+				t.Fatalf("unable to exec tester %q: %v", tester.Name, err)
+			}
+			if len(madeRequests) != 1 || !reflect.DeepEqual(madeRequests[0], test.expected) {
+				t.Errorf("expected request %#v, got %#v", test.expected, madeRequests)
+			}
+		})
+	}
+}
+
+func TestRequestWithMissingParameter(t *testing.T) {
+	parsedConfig := parseTestConfig(t, `
+applications: {app: {request_type: test}}
+cases:
+  - parametrized:
+      parameters: [{id: 1}]
+      request: {path: "/{{ .params.name }}"}
+      expected_code: 200`)
+	err := parsedConfig.Testers[0].Exec(map[string]interface{}{})
+	if err == nil {
+		t.Fatalf("expected error of missing parameter")
+	}
+}
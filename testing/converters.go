@@ -16,11 +16,13 @@ type CustomEqualityChecker interface {
 // TODO хорошо бы apply-ить конвертеры при настройке, а не при работе, чтобы ошибки выдавались перед запуском
 //      но это не полуяится так как нужно учитывать интерполяцию. самая лучшая мысль - создавать объект "equality checker",
 //      который будет создаваться с expected-значением на этапе настройки и сможет учитывать конвертеры и интерполяцию и будет вызываться на этапе проверки с actual-ными значениями
-func ApplyConverters(input interface{}) (interface{}, error) {
-	return applyConverters(input, "")
+// params of case are taken from variables to store snapshots of each combination of parameters separately
+func ApplyConverters(input interface{}, variables map[string]interface{}) (interface{}, error) {
+	params, _ := variables["params"].(map[string]interface{})
+	return applyConverters(input, "", params)
 }
 
-func applyConverters(inputInterface interface{}, objectPath string) (interface{}, error) {
+func applyConverters(inputInterface interface{}, objectPath string, params map[string]interface{}) (interface{}, error) {
 	// fmt.Printf("()()()()()() input %#v\n", inputInterface)
 	switch input := inputInterface.(type) {
 	case map[string]interface{}:
@@ -62,19 +64,21 @@ func applyConverters(inputInterface interface{}, objectPath string) (interface{}
 					return RegexpEqualityChecker{
 						re: compiledRe,
 					}, nil
+				case "snapshot":
+					return NewSnapshotEqualityChecker(v, params)
 				default:
 					return nil, fmt.Errorf("converter with name %q not found", converterName)
 				}
 			}
 			switch v.(type) {
 			case map[string]interface{}:
-				res, err := applyConverters(v, fmt.Sprintf("%s.%s", objectPath, k))
+				res, err := applyConverters(v, fmt.Sprintf("%s.%s", objectPath, k), params)
 				if err != nil {
 					return nil, err // fmt.Errorf("unable to apply converters to %s.%s: %v", objectPath, k, err)
 				}
 				input[k] = res
 			case []interface{}:
-				res, err := applyConverters(v, fmt.Sprintf("%s.%s", objectPath, k))
+				res, err := applyConverters(v, fmt.Sprintf("%s.%s", objectPath, k), params)
 				if err != nil {
 					return nil, err // fmt.Errorf("unable to apply converters to %s.%s: %v", objectPath, k, err)
 				}
@@ -83,7 +87,7 @@ func applyConverters(inputInterface interface{}, objectPath string) (interface{}
 		}
 	case []interface{}:
 		for i, val := range input {
-			res, err := applyConverters(val, fmt.Sprintf("%s[%d]", objectPath, i))
+			res, err := applyConverters(val, fmt.Sprintf("%s[%d]", objectPath, i), params)
 			if err != nil {
 				return nil, err // fmt.Errorf("unable to apply converters to %s.%d: %v", objectPath, i, err)
 			}
@@ -2,23 +2,46 @@ package testing
 
 import (
 	"fmt"
+	"integration_framework/plugins"
 )
 
-func (pc *ParsedConfig) RunTests() (exitCode int) {
-	failedTests := make(map[string]error)
+type failedTest struct {
+	name string
+	err  error
+	// logs written by application while test was running
+	applicationLogs string
+}
+
+func (pc *ParsedConfig) RunTests(applicationsUrls map[string]string, runLogs *plugins.RunLogs) (exitCode int) {
+	var failedTests []failedTest
 
 	if len(pc.Testers) == 0 {
 		fmt.Println("==== no tests to run")
 		return 3
 	}
 
+	environment := pc.environmentVariables(applicationsUrls)
 	for _, tester := range pc.Testers {
 		fmt.Printf("---- %s\n", tester.Name)
 
-		err := tester.Exec()
+		testLogs := runLogs.StartTest(tester.application)
+		for _, service := range pc.Services {
+			if serviceWithTestLogs, ok := service.(plugins.IServiceWithTestLogs); ok {
+				serviceWithTestLogs.TestStarted(testLogs)
+			}
+		}
+		err := tester.Exec(environment)
 		if err != nil {
 			fmt.Printf("====> test failed: %v\n", err)
-			failedTests[tester.Name] = err
+			applicationLogs, logsErr := testLogs.Read(tester.application)
+			if logsErr != nil {
+				fmt.Printf("unable to read logs of application %q: %v\n", tester.application, logsErr)
+			}
+			failedTests = append(failedTests, failedTest{
+				name:            tester.Name,
+				err:             err,
+				applicationLogs: applicationLogs,
+			})
 		} else {
 			fmt.Printf("====> test passed\n")
 		}
@@ -28,9 +51,12 @@ func (pc *ParsedConfig) RunTests() (exitCode int) {
 		fmt.Printf("==== %d test(s) passed\n", len(pc.Testers))
 	} else {
 		fmt.Printf("%d test(s) of %d fails\n", len(failedTests), len(pc.Testers))
-		for failedTestName, failedTestError := range failedTests {
-			fmt.Printf("==== #%q\n", failedTestName)
-			fmt.Printf("%v\n", failedTestError)
+		for _, failedTest := range failedTests {
+			fmt.Printf("==== #%q\n", failedTest.name)
+			fmt.Printf("%v\n", failedTest.err)
+			if failedTest.applicationLogs != "" {
+				fmt.Printf("---- application logs:\n%s", failedTest.applicationLogs)
+			}
 		}
 		exitCode = 2
 	}
@@ -0,0 +1,326 @@
+package testing
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"integration_framework/helper"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	snapshotsDirectory string
+	updateSnapshots    bool
+
+	snapshotNameRegexp      = regexp.MustCompile(`^[\w.\-]+(/[\w.\-]+)*$`)
+	snapshotNamePartRegexp  = regexp.MustCompile(`^\w+$`)
+	snapshotPathItemRegexp  = regexp.MustCompile(`^([^\[\]]*)((\[(\d+|\*)\])*)$`)
+	snapshotPathIndexRegexp = regexp.MustCompile(`\[(\d+|\*)\]`)
+)
+
+// ConfigureSnapshots sets directory where snapshots are stored and whether snapshots should be written by actual values.
+// Missing snapshots are not written without update, so run with forgotten snapshot fails instead of passing on CI
+func ConfigureSnapshots(directory string, update bool) {
+	snapshotsDirectory = directory
+	updateSnapshots = update
+}
+
+// IsSnapshotDefinition returns true if value is map like `{$$_snapshot: name}`
+func IsSnapshotDefinition(v interface{}) bool {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		yamlMap, ok := helper.IsYamlMap(v)
+		if !ok {
+			return false
+		}
+		m = yamlMap.ToMap()
+	}
+	_, ok = m["$$_snapshot"]
+	return ok
+}
+
+type SnapshotEqualityChecker struct {
+	name        string
+	ignorePaths [][]string
+}
+
+// NewSnapshotEqualityChecker creates checker from value of `$$_snapshot` converter.
+// value can be name of snapshot or map like `{name: snapshot_name, ignore: [data.items[*].id]}`.
+// Parameters of parametrized case are added to name, so every row or combination of matrix has own snapshot
+func NewSnapshotEqualityChecker(v interface{}, params map[string]interface{}) (*SnapshotEqualityChecker, error) {
+	var (
+		name   string
+		ignore []interface{}
+	)
+	switch value := v.(type) {
+	case string:
+		name = value
+	case map[string]interface{}:
+		var ok bool
+		name, ok = value["name"].(string)
+		if !ok {
+			return nil, fmt.Errorf("snapshot name should be string, but it is %T (%#v)", value["name"], value["name"])
+		}
+		if value["ignore"] != nil {
+			ignore, ok = value["ignore"].([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("snapshot ignore should be list, but it is %T (%#v)", value["ignore"], value["ignore"])
+			}
+		}
+	default:
+		return nil, fmt.Errorf("snapshot should be defined as string or map, but it is %T (%#v)", v, v)
+	}
+	if !snapshotNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid snapshot name %q", name)
+	}
+	checker := &SnapshotEqualityChecker{
+		name: name + snapshotParamsSuffix(params),
+	}
+	for _, ignorePathInterface := range ignore {
+		ignorePath, ok := ignorePathInterface.(string)
+		if !ok {
+			return nil, fmt.Errorf("ignored path should be string, but it is %T (%#v)", ignorePathInterface, ignorePathInterface)
+		}
+		parsedPath, err := parseSnapshotPath(ignorePath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse ignored path %q: %v", ignorePath, err)
+		}
+		checker.ignorePaths = append(checker.ignorePaths, parsedPath)
+	}
+	return checker, nil
+}
+
+func (e SnapshotEqualityChecker) IsEqualTo(v interface{}) (bool, error) {
+	if snapshotsDirectory == "" {
+		return false, fmt.Errorf("snapshots directory not configured")
+	}
+	actual, err := normalizeSnapshotValue(v)
+	if err != nil {
+		return false, fmt.Errorf("unable to normalize actual value: %v", err)
+	}
+	for _, ignorePath := range e.ignorePaths {
+		actual = removeSnapshotPath(actual, ignorePath)
+	}
+
+	pathToSnapshot := filepath.Join(snapshotsDirectory, e.name+".json")
+	snapshotBytes, err := ioutil.ReadFile(pathToSnapshot)
+	if os.IsNotExist(err) && !updateSnapshots {
+		return false, fmt.Errorf("snapshot %q not found, run with --update-snapshots to write it", e.name)
+	}
+	if updateSnapshots {
+		err = e.write(pathToSnapshot, actual)
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf(".. snapshot %q written\n", e.name)
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to read snapshot %q: %v", e.name, err)
+	}
+
+	var expected interface{}
+	err = json.Unmarshal(snapshotBytes, &expected)
+	if err != nil {
+		return false, fmt.Errorf("unable to unmarshal snapshot %q: %v", e.name, err)
+	}
+	if reflect.DeepEqual(actual, expected) {
+		return true, nil
+	}
+	err = IsEqual(actual, expected)
+	if err != nil {
+		return false, fmt.Errorf("actual value not matched snapshot %q: %v", e.name, err)
+	}
+	// actual value contains everything from snapshot, so it has some extra data or values differ only in types
+	err = IsEqual(expected, actual)
+	if err != nil {
+		return false, fmt.Errorf("actual value contains data not stored in snapshot %q (values swapped): %v", e.name, err)
+	}
+	return false, fmt.Errorf("actual value not matched snapshot %q.\n Actual value  : %s\n Snapshot value: %s", e.name, describeSnapshotValue(actual), describeSnapshotValue(expected))
+}
+
+// describeSnapshotValue returns value as json to show difference in types like `0` and `"0"`
+func describeSnapshotValue(v interface{}) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(encoded)
+}
+
+func (e SnapshotEqualityChecker) write(pathToSnapshot string, value interface{}) error {
+	snapshotBytes, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal snapshot %q: %v", e.name, err)
+	}
+	err = os.MkdirAll(filepath.Dir(pathToSnapshot), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("unable to create directory for snapshot %q: %v", e.name, err)
+	}
+	err = ioutil.WriteFile(pathToSnapshot, append(snapshotBytes, '\n'), 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write snapshot %q: %v", e.name, err)
+	}
+	return nil
+}
+
+// snapshotParamsSuffix returns suffix like `.param1-value1.param2-value2` sorted by names of parameters.
+// Values which can not be used in file name are replaced by hash, so different values never share snapshot
+func snapshotParamsSuffix(params map[string]interface{}) string {
+	var names []string
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var suffix strings.Builder
+	for _, name := range names {
+		suffix.WriteString("." + snapshotNamePart(name) + "-" + snapshotNamePart(params[name]))
+	}
+	return suffix.String()
+}
+
+func snapshotNamePart(v interface{}) string {
+	switch v.(type) {
+	case string, bool, int, int64, float64:
+		part := fmt.Sprintf("%v", v)
+		if snapshotNamePartRegexp.MatchString(part) {
+			return part
+		}
+	}
+	encoded, err := json.Marshal(prepareSnapshotValue(v))
+	if err != nil {
+		encoded = []byte(fmt.Sprintf("%#v", v))
+	}
+	hash := fnv.New32a()
+	hash.Write(encoded)
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
+
+// normalizeSnapshotValue converts value to the form it will have after saving to snapshot and loading it back
+func normalizeSnapshotValue(v interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(prepareSnapshotValue(v))
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	err = json.Unmarshal(encoded, &res)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// prepareSnapshotValue converts values returned by databases to values readable in snapshots
+func prepareSnapshotValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []byte:
+		return string(value)
+	case time.Time:
+		return value.UTC().Format(helper.TimeLayout)
+	case *time.Time:
+		if value == nil {
+			return nil
+		}
+		return value.UTC().Format(helper.TimeLayout)
+	case helper.YamlMap:
+		return prepareSnapshotValue(value.ToMap())
+	case map[interface{}]interface{}:
+		return prepareSnapshotValue(helper.YamlMap(value).ToMap())
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			res[k] = prepareSnapshotValue(item)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(value))
+		for i, item := range value {
+			res[i] = prepareSnapshotValue(item)
+		}
+		return res
+	}
+	return v
+}
+
+// parseSnapshotPath parses path like `data.items[*].id` to list of segments `data`, `items`, `*`, `id`
+func parseSnapshotPath(path string) ([]string, error) {
+	var res []string
+	for _, item := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if !snapshotPathItemRegexp.MatchString(item) {
+			return nil, fmt.Errorf("invalid path segment %q", item)
+		}
+		matches := snapshotPathItemRegexp.FindStringSubmatch(item)
+		if matches[1] != "" {
+			res = append(res, matches[1])
+		}
+		for _, index := range snapshotPathIndexRegexp.FindAllStringSubmatch(matches[2], -1) {
+			res = append(res, "["+index[1]+"]")
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("path is empty")
+	}
+	return res, nil
+}
+
+func removeSnapshotPath(v interface{}, path []string) interface{} {
+	if len(path) == 0 {
+		return v
+	}
+	segment := path[0]
+	isLast := len(path) == 1
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if segment == "*" {
+			for k, item := range value {
+				if isLast {
+					delete(value, k)
+				} else {
+					value[k] = removeSnapshotPath(item, path[1:])
+				}
+			}
+			return value
+		}
+		item, ok := value[segment]
+		if !ok {
+			return value
+		}
+		if isLast {
+			delete(value, segment)
+		} else {
+			value[segment] = removeSnapshotPath(item, path[1:])
+		}
+		return value
+	case []interface{}:
+		if segment == "[*]" {
+			if isLast {
+				return []interface{}{}
+			}
+			for i, item := range value {
+				value[i] = removeSnapshotPath(item, path[1:])
+			}
+			return value
+		}
+		if !strings.HasPrefix(segment, "[") {
+			return value
+		}
+		index, err := strconv.Atoi(strings.Trim(segment, "[]"))
+		if err != nil || index >= len(value) {
+			return value
+		}
+		if isLast {
+			return append(value[:index:index], value[index+1:]...)
+		}
+		value[index] = removeSnapshotPath(value[index], path[1:])
+		return value
+	}
+	return v
+}
@@ -0,0 +1,289 @@
+package testing
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotEqualityChecker(t *testing.T) {
+	directory, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	defer ConfigureSnapshots("", false)
+	checker, err := NewSnapshotEqualityChecker("users/list", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := map[string]interface{}{"users": []interface{}{"alice"}}
+	pathToSnapshot := filepath.Join(directory, "users", "list.json")
+
+	ConfigureSnapshots(directory, false)
+	equal, err := checker.IsEqualTo(actual)
+	if equal || err == nil || !strings.Contains(err.Error(), "--update-snapshots") {
+		t.Errorf("expected missing snapshot to fail without update, got %v, %v", equal, err)
+	}
+	if _, err := os.Stat(pathToSnapshot); !os.IsNotExist(err) {
+		t.Errorf("expected missing snapshot not written without update, got %v", err)
+	}
+
+	ConfigureSnapshots(directory, true)
+	equal, err = checker.IsEqualTo(actual)
+	if !equal || err != nil {
+		t.Fatalf("expected missing snapshot written with update, got %v, %v", equal, err)
+	}
+
+	ConfigureSnapshots(directory, false)
+	equal, err = checker.IsEqualTo(actual)
+	if !equal || err != nil {
+		t.Errorf("expected actual value equal to written snapshot, got %v, %v", equal, err)
+	}
+	changed := map[string]interface{}{"users": []interface{}{"bob"}}
+	equal, err = checker.IsEqualTo(changed)
+	if equal || err == nil {
+		t.Errorf("expected changed value not equal to snapshot, got %v, %v", equal, err)
+	}
+
+	ConfigureSnapshots(directory, true)
+	equal, err = checker.IsEqualTo(changed)
+	if !equal || err != nil {
+		t.Fatalf("expected snapshot rewritten with update, got %v, %v", equal, err)
+	}
+	ConfigureSnapshots(directory, false)
+	equal, err = checker.IsEqualTo(changed)
+	if !equal || err != nil {
+		t.Errorf("expected changed value equal to rewritten snapshot, got %v, %v", equal, err)
+	}
+}
+
+// writeSnapshot writes value as snapshot with name and configures directory without update
+func writeSnapshot(t *testing.T, directory string, name string, value interface{}) {
+	ConfigureSnapshots(directory, true)
+	defer ConfigureSnapshots(directory, false)
+	checker, err := NewSnapshotEqualityChecker(name, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal, err := checker.IsEqualTo(value)
+	if !equal || err != nil {
+		t.Fatalf("unable to write snapshot %q: %v, %v", name, equal, err)
+	}
+}
+
+func TestSnapshotIgnorePaths(t *testing.T) {
+	directory, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	defer ConfigureSnapshots("", false)
+	writeSnapshot(t, directory, "items", map[string]interface{}{
+		"data": map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "first"},
+				map[string]interface{}{"name": "second"},
+			},
+		},
+	})
+	checker, err := NewSnapshotEqualityChecker(map[string]interface{}{
+		"name":   "items",
+		"ignore": []interface{}{"data.items[*].id", "data.total", "meta"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := func(firstName string) map[string]interface{} {
+		return map[string]interface{}{
+			"data": map[string]interface{}{
+				"items": []interface{}{
+					map[string]interface{}{"id": 10, "name": firstName},
+					map[string]interface{}{"id": 11, "name": "second"},
+				},
+				"total": 2,
+			},
+			"meta": map[string]interface{}{"request_id": "abc"},
+		}
+	}
+	equal, err := checker.IsEqualTo(actual("first"))
+	if !equal || err != nil {
+		t.Errorf("expected ignored paths not compared, got %v, %v", equal, err)
+	}
+	equal, err = checker.IsEqualTo(actual("changed"))
+	if equal || err == nil {
+		t.Errorf("expected not ignored paths compared, got %v, %v", equal, err)
+	}
+
+	checker, err = NewSnapshotEqualityChecker(map[string]interface{}{
+		"name":   "items",
+		"ignore": []interface{}{"data.total"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equal, err = checker.IsEqualTo(actual("first"))
+	if equal || err == nil || !strings.Contains(err.Error(), "not stored in snapshot") {
+		t.Errorf("expected extra data reported, got %v, %v", equal, err)
+	}
+}
+
+func TestRemoveSnapshotPath(t *testing.T) {
+	cases := []struct {
+		name     string
+		path     string
+		value    interface{}
+		expected interface{}
+	}{
+		{
+			name:     "key",
+			path:     "id",
+			value:    map[string]interface{}{"id": 1.0, "name": "a"},
+			expected: map[string]interface{}{"name": "a"},
+		},
+		{
+			name:     "any key",
+			path:     "*.id",
+			value:    map[string]interface{}{"a": map[string]interface{}{"id": 1.0}, "b": map[string]interface{}{"id": 2.0, "x": true}},
+			expected: map[string]interface{}{"a": map[string]interface{}{}, "b": map[string]interface{}{"x": true}},
+		},
+		{
+			name:     "index",
+			path:     "items[1]",
+			value:    map[string]interface{}{"items": []interface{}{"a", "b", "c"}},
+			expected: map[string]interface{}{"items": []interface{}{"a", "c"}},
+		},
+		{
+			name:     "all items",
+			path:     "items[*]",
+			value:    map[string]interface{}{"items": []interface{}{"a", "b"}},
+			expected: map[string]interface{}{"items": []interface{}{}},
+		},
+		{
+			name:     "nested lists",
+			path:     "[*][0].id",
+			value:    []interface{}{[]interface{}{map[string]interface{}{"id": 1.0, "name": "a"}}},
+			expected: []interface{}{[]interface{}{map[string]interface{}{"name": "a"}}},
+		},
+		{
+			name:     "missing path",
+			path:     "data.items[5].id",
+			value:    map[string]interface{}{"data": map[string]interface{}{"items": []interface{}{"a"}}},
+			expected: map[string]interface{}{"data": map[string]interface{}{"items": []interface{}{"a"}}},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path, err := parseSnapshotPath(c.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual := removeSnapshotPath(c.value, path)
+			if !reflect.DeepEqual(actual, c.expected) {
+				t.Errorf("expected %#v, got %#v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSnapshotMismatchInTypes(t *testing.T) {
+	directory, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	defer ConfigureSnapshots("", false)
+	writeSnapshot(t, directory, "count", map[string]interface{}{"count": 0})
+	checker, err := NewSnapshotEqualityChecker("count", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// values are equal in both directions, but differ in types
+	equal, err := checker.IsEqualTo(map[string]interface{}{"count": "0"})
+	if equal || err == nil {
+		t.Fatalf("expected value of other type not equal to snapshot, got %v, %v", equal, err)
+	}
+	if strings.Contains(err.Error(), "<nil>") || !strings.Contains(err.Error(), `{"count":"0"}`) || !strings.Contains(err.Error(), `{"count":0}`) {
+		t.Errorf("expected both values in error, got %v", err)
+	}
+}
+
+func TestSnapshotOfParametrizedCase(t *testing.T) {
+	directory, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	defer ConfigureSnapshots("", false)
+
+	rows := []map[string]interface{}{
+		{"user": "alice", "page": 1},
+		{"user": "bob", "page": 1},
+		{"user": "bob", "page": 2},
+	}
+	ConfigureSnapshots(directory, true)
+	for _, row := range rows {
+		converted, err := ApplyConverters(map[string]interface{}{"$$_snapshot": "users"}, map[string]interface{}{"params": row})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = IsEqual(row, converted)
+		if err != nil {
+			t.Fatalf("unable to write snapshot of %v: %v", row, err)
+		}
+	}
+	for _, name := range []string{"users.page-1.user-alice.json", "users.page-1.user-bob.json", "users.page-2.user-bob.json"} {
+		if _, err := os.Stat(filepath.Join(directory, name)); err != nil {
+			t.Errorf("expected snapshot %s written: %v", name, err)
+		}
+	}
+
+	ConfigureSnapshots(directory, false)
+	for _, row := range rows {
+		converted, err := ApplyConverters(map[string]interface{}{"$$_snapshot": "users"}, map[string]interface{}{"params": row})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = IsEqual(row, converted)
+		if err != nil {
+			t.Errorf("expected %v equal to own snapshot, got %v", row, err)
+		}
+	}
+}
+
+func TestSnapshotParamsSuffix(t *testing.T) {
+	cases := []struct {
+		name     string
+		params   map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "no params",
+			params:   nil,
+			expected: "",
+		},
+		{
+			name:     "sorted by names",
+			params:   map[string]interface{}{"b": 2, "a": "x", "c": true},
+			expected: ".a-x.b-2.c-true",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			suffix := snapshotParamsSuffix(c.params)
+			if suffix != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, suffix)
+			}
+		})
+	}
+
+	// values not allowed in file name are hashed, so different values have different suffixes
+	first := snapshotParamsSuffix(map[string]interface{}{"filter": map[string]interface{}{"name": "a b"}})
+	second := snapshotParamsSuffix(map[string]interface{}{"filter": map[string]interface{}{"name": "a_b"}})
+	if first == second || !snapshotNameRegexp.MatchString("users"+first) {
+		t.Errorf("expected different valid suffixes, got %q and %q", first, second)
+	}
+}
@@ -12,41 +12,80 @@ type createTesterContext struct {
 	Services map[string]plugins.IService
 }
 
+// requesterFactory creates requester of test case by variables of its execution
+type requesterFactory func(variables map[string]interface{}) (plugins.IRequester, error)
+
 type Tester struct {
 	Name             string
 	servicePreparers []plugins.IServicePreparer
 	serviceCheckers  []plugins.IServiceChecker
-	requester        plugins.IRequester
+	requester        requesterFactory
 	expectedResponse *helper.YamlMap
 	expectedCode     int
+	params           map[string]interface{}
+	// parameters passed to general case by `with` of selector, they are interpolated before execution
+	with map[string]interface{}
+	// name of application which receives request
+	application string
 }
 
-func (pc *ParsedConfig) createTester(testCaseName string, servicePreparers []plugins.IServicePreparer, serviceCheckers []plugins.IServiceChecker, testCase *application_config.TestCase, requester plugins.IRequester) error {
+func (pc *ParsedConfig) createTester(testCaseName string, servicePreparers []plugins.IServicePreparer, serviceCheckers []plugins.IServiceChecker, params map[string]interface{}, with map[string]interface{}, application string, testCase *application_config.TestCase, requester requesterFactory) error {
 	pc.Testers = append(pc.Testers, Tester{
 		Name:             testCaseName,
 		servicePreparers: servicePreparers,
-		serviceCheckers:  serviceCheckers,
+		serviceCheckers:  append(append([]plugins.IServiceChecker{}, serviceCheckers...), pc.defaultCheckers...),
 		requester:        requester,
 		expectedResponse: testCase.ExpectedResponse,
 		expectedCode:     testCase.ExpectedCode,
+		params:           params,
+		with:             with,
+		application:      application,
 	})
 	return nil
 }
 
-func (t Tester) Exec() error {
+func (t Tester) Exec(environment map[string]interface{}) error {
+	variables := map[string]interface{}{
+		"params": t.params,
+	}
+	for key, value := range environment {
+		variables[key] = value
+	}
+	if applications, ok := environment["applications"].(map[string]interface{}); ok {
+		variables["application"] = applications[t.application]
+	}
+	params, err := t.interpolateWith(variables)
+	if err != nil {
+		return err
+	}
+	variables["params"] = params
+
+	requester, err := t.requester(variables)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %v", err)
+	}
+
 	for _, servicePreparer := range t.servicePreparers {
-		err := servicePreparer.PrepareService()
+		err := servicePreparer.PrepareService(variables)
 		if err != nil {
 			return fmt.Errorf("unable to prepare service: %v", err)
 		}
 	}
 
-	variables := make(map[string]interface{})
+	for _, serviceChecker := range t.serviceCheckers {
+		if checkerBeforeRequest, ok := serviceChecker.(plugins.IServiceCheckerBeforeRequest); ok {
+			err := checkerBeforeRequest.BeforeRequest(variables)
+			if err != nil {
+				return fmt.Errorf("unable to prepare service check: %v", err)
+			}
+		}
+	}
+
 	saveResult := func(key string, value interface{}) {
 		variables[key] = value
 	}
 
-	err := t.checkRequest(saveResult, variables)
+	err = t.checkRequest(requester, len(params) != 0, saveResult, variables)
 	if err != nil {
 		return fmt.Errorf("unable to check request: %v", err)
 	}
@@ -61,8 +100,28 @@ func (t Tester) Exec() error {
 	return nil
 }
 
-func (t Tester) checkRequest(saveResult plugins.FnResultSaver, variables map[string]interface{}) error {
-	responseBody, statusCode, err := t.requester.MakeRequest()
+// interpolateWith returns parameters of test case along with parameters passed by selector of general case.
+// Selector's parameters are interpolated by parameters of test case and urls of launched services
+func (t Tester) interpolateWith(variables map[string]interface{}) (map[string]interface{}, error) {
+	if len(t.with) == 0 {
+		return t.params, nil
+	}
+	with, err := helper.ApplyInterpolationForObject(t.with, variables)
+	if err != nil {
+		return nil, fmt.Errorf("unable to interpolate parameters of selector: %v", err)
+	}
+	params := make(map[string]interface{}, len(t.params)+len(t.with))
+	for key, value := range t.params {
+		params[key] = value
+	}
+	for key, value := range with.(map[string]interface{}) {
+		params[key] = value
+	}
+	return params, nil
+}
+
+func (t Tester) checkRequest(requester plugins.IRequester, parametrized bool, saveResult plugins.FnResultSaver, variables map[string]interface{}) error {
+	responseBody, statusCode, err := requester.MakeRequest(variables)
 	if err != nil {
 		return fmt.Errorf("unable to make request: %v", err)
 	}
@@ -85,7 +144,15 @@ func (t Tester) checkRequest(saveResult plugins.FnResultSaver, variables map[str
 			}
 		} else {
 			// ... and it defined like map
-			expectedBody, err := ApplyConverters(t.expectedResponse.ToMap())
+			var expectedBody interface{} = t.expectedResponse.ToMap()
+			// parameters are interpolated only in parametrized test cases, so other expected responses can contain `{{` as is
+			if parametrized {
+				expectedBody, err = helper.ApplyInterpolationForObject(expectedBody, variables)
+				if err != nil {
+					return fmt.Errorf("unable to apply interpolation to expected_response: %v", err)
+				}
+			}
+			expectedBody, err = ApplyConverters(expectedBody, variables)
 			if err != nil {
 				return fmt.Errorf("unable to apply converters: %v", err)
 			}